@@ -2,6 +2,9 @@ package adapters
 
 import (
 	"context"
+	"encoding/json"
+
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
 )
 
 // LLMAdapter is the interface that all LLM adapters must implement
@@ -12,6 +15,10 @@ type LLMAdapter interface {
 	// ChatCompletionStream sends a streaming chat completion request
 	ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (ChatCompletionStream, error)
 
+	// Embed creates embeddings for the request's inputs. Adapters whose
+	// provider has no embedding models return an error wrapping ErrNotSupported.
+	Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error)
+
 	// GetModelInfo gets information about a model
 	GetModelInfo(ctx context.Context, modelID string) (*ModelInfo, error)
 
@@ -22,19 +29,114 @@ type LLMAdapter interface {
 	GetCapabilities() *AdapterCapabilities
 }
 
-// ChatCompletionRequest represents a chat completion request
+// ModelLister is implemented by adapters that can discover the models their
+// provider serves
+type ModelLister interface {
+	// ListModels lists the available models
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// DetectionHandler receives the provider's own safety or content filter
+// detections for responses that were annotated but not blocked
+type DetectionHandler func(ctx context.Context, model string, detections []detector.DetectionResult)
+
+// ChatCompletionRequest represents a chat completion request. Fields that
+// are not modelled here are kept in Extra and sent on unchanged.
 type ChatCompletionRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float32   `json:"temperature,omitempty"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Stream      bool      `json:"stream,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    *float32        `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+	Tools          []Tool          `json:"tools,omitempty"`
+	ToolChoice     *ToolChoice     `json:"tool_choice,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Extra          Extra           `json:"-"`
 }
 
-// Message represents a chat message
+// Message represents a chat message. Content holds plain text content;
+// multimodal content is held in Parts instead. Assistant messages may carry
+// ToolCalls, and tool messages answer the call named by ToolCallID.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string        `json:"role"`
+	Content    string        `json:"content"`
+	Parts      []ContentPart `json:"-"`
+	Name       string        `json:"name,omitempty"`
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+	Extra      Extra         `json:"-"`
+}
+
+// ContentPart is one part of multimodal message content: text, image_url
+// or input_audio
+type ContentPart struct {
+	Type       string      `json:"type"`
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *ImageURL   `json:"image_url,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+	Extra      Extra       `json:"-"`
+}
+
+// ImageURL is an image given by URL or as a base64 data URL
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// InputAudio is base64-encoded audio in the given format, e.g. wav or mp3
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+// Tool is a function the model may call
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes a callable function. Parameters is a JSON schema.
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// ToolChoice controls tool use: Mode is none, auto or required, or Function
+// names the function the model must call
+type ToolChoice struct {
+	Mode     string
+	Function string
+}
+
+// ToolCall is a function call made by the model. Index is only set on
+// stream deltas, where a call's arguments arrive in pieces.
+type ToolCall struct {
+	Index    *int             `json:"index,omitempty"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction is the function and JSON-encoded arguments of a tool call
+type ToolCallFunction struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// ResponseFormat requests text, json_object or json_schema output
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is the schema structured output must follow
+type JSONSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
 }
 
 // ChatCompletionResponse represents a chat completion response
@@ -45,6 +147,7 @@ type ChatCompletionResponse struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   Usage    `json:"usage"`
+	Extra   Extra    `json:"-"`
 }
 
 // Choice represents a response choice
@@ -77,6 +180,7 @@ type ChatCompletionStreamResponse struct {
 	Created int64          `json:"created"`
 	Model   string         `json:"model"`
 	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"`
 }
 
 // StreamChoice represents a streaming response choice
@@ -86,6 +190,36 @@ type StreamChoice struct {
 	FinishReason string  `json:"finish_reason"`
 }
 
+// EmbeddingRequest represents an embeddings request. Fields that are not
+// modelled here are kept in Extra and sent on unchanged.
+type EmbeddingRequest struct {
+	Model          string         `json:"model"`
+	Input          EmbeddingInput `json:"input"`
+	EncodingFormat string         `json:"encoding_format,omitempty"`
+	Dimensions     int            `json:"dimensions,omitempty"`
+	User           string         `json:"user,omitempty"`
+	Extra          Extra          `json:"-"`
+}
+
+// EmbeddingInput is the text to embed. A single string is decoded as a
+// list of one; pre-tokenized input is rejected, as it cannot be redacted.
+type EmbeddingInput []string
+
+// EmbeddingResponse represents an embeddings response
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Data   []Embedding `json:"data"`
+	Model  string      `json:"model"`
+	Usage  Usage       `json:"usage"`
+}
+
+// Embedding is the embedding of the input at Index
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
 // ModelInfo represents model information
 type ModelInfo struct {
 	ID      string `json:"id"`
@@ -109,8 +243,11 @@ type AdapterFactory interface {
 	CreateAdapter(config *AdapterConfig) (LLMAdapter, error)
 }
 
-// AdapterConfig represents adapter configuration
+// AdapterConfig represents adapter configuration. Models lists the model
+// names the adapter serves; a trailing * matches by prefix and * alone
+// matches any model.
 type AdapterConfig struct {
-	Type     string                 `json:"type"`
-	Settings map[string]interface{} `json:"settings"`
+	Type     string                 `json:"type" mapstructure:"type"`
+	Models   []string               `json:"models" mapstructure:"models"`
+	Settings map[string]interface{} `json:"settings" mapstructure:"settings"`
 }
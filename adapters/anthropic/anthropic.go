@@ -0,0 +1,514 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+)
+
+const (
+	// DefaultBaseURL is the Anthropic API endpoint
+	DefaultBaseURL = "https://api.anthropic.com"
+	// DefaultVersion is the anthropic-version header sent with every request
+	DefaultVersion = "2023-06-01"
+	// DefaultMaxTokens is used when a request does not set max_tokens, which
+	// the Messages API requires
+	DefaultMaxTokens = 4096
+)
+
+// AnthropicAdapter implements the LLMAdapter interface for the Anthropic Messages API
+type AnthropicAdapter struct {
+	apiKey     string
+	baseURL    string
+	version    string
+	maxTokens  int
+	httpClient *http.Client
+	breakers   *circuitbreaker.Manager
+}
+
+// Ensure AnthropicAdapter implements adapters.LLMAdapter
+var _ adapters.LLMAdapter = (*AnthropicAdapter)(nil)
+
+// MessagesRequest is a Messages API request
+type MessagesRequest struct {
+	Model       string      `json:"model"`
+	System      string      `json:"system,omitempty"`
+	Messages    []Message   `json:"messages"`
+	MaxTokens   int         `json:"max_tokens"`
+	Temperature *float32    `json:"temperature,omitempty"`
+	Stream      bool        `json:"stream,omitempty"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+}
+
+// Message is a Messages API message
+type Message struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock is a block of message content: text, image, tool_use or
+// tool_result
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Source    *ImageSource    `json:"source,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+// ImageSource is an inline base64 image or an image URL
+type ImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Tool is a tool definition
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// ToolChoice is auto, any, none or a named tool
+type ToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// MessagesResponse is a Messages API response
+type MessagesResponse struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Role       string         `json:"role"`
+	Model      string         `json:"model"`
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      Usage          `json:"usage"`
+}
+
+// Usage is Messages API token usage
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// APIError is an error reported by the Anthropic API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("Anthropic API returned status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("Anthropic API error: %s: %s", e.Type, e.Message)
+}
+
+// NewAnthropicAdapter creates a new Anthropic adapter
+func NewAnthropicAdapter(apiKey, baseURL string, timeout time.Duration) *AnthropicAdapter {
+	return &AnthropicAdapter{
+		apiKey:    apiKey,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		version:   DefaultVersion,
+		maxTokens: DefaultMaxTokens,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// NewAdapterFromSettings creates an Anthropic adapter from registry settings:
+// apiKey or apiKeyEnv, baseUrl, version, maxTokens and timeout
+func NewAdapterFromSettings(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+	apiKey, err := adapters.SecretSetting(settings, "apiKey")
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := adapters.StringSetting(settings, "baseUrl", DefaultBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := adapters.StringSetting(settings, "version", DefaultVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens, err := adapters.IntSetting(settings, "maxTokens", DefaultMaxTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := adapters.DurationSetting(settings, "timeout", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	adapter := NewAnthropicAdapter(apiKey, baseURL, timeout)
+	adapter.version = version
+	adapter.maxTokens = maxTokens
+	return adapter, nil
+}
+
+// SetCircuitBreakers enables per-model circuit breaking for requests to Anthropic
+func (aa *AnthropicAdapter) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	aa.breakers = breakers
+}
+
+// ChatCompletion sends a chat completion request to the Messages API
+func (aa *AnthropicAdapter) ChatCompletion(ctx context.Context, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	messagesReq, err := aa.toMessagesRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := aa.send(ctx, messagesReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp MessagesResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &adapters.ChatCompletionResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   resp.Model,
+		Choices: []adapters.Choice{
+			{
+				Index:        0,
+				Message:      toMessage(resp.Content),
+				FinishReason: finishReason(resp.StopReason),
+			},
+		},
+		Usage: toUsage(resp.Usage),
+	}, nil
+}
+
+// ChatCompletionStream sends a streaming chat completion request to the Messages API
+func (aa *AnthropicAdapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	messagesReq, err := aa.toMessagesRequest(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := aa.send(ctx, messagesReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return newMessageStream(httpResp), nil
+}
+
+// Embed is not supported, as Anthropic does not serve embedding models
+func (aa *AnthropicAdapter) Embed(ctx context.Context, req *adapters.EmbeddingRequest) (*adapters.EmbeddingResponse, error) {
+	return nil, fmt.Errorf("Anthropic does not serve embeddings: %w", adapters.ErrNotSupported)
+}
+
+// GetModelInfo gets information about a model
+func (aa *AnthropicAdapter) GetModelInfo(ctx context.Context, modelID string) (*adapters.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", aa.baseURL+"/v1/models/"+modelID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	aa.setHeaders(httpReq)
+
+	httpResp, err := aa.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, parseAPIError(httpResp)
+	}
+
+	var model struct {
+		ID        string    `json:"id"`
+		Type      string    `json:"type"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&model); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &adapters.ModelInfo{
+		ID:      model.ID,
+		Object:  model.Type,
+		Created: model.CreatedAt.Unix(),
+		OwnedBy: "anthropic",
+	}, nil
+}
+
+// ValidateConfig validates the adapter configuration
+func (aa *AnthropicAdapter) ValidateConfig() error {
+	if aa.apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+
+	if aa.baseURL == "" {
+		aa.baseURL = DefaultBaseURL
+	}
+
+	if aa.maxTokens <= 0 {
+		return fmt.Errorf("max tokens must be positive")
+	}
+
+	return nil
+}
+
+// GetCapabilities returns the adapter capabilities
+func (aa *AnthropicAdapter) GetCapabilities() *adapters.AdapterCapabilities {
+	return &adapters.AdapterCapabilities{
+		Streaming:     true,
+		FunctionCalls: true,
+		Embeddings:    false,
+		ModelInfo:     true,
+		RateLimiting:  true,
+	}
+}
+
+// toMessagesRequest converts a chat completion request. System messages are
+// hoisted into the system prompt, tool results are sent as user content and
+// consecutive messages from the same role are merged into one message with
+// several content blocks. JSON output is requested in the system prompt, as
+// the Messages API has no JSON mode.
+func (aa *AnthropicAdapter) toMessagesRequest(req *adapters.ChatCompletionRequest, stream bool) (*MessagesRequest, error) {
+	var system []string
+	var messages []Message
+
+	for _, message := range req.Messages {
+		if message.Role == "system" {
+			system = append(system, message.Text())
+			continue
+		}
+
+		blocks, err := toContentBlocks(&message)
+		if err != nil {
+			return nil, err
+		}
+
+		role := message.Role
+		if role == "tool" {
+			role = "user"
+		}
+
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, Message{Role: role, Content: blocks})
+	}
+
+	if instruction := req.ResponseFormat.Instruction(); instruction != "" {
+		system = append(system, instruction)
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = aa.maxTokens
+	}
+
+	messagesReq := &MessagesRequest{
+		Model:       req.Model,
+		System:      strings.Join(system, "\n\n"),
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: req.Temperature,
+		Stream:      stream,
+		ToolChoice:  toToolChoice(req.ToolChoice),
+	}
+
+	for _, tool := range req.Tools {
+		schema := tool.Function.Parameters
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object"}`)
+		}
+		messagesReq.Tools = append(messagesReq.Tools, Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	return messagesReq, nil
+}
+
+// toContentBlocks converts a message's content and tool calls
+func toContentBlocks(message *adapters.Message) ([]ContentBlock, error) {
+	if message.Role == "tool" {
+		return []ContentBlock{{Type: "tool_result", ToolUseID: message.ToolCallID, Content: message.Text()}}, nil
+	}
+
+	var blocks []ContentBlock
+	if message.Parts == nil {
+		if message.Content != "" || len(message.ToolCalls) == 0 {
+			blocks = append(blocks, ContentBlock{Type: "text", Text: message.Content})
+		}
+	}
+
+	for _, part := range message.Parts {
+		switch part.Type {
+		case "text":
+			blocks = append(blocks, ContentBlock{Type: "text", Text: part.Text})
+		case "image_url":
+			source := &ImageSource{Type: "url", URL: part.ImageURL.URL}
+			if mediaType, data, ok := adapters.ParseDataURL(part.ImageURL.URL); ok {
+				source = &ImageSource{Type: "base64", MediaType: mediaType, Data: data}
+			}
+			blocks = append(blocks, ContentBlock{Type: "image", Source: source})
+		default:
+			return nil, fmt.Errorf("Anthropic does not support %s content", part.Type)
+		}
+	}
+
+	for _, call := range message.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if strings.TrimSpace(call.Function.Arguments) == "" {
+			input = json.RawMessage("{}")
+		}
+		if !json.Valid(input) {
+			return nil, fmt.Errorf("tool call %s has invalid JSON arguments", call.ID)
+		}
+		blocks = append(blocks, ContentBlock{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: input})
+	}
+
+	return blocks, nil
+}
+
+// toToolChoice converts a tool choice; required becomes any
+func toToolChoice(choice *adapters.ToolChoice) *ToolChoice {
+	switch {
+	case choice == nil:
+		return nil
+	case choice.Function != "":
+		return &ToolChoice{Type: "tool", Name: choice.Function}
+	case choice.Mode == "required":
+		return &ToolChoice{Type: "any"}
+	case choice.Mode == "none":
+		return &ToolChoice{Type: "none"}
+	default:
+		return &ToolChoice{Type: "auto"}
+	}
+}
+
+// toMessage converts response content blocks into an assistant message
+func toMessage(blocks []ContentBlock) adapters.Message {
+	message := adapters.Message{Role: "assistant", Content: joinText(blocks)}
+	for _, block := range blocks {
+		if block.Type == "tool_use" {
+			message.ToolCalls = append(message.ToolCalls, adapters.ToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: adapters.ToolCallFunction{Name: block.Name, Arguments: string(block.Input)},
+			})
+		}
+	}
+	return message
+}
+
+// send posts a Messages API request and returns the response if it succeeded
+func (aa *AnthropicAdapter) send(ctx context.Context, req *MessagesRequest) (*http.Response, error) {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", aa.baseURL+"/v1/messages", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	aa.setHeaders(httpReq)
+
+	httpResp, err := aa.breakers.Do(aa.httpClient, httpReq, req.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		return nil, parseAPIError(httpResp)
+	}
+
+	return httpResp, nil
+}
+
+// setHeaders sets the authentication and version headers
+func (aa *AnthropicAdapter) setHeaders(req *http.Request) {
+	req.Header.Set("x-api-key", aa.apiKey)
+	req.Header.Set("anthropic-version", aa.version)
+}
+
+// parseAPIError reads an error response into an *adapters.ProviderError.
+// Anthropic reports overload as status 529, which counts as a server error.
+func parseAPIError(resp *http.Response) error {
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, &body); err != nil || body.Error.Message == "" {
+		body.Error = APIError{Type: "api_error", Message: strings.TrimSpace(string(data))}
+	}
+	body.Error.StatusCode = resp.StatusCode
+
+	providerErr := adapters.NewProviderError("anthropic", resp, data, &body.Error)
+	providerErr.Code, providerErr.Message = body.Error.Type, body.Error.Message
+	return providerErr
+}
+
+// joinText concatenates the text content blocks
+func joinText(blocks []ContentBlock) string {
+	var builder strings.Builder
+	for _, block := range blocks {
+		if block.Type == "text" {
+			builder.WriteString(block.Text)
+		}
+	}
+	return builder.String()
+}
+
+// finishReason maps an Anthropic stop reason to an OpenAI finish reason
+func finishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	default:
+		return stopReason
+	}
+}
+
+// toUsage converts Anthropic usage to adapter usage
+func toUsage(usage Usage) adapters.Usage {
+	return adapters.Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.InputTokens + usage.OutputTokens,
+	}
+}
@@ -0,0 +1,188 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/sse"
+)
+
+// MessageStream converts Messages API stream events into chat completion chunks
+type MessageStream struct {
+	response *http.Response
+	reader   *sse.Reader
+	id       string
+	model    string
+	created  int64
+	usage    Usage
+	tools    map[int]int
+	done     bool
+}
+
+// streamEvent is a Messages API stream event
+type streamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage Usage  `json:"usage"`
+	} `json:"message"`
+	Index        int          `json:"index"`
+	ContentBlock ContentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *Usage    `json:"usage"`
+	Error *APIError `json:"error"`
+}
+
+// newMessageStream creates a stream over a Messages API response
+func newMessageStream(resp *http.Response) *MessageStream {
+	return &MessageStream{
+		response: resp,
+		reader:   sse.NewReader(resp.Body),
+		created:  time.Now().Unix(),
+		tools:    make(map[int]int),
+	}
+}
+
+// Recv receives the next chunk. It returns io.EOF after message_stop and an
+// *adapters.ProviderError wrapping an *APIError if Anthropic reports an
+// error mid-stream.
+func (s *MessageStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	for {
+		event, err := s.reader.ReadEvent()
+		if err != nil {
+			s.done = true
+			return nil, err
+		}
+		if event.Data == "" {
+			continue
+		}
+
+		var data streamEvent
+		if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+			s.done = true
+			return nil, fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		switch data.Type {
+		case "message_start":
+			s.id = data.Message.ID
+			s.model = data.Message.Model
+			s.usage = data.Message.Usage
+			return s.chunk(adapters.StreamChoice{Delta: adapters.Message{Role: "assistant"}}), nil
+
+		case "content_block_start":
+			if data.ContentBlock.Type != "tool_use" {
+				continue
+			}
+			// Tool calls are numbered in order, whatever their block index
+			index := len(s.tools)
+			s.tools[data.Index] = index
+			return s.chunk(adapters.StreamChoice{Delta: adapters.Message{ToolCalls: []adapters.ToolCall{{
+				Index:    &index,
+				ID:       data.ContentBlock.ID,
+				Type:     "function",
+				Function: adapters.ToolCallFunction{Name: data.ContentBlock.Name},
+			}}}}), nil
+
+		case "content_block_delta":
+			switch data.Delta.Type {
+			case "text_delta":
+				return s.chunk(adapters.StreamChoice{Delta: adapters.Message{Content: data.Delta.Text}}), nil
+			case "input_json_delta":
+				index, ok := s.tools[data.Index]
+				if !ok || data.Delta.PartialJSON == "" {
+					continue
+				}
+				return s.chunk(adapters.StreamChoice{Delta: adapters.Message{ToolCalls: []adapters.ToolCall{{
+					Index:    &index,
+					Function: adapters.ToolCallFunction{Arguments: data.Delta.PartialJSON},
+				}}}}), nil
+			}
+			continue
+
+		case "message_delta":
+			if data.Usage != nil {
+				s.usage.OutputTokens = data.Usage.OutputTokens
+			}
+			chunk := s.chunk(adapters.StreamChoice{FinishReason: finishReason(data.Delta.StopReason)})
+			usage := toUsage(s.usage)
+			chunk.Usage = &usage
+			return chunk, nil
+
+		case "message_stop":
+			s.done = true
+			return nil, io.EOF
+
+		case "error":
+			s.done = true
+			if data.Error == nil {
+				data.Error = &APIError{Type: "api_error", Message: event.Data}
+			}
+			return nil, streamError(data.Error, []byte(event.Data))
+		}
+
+		// ping and content_block_stop carry no chunk
+	}
+}
+
+// Close closes the stream
+func (s *MessageStream) Close() error {
+	if s.response != nil && s.response.Body != nil {
+		return s.response.Body.Close()
+	}
+	return nil
+}
+
+// chunk builds a stream chunk with the message metadata
+func (s *MessageStream) chunk(choice adapters.StreamChoice) *adapters.ChatCompletionStreamResponse {
+	return &adapters.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []adapters.StreamChoice{choice},
+	}
+}
+
+// streamError wraps an error event received mid-stream in an
+// *adapters.ProviderError, classified by its error type since the response
+// status was already 200
+func streamError(apiErr *APIError, payload []byte) *adapters.ProviderError {
+	return &adapters.ProviderError{
+		Provider: "anthropic",
+		Kind:     errorKind(apiErr.Type),
+		Code:     apiErr.Type,
+		Message:  apiErr.Message,
+		Body:     payload,
+		Err:      apiErr,
+	}
+}
+
+// errorKind classifies an Anthropic error type. api_error and
+// overloaded_error count as server errors.
+func errorKind(errorType string) error {
+	switch errorType {
+	case "rate_limit_error":
+		return adapters.ErrRateLimited
+	case "authentication_error", "permission_error":
+		return adapters.ErrAuthentication
+	case "invalid_request_error", "not_found_error", "request_too_large":
+		return adapters.ErrInvalidRequest
+	default:
+		return adapters.ErrServerError
+	}
+}
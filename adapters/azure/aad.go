@@ -0,0 +1,115 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+)
+
+// CognitiveServicesScope is the AAD scope of Azure OpenAI data plane tokens
+const CognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+
+// tokenRefreshMargin is how long before expiry a cached token is replaced
+const tokenRefreshMargin = 5 * time.Minute
+
+// CredentialTokenSource is a TokenSource backed by an azidentity credential.
+// Tokens are cached and refreshed before they expire, so long-running
+// gateways keep authenticating after the first token's hour is up.
+type CredentialTokenSource struct {
+	credential azcore.TokenCredential
+	scope      string
+	token      azcore.AccessToken
+	mutex      sync.Mutex
+}
+
+// NewCredentialTokenSource creates a token source for credential and scope,
+// usually CognitiveServicesScope
+func NewCredentialTokenSource(credential azcore.TokenCredential, scope string) *CredentialTokenSource {
+	return &CredentialTokenSource{credential: credential, scope: scope}
+}
+
+// Token returns the cached token, fetching a new one if it expires within
+// the refresh margin or the credential suggested refreshing it
+func (s *CredentialTokenSource) Token(ctx context.Context) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	fresh := s.token.Token != "" && now.Add(tokenRefreshMargin).Before(s.token.ExpiresOn) &&
+		(s.token.RefreshOn.IsZero() || now.Before(s.token.RefreshOn))
+	if fresh {
+		return s.token.Token, nil
+	}
+
+	token, err := s.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{s.scope}})
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	return token.Token, nil
+}
+
+// tokenSourceFromSettings returns the token source selected by the
+// aadCredential setting: default for the DefaultAzureCredential chain,
+// managedIdentity with an optional aadClientId, or clientSecret with
+// aadTenantId, aadClientId and aadClientSecret or aadClientSecretEnv.
+// aadScope overrides the token scope. A static aadToken is used if no
+// credential is set, and nil is returned if neither is.
+func tokenSourceFromSettings(settings map[string]interface{}) (TokenSource, error) {
+	kind, err := adapters.StringSetting(settings, "aadCredential", "")
+	if err != nil {
+		return nil, err
+	}
+	if kind == "" {
+		aadToken, err := adapters.SecretSetting(settings, "aadToken")
+		if err != nil || aadToken == "" {
+			return nil, err
+		}
+		return StaticToken(aadToken), nil
+	}
+
+	scope, err := adapters.StringSetting(settings, "aadScope", CognitiveServicesScope)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := adapters.StringSetting(settings, "aadTenantId", "")
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := adapters.StringSetting(settings, "aadClientId", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var credential azcore.TokenCredential
+	switch kind {
+	case "default":
+		credential, err = azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{TenantID: tenantID})
+	case "managedIdentity":
+		options := &azidentity.ManagedIdentityCredentialOptions{}
+		if clientID != "" {
+			options.ID = azidentity.ClientID(clientID)
+		}
+		credential, err = azidentity.NewManagedIdentityCredential(options)
+	case "clientSecret":
+		var clientSecret string
+		clientSecret, err = adapters.SecretSetting(settings, "aadClientSecret")
+		if err != nil {
+			return nil, err
+		}
+		credential, err = azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+	default:
+		return nil, fmt.Errorf("unknown aadCredential %q: want default, managedIdentity or clientSecret", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s AAD credential: %w", kind, err)
+	}
+	return NewCredentialTokenSource(credential, scope), nil
+}
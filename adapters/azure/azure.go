@@ -0,0 +1,425 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// DefaultAPIVersion is the Azure OpenAI data plane API version
+const DefaultAPIVersion = "2024-06-01"
+
+// TokenSource supplies Azure Active Directory bearer tokens
+type TokenSource interface {
+	// Token returns a valid access token
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token. AAD
+// tokens expire after about an hour, so long-running gateways should use a
+// CredentialTokenSource instead.
+type StaticToken string
+
+// Token returns the token
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// DetectionHandler receives content filter detections for responses that
+// were annotated but not blocked
+type DetectionHandler = adapters.DetectionHandler
+
+// AzureAdapter implements the LLMAdapter interface for Azure OpenAI. Requests
+// are sent to the deployment mapped to the requested model, or to a
+// deployment named after the model if there is no mapping.
+type AzureAdapter struct {
+	endpoint    string
+	apiVersion  string
+	apiKey      string
+	tokens      TokenSource
+	deployments map[string]string
+	httpClient  *http.Client
+	breakers    *circuitbreaker.Manager
+	onDetection DetectionHandler
+}
+
+// Ensure AzureAdapter implements adapters.LLMAdapter
+var _ adapters.LLMAdapter = (*AzureAdapter)(nil)
+
+// APIError is an error reported by the Azure OpenAI API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("Azure OpenAI API returned status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("Azure OpenAI API error: %s: %s", e.Code, e.Message)
+}
+
+// errorBody is an Azure OpenAI error response
+type errorBody struct {
+	Error struct {
+		APIError
+		InnerError struct {
+			Code                string               `json:"code"`
+			ContentFilterResult ContentFilterResults `json:"content_filter_result"`
+		} `json:"innererror"`
+	} `json:"error"`
+}
+
+// promptFilterResult is the content filter verdict for one prompt
+type promptFilterResult struct {
+	PromptIndex          int                  `json:"prompt_index"`
+	ContentFilterResults ContentFilterResults `json:"content_filter_results"`
+}
+
+// chatCompletionResponse is an Azure chat completion response
+type chatCompletionResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index                int                  `json:"index"`
+		Message              adapters.Message     `json:"message"`
+		FinishReason         string               `json:"finish_reason"`
+		ContentFilterResults ContentFilterResults `json:"content_filter_results"`
+	} `json:"choices"`
+	Usage               adapters.Usage       `json:"usage"`
+	PromptFilterResults []promptFilterResult `json:"prompt_filter_results"`
+}
+
+// NewAzureAdapter creates a new Azure OpenAI adapter. deployments maps model
+// names to deployment names.
+func NewAzureAdapter(endpoint, apiVersion, apiKey string, deployments map[string]string, timeout time.Duration) *AzureAdapter {
+	if apiVersion == "" {
+		apiVersion = DefaultAPIVersion
+	}
+
+	normalized := make(map[string]string, len(deployments))
+	for model, deployment := range deployments {
+		normalized[strings.ToLower(model)] = deployment
+	}
+
+	return &AzureAdapter{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		apiVersion:  apiVersion,
+		apiKey:      apiKey,
+		deployments: normalized,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// NewAdapterFromSettings creates an Azure OpenAI adapter from registry
+// settings: endpoint, apiVersion, apiKey or apiKeyEnv, aadCredential and
+// its options or a static aadToken or aadTokenEnv, deployments and timeout
+func NewAdapterFromSettings(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+	endpoint, err := adapters.StringSetting(settings, "endpoint", "")
+	if err != nil {
+		return nil, err
+	}
+
+	apiVersion, err := adapters.StringSetting(settings, "apiVersion", DefaultAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := adapters.SecretSetting(settings, "apiKey")
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := tokenSourceFromSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	deployments, err := adapters.StringMapSetting(settings, "deployments")
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := adapters.DurationSetting(settings, "timeout", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	adapter := NewAzureAdapter(endpoint, apiVersion, apiKey, deployments, timeout)
+	if tokens != nil {
+		adapter.SetTokenSource(tokens)
+	}
+	return adapter, nil
+}
+
+// SetTokenSource authenticates with Azure Active Directory tokens instead of an API key
+func (aa *AzureAdapter) SetTokenSource(tokens TokenSource) {
+	aa.tokens = tokens
+}
+
+// SetDetectionHandler sets the handler for content filter annotations on
+// responses that were not blocked
+func (aa *AzureAdapter) SetDetectionHandler(handler DetectionHandler) {
+	aa.onDetection = handler
+}
+
+// SetCircuitBreakers enables per-deployment circuit breaking
+func (aa *AzureAdapter) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	aa.breakers = breakers
+}
+
+// Deployment returns the deployment that serves a model
+func (aa *AzureAdapter) Deployment(model string) string {
+	if deployment, ok := aa.deployments[strings.ToLower(model)]; ok {
+		return deployment
+	}
+	return model
+}
+
+// ChatCompletion sends a chat completion request to the model's deployment
+func (aa *AzureAdapter) ChatCompletion(ctx context.Context, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	completionReq := *req
+	completionReq.Stream = false
+
+	httpResp, err := aa.send(ctx, req.Model, "chat/completions", &completionReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp chatCompletionResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var detections []detector.DetectionResult
+	for _, result := range resp.PromptFilterResults {
+		detections = append(detections, result.ContentFilterResults.Detections()...)
+	}
+
+	choices := make([]adapters.Choice, len(resp.Choices))
+	filtered := false
+	for i, choice := range resp.Choices {
+		detections = append(detections, choice.ContentFilterResults.Detections()...)
+		if choice.FinishReason == "content_filter" {
+			filtered = true
+		}
+
+		choices[i] = adapters.Choice{
+			Index:        choice.Index,
+			Message:      choice.Message,
+			FinishReason: choice.FinishReason,
+		}
+	}
+
+	if filtered {
+		return nil, &ContentFilterError{Source: "completion", Detections: detections}
+	}
+	aa.reportDetections(ctx, req.Model, detections)
+
+	return &adapters.ChatCompletionResponse{
+		ID:      resp.ID,
+		Object:  resp.Object,
+		Created: resp.Created,
+		Model:   resp.Model,
+		Choices: choices,
+		Usage:   resp.Usage,
+	}, nil
+}
+
+// ChatCompletionStream sends a streaming chat completion request to the model's deployment
+func (aa *AzureAdapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	streamReq := *req
+	streamReq.Stream = true
+
+	httpResp, err := aa.send(ctx, req.Model, "chat/completions", &streamReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return newChatCompletionStream(ctx, aa, req.Model, httpResp), nil
+}
+
+// Embed sends an embeddings request to the model's deployment
+func (aa *AzureAdapter) Embed(ctx context.Context, req *adapters.EmbeddingRequest) (*adapters.EmbeddingResponse, error) {
+	// Embeddings are decoded as floats, so base64 encoding is never requested
+	embedReq := *req
+	embedReq.EncodingFormat = ""
+
+	httpResp, err := aa.send(ctx, req.Model, "embeddings", &embedReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp adapters.EmbeddingResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+// GetModelInfo gets information about a model
+func (aa *AzureAdapter) GetModelInfo(ctx context.Context, modelID string) (*adapters.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", aa.url("/openai/models/"+url.PathEscape(modelID)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if err := aa.authenticate(ctx, httpReq); err != nil {
+		return nil, err
+	}
+
+	httpResp, err := aa.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, parseError(httpResp)
+	}
+
+	var model struct {
+		ID        string `json:"id"`
+		Object    string `json:"object"`
+		CreatedAt int64  `json:"created_at"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&model); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &adapters.ModelInfo{
+		ID:      model.ID,
+		Object:  model.Object,
+		Created: model.CreatedAt,
+		OwnedBy: "azure",
+	}, nil
+}
+
+// ValidateConfig validates the adapter configuration
+func (aa *AzureAdapter) ValidateConfig() error {
+	if aa.endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+
+	if aa.apiKey == "" && aa.tokens == nil {
+		return fmt.Errorf("API key or AAD token source is required")
+	}
+
+	return nil
+}
+
+// GetCapabilities returns the adapter capabilities
+func (aa *AzureAdapter) GetCapabilities() *adapters.AdapterCapabilities {
+	return &adapters.AdapterCapabilities{
+		Streaming:     true,
+		FunctionCalls: true,
+		Embeddings:    true,
+		ModelInfo:     true,
+		RateLimiting:  true,
+	}
+}
+
+// send posts a request to an operation of the model's deployment, such as
+// chat/completions, and returns the response if it succeeded
+func (aa *AzureAdapter) send(ctx context.Context, model, operation string, req interface{}) (*http.Response, error) {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	deployment := aa.Deployment(model)
+	endpoint := aa.url("/openai/deployments/" + url.PathEscape(deployment) + "/" + operation)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if err := aa.authenticate(ctx, httpReq); err != nil {
+		return nil, err
+	}
+
+	httpResp, err := aa.breakers.Do(aa.httpClient, httpReq, deployment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		return nil, parseError(httpResp)
+	}
+
+	return httpResp, nil
+}
+
+// url builds a data plane URL with the API version
+func (aa *AzureAdapter) url(path string) string {
+	return aa.endpoint + path + "?api-version=" + url.QueryEscape(aa.apiVersion)
+}
+
+// authenticate sets the AAD bearer token, or the api-key header if no token source is set
+func (aa *AzureAdapter) authenticate(ctx context.Context, req *http.Request) error {
+	if aa.tokens == nil {
+		req.Header.Set("api-key", aa.apiKey)
+		return nil
+	}
+
+	token, err := aa.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get AAD token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// reportDetections passes non-blocking detections to the handler
+func (aa *AzureAdapter) reportDetections(ctx context.Context, model string, detections []detector.DetectionResult) {
+	if aa.onDetection != nil && len(detections) > 0 {
+		aa.onDetection(ctx, model, detections)
+	}
+}
+
+// parseError reads an error response into an *adapters.ProviderError.
+// Prompts rejected by the content filter wrap a *ContentFilterError, and
+// other errors wrap an *APIError.
+func parseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var body errorBody
+	if err := json.Unmarshal(data, &body); err != nil || body.Error.Message == "" {
+		body.Error.Code, body.Error.Message = "unknown", strings.TrimSpace(string(data))
+	}
+
+	apiErr := body.Error.APIError
+	apiErr.StatusCode = resp.StatusCode
+
+	var err error = &apiErr
+	if apiErr.Code == "content_filter" {
+		err = &ContentFilterError{
+			Source:     "prompt",
+			Message:    apiErr.Message,
+			Detections: body.Error.InnerError.ContentFilterResult.Detections(),
+		}
+	}
+
+	providerErr := adapters.NewProviderError("azure", resp, data, err)
+	providerErr.Code, providerErr.Message = apiErr.Code, apiErr.Message
+	return providerErr
+}
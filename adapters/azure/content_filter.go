@@ -0,0 +1,151 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// ContentFilterResult is Azure's verdict for one content filter category
+type ContentFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"`
+	Detected *bool  `json:"detected,omitempty"`
+}
+
+// ContentFilterResults maps content filter categories (hate, sexual,
+// violence, self_harm, jailbreak, ...) to their results
+type ContentFilterResults map[string]ContentFilterResult
+
+// UnmarshalJSON decodes content filter results, skipping entries such as
+// custom_blocklists that do not have the per-category shape
+func (r *ContentFilterResults) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	results := make(ContentFilterResults, len(raw))
+	for category, value := range raw {
+		var result ContentFilterResult
+		if err := json.Unmarshal(value, &result); err == nil {
+			results[category] = result
+		}
+	}
+	*r = results
+	return nil
+}
+
+// Filtered reports whether any category blocked the content
+func (r ContentFilterResults) Filtered() bool {
+	for _, result := range r {
+		if result.Filtered {
+			return true
+		}
+	}
+	return false
+}
+
+// Detections converts the results into Sentinel detections, one per
+// category that was filtered, detected or rated above safe
+func (r ContentFilterResults) Detections() []detector.DetectionResult {
+	categories := make([]string, 0, len(r))
+	for category := range r {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	var detections []detector.DetectionResult
+	for _, category := range categories {
+		result := r[category]
+		score := severityScore(result)
+		if score == 0 && !result.Filtered {
+			continue
+		}
+
+		filtered := 0.0
+		recommendation := "Allow the request"
+		if result.Filtered {
+			filtered = 1
+			recommendation = "Block the request"
+		}
+
+		detections = append(detections, detector.DetectionResult{
+			Score:          score,
+			Confidence:     1.0,
+			ViolationType:  "content_filter_" + category,
+			Details:        map[string]float64{"severity": score, "filtered": filtered},
+			Recommendation: recommendation,
+		})
+	}
+	return detections
+}
+
+// severityScore maps an Azure severity or detection flag to a score in [0, 1]
+func severityScore(result ContentFilterResult) float64 {
+	if result.Detected != nil {
+		if *result.Detected {
+			return 1
+		}
+		return 0
+	}
+
+	switch result.Severity {
+	case "low":
+		return 0.33
+	case "medium":
+		return 0.66
+	case "high":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ContentFilterError is returned when Azure's content filter blocks a prompt
+// or a completion. Detections describes the categories that triggered.
+type ContentFilterError struct {
+	// Source is "prompt" or "completion"
+	Source     string
+	Message    string
+	Detections []detector.DetectionResult
+}
+
+// Error implements the error interface
+func (e *ContentFilterError) Error() string {
+	types := make([]string, len(e.Detections))
+	for i, detection := range e.Detections {
+		types[i] = detection.ViolationType
+	}
+
+	message := fmt.Sprintf("Azure content filter blocked the %s", e.Source)
+	if len(types) > 0 {
+		message += " (" + strings.Join(types, ", ") + ")"
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+// ViolationType returns the violation type of the first blocking detection
+func (e *ContentFilterError) ViolationType() string {
+	for _, detection := range e.Detections {
+		if detection.Details["filtered"] == 1 {
+			return detection.ViolationType
+		}
+	}
+	if len(e.Detections) > 0 {
+		return e.Detections[0].ViolationType
+	}
+	return "content_filter"
+}
+
+// Is reports whether target is adapters.ErrContentFiltered
+func (e *ContentFilterError) Is(target error) bool {
+	return target == adapters.ErrContentFiltered
+}
@@ -0,0 +1,134 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/sse"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// ChatCompletionStream reads an Azure OpenAI chat completion stream
+type ChatCompletionStream struct {
+	ctx        context.Context
+	adapter    *AzureAdapter
+	model      string
+	response   *http.Response
+	reader     *sse.Reader
+	detections []detector.DetectionResult
+	done       bool
+}
+
+// streamChunk is an Azure stream chunk, which may carry only filter results
+type streamChunk struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index                int                  `json:"index"`
+		Delta                adapters.Message     `json:"delta"`
+		FinishReason         string               `json:"finish_reason"`
+		ContentFilterResults ContentFilterResults `json:"content_filter_results"`
+	} `json:"choices"`
+	PromptFilterResults []promptFilterResult `json:"prompt_filter_results"`
+	Error               *APIError            `json:"error"`
+}
+
+// newChatCompletionStream creates a stream over an Azure response
+func newChatCompletionStream(ctx context.Context, adapter *AzureAdapter, model string, resp *http.Response) *ChatCompletionStream {
+	return &ChatCompletionStream{
+		ctx:      ctx,
+		adapter:  adapter,
+		model:    model,
+		response: resp,
+		reader:   sse.NewReader(resp.Body),
+	}
+}
+
+// Recv receives the next chunk. It returns io.EOF once the stream is
+// finished and a *ContentFilterError if the completion is filtered.
+func (s *ChatCompletionStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	for {
+		event, err := s.reader.ReadEvent()
+		if err == io.EOF {
+			return nil, s.finish()
+		}
+		if err != nil {
+			s.done = true
+			return nil, err
+		}
+
+		if event.Data == "" {
+			continue
+		}
+		if event.Data == "[DONE]" {
+			return nil, s.finish()
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			s.done = true
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			s.done = true
+			return nil, chunk.Error
+		}
+
+		for _, result := range chunk.PromptFilterResults {
+			s.detections = append(s.detections, result.ContentFilterResults.Detections()...)
+		}
+
+		choices := make([]adapters.StreamChoice, 0, len(chunk.Choices))
+		for _, choice := range chunk.Choices {
+			s.detections = append(s.detections, choice.ContentFilterResults.Detections()...)
+			if choice.FinishReason == "content_filter" {
+				s.done = true
+				return nil, &ContentFilterError{Source: "completion", Detections: s.detections}
+			}
+
+			choices = append(choices, adapters.StreamChoice{
+				Index:        choice.Index,
+				Delta:        choice.Delta,
+				FinishReason: choice.FinishReason,
+			})
+		}
+
+		// Chunks carrying only filter results are not passed on
+		if len(choices) == 0 {
+			continue
+		}
+
+		return &adapters.ChatCompletionStreamResponse{
+			ID:      chunk.ID,
+			Object:  chunk.Object,
+			Created: chunk.Created,
+			Model:   chunk.Model,
+			Choices: choices,
+		}, nil
+	}
+}
+
+// Close closes the stream
+func (s *ChatCompletionStream) Close() error {
+	if s.response != nil && s.response.Body != nil {
+		return s.response.Body.Close()
+	}
+	return nil
+}
+
+// finish reports the collected detections and ends the stream
+func (s *ChatCompletionStream) finish() error {
+	s.done = true
+	s.adapter.reportDetections(s.ctx, s.model, s.detections)
+	return io.EOF
+}
@@ -0,0 +1,728 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+)
+
+// SigningName is the SigV4 service name for the Bedrock runtime
+const SigningName = "bedrock"
+
+// BedrockAdapter implements the LLMAdapter interface for the Bedrock
+// Converse and ConverseStream APIs
+type BedrockAdapter struct {
+	region      string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	models      map[string]string
+	httpClient  *http.Client
+	breakers    *circuitbreaker.Manager
+}
+
+// Ensure BedrockAdapter implements adapters.LLMAdapter
+var _ adapters.LLMAdapter = (*BedrockAdapter)(nil)
+
+// ConverseRequest is a Converse API request
+type ConverseRequest struct {
+	Messages        []Message        `json:"messages"`
+	System          []ContentBlock   `json:"system,omitempty"`
+	InferenceConfig *InferenceConfig `json:"inferenceConfig,omitempty"`
+	ToolConfig      *ToolConfig      `json:"toolConfig,omitempty"`
+}
+
+// Message is a Converse API message
+type Message struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ContentBlock is a block of message content. Exactly one field is set.
+type ContentBlock struct {
+	Text       string      `json:"text,omitempty"`
+	Image      *Image      `json:"image,omitempty"`
+	ToolUse    *ToolUse    `json:"toolUse,omitempty"`
+	ToolResult *ToolResult `json:"toolResult,omitempty"`
+}
+
+// Image is an inline image; Bytes is base64 encoded
+type Image struct {
+	Format string `json:"format"`
+	Source struct {
+		Bytes string `json:"bytes"`
+	} `json:"source"`
+}
+
+// ToolUse is a tool call made by the model
+type ToolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+// ToolResult is the result of a tool call
+type ToolResult struct {
+	ToolUseID string         `json:"toolUseId"`
+	Content   []ContentBlock `json:"content"`
+}
+
+// ToolConfig holds the tools a model may call
+type ToolConfig struct {
+	Tools      []Tool                 `json:"tools"`
+	ToolChoice map[string]interface{} `json:"toolChoice,omitempty"`
+}
+
+// Tool is a tool definition
+type Tool struct {
+	ToolSpec struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		InputSchema struct {
+			JSON json.RawMessage `json:"json"`
+		} `json:"inputSchema"`
+	} `json:"toolSpec"`
+}
+
+// InferenceConfig holds the inference parameters shared by every model family
+type InferenceConfig struct {
+	MaxTokens   int      `json:"maxTokens,omitempty"`
+	Temperature *float32 `json:"temperature,omitempty"`
+}
+
+// ConverseResponse is a Converse API response
+type ConverseResponse struct {
+	Output struct {
+		Message Message `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      Usage  `json:"usage"`
+}
+
+// Usage is Converse API token usage
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+// APIError is an error reported by Bedrock
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"-"`
+	Message    string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("Bedrock API returned status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("Bedrock API error: %s: %s", e.Type, e.Message)
+}
+
+// StaticCredentials returns a provider for fixed AWS credentials
+func StaticCredentials(accessKeyID, secretAccessKey, sessionToken string) aws.CredentialsProvider {
+	return aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+			Source:          "StaticCredentials",
+		}, nil
+	})
+}
+
+// NewBedrockAdapter creates a new Bedrock adapter. An empty endpoint uses
+// the regional Bedrock runtime endpoint. models maps request model names to
+// Bedrock model IDs; unmapped names are used as model IDs.
+func NewBedrockAdapter(region, endpoint string, credentials aws.CredentialsProvider, models map[string]string, timeout time.Duration) *BedrockAdapter {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region)
+	}
+
+	return &BedrockAdapter{
+		region:      region,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+		models:      models,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// NewAdapterFromSettings creates a Bedrock adapter from registry settings:
+// region, endpoint, accessKeyId(Env), secretAccessKey(Env), sessionToken(Env),
+// models and timeout. Without an access key the default AWS credential chain
+// and region are used, as for AWSKMSClient.
+func NewAdapterFromSettings(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+	region, err := adapters.StringSetting(settings, "region", "")
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := adapters.StringSetting(settings, "endpoint", "")
+	if err != nil {
+		return nil, err
+	}
+
+	accessKeyID, err := adapters.SecretSetting(settings, "accessKeyId")
+	if err != nil {
+		return nil, err
+	}
+
+	secretAccessKey, err := adapters.SecretSetting(settings, "secretAccessKey")
+	if err != nil {
+		return nil, err
+	}
+
+	sessionToken, err := adapters.SecretSetting(settings, "sessionToken")
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := adapters.StringMapSetting(settings, "models")
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := adapters.DurationSetting(settings, "timeout", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials aws.CredentialsProvider
+	if accessKeyID != "" {
+		credentials = StaticCredentials(accessKeyID, secretAccessKey, sessionToken)
+	} else {
+		cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		credentials = cfg.Credentials
+		region = cfg.Region
+	}
+
+	return NewBedrockAdapter(region, endpoint, credentials, models, timeout), nil
+}
+
+// SetCircuitBreakers enables per-model circuit breaking for requests to Bedrock
+func (ba *BedrockAdapter) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	ba.breakers = breakers
+}
+
+// ChatCompletion sends a chat completion request to the Converse API
+func (ba *BedrockAdapter) ChatCompletion(ctx context.Context, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	modelID := ba.modelID(req.Model)
+
+	converseReq, err := toConverseRequest(modelID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := ba.send(ctx, modelID, "converse", converseReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp ConverseResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &adapters.ChatCompletionResponse{
+		ID:      responseID(httpResp),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+		Choices: []adapters.Choice{
+			{
+				Index:        0,
+				Message:      toMessage(resp.Output.Message.Content),
+				FinishReason: finishReason(resp.StopReason),
+			},
+		},
+		Usage: toUsage(resp.Usage),
+	}, nil
+}
+
+// ChatCompletionStream sends a streaming chat completion request to the ConverseStream API
+func (ba *BedrockAdapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	modelID := ba.modelID(req.Model)
+
+	converseReq, err := toConverseRequest(modelID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := ba.send(ctx, modelID, "converse-stream", converseReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConverseStream(httpResp, req.Model), nil
+}
+
+// Embed creates embeddings with the InvokeModel API. Amazon Titan embeds
+// one input per call; Cohere embeds them all at once. Cohere inputs are
+// embedded as search_document unless the request sets input_type.
+func (ba *BedrockAdapter) Embed(ctx context.Context, req *adapters.EmbeddingRequest) (*adapters.EmbeddingResponse, error) {
+	modelID := ba.modelID(req.Model)
+	resp := &adapters.EmbeddingResponse{Object: "list", Model: req.Model}
+
+	switch modelFamily(modelID) {
+	case "amazon":
+		for i, input := range req.Input {
+			var titan struct {
+				Embedding []float64 `json:"embedding"`
+			}
+			titanReq := map[string]interface{}{"inputText": input}
+			if req.Dimensions > 0 {
+				titanReq["dimensions"] = req.Dimensions
+			}
+			tokens, err := ba.invoke(ctx, modelID, titanReq, &titan)
+			if err != nil {
+				return nil, err
+			}
+
+			resp.Data = append(resp.Data, adapters.Embedding{Object: "embedding", Index: i, Embedding: titan.Embedding})
+			resp.Usage.PromptTokens += tokens
+		}
+
+	case "cohere":
+		inputType := json.RawMessage(`"search_document"`)
+		if value, ok := req.Extra["input_type"]; ok {
+			inputType = value
+		}
+
+		var cohere struct {
+			Embeddings [][]float64 `json:"embeddings"`
+		}
+		cohereReq := map[string]interface{}{"texts": req.Input, "input_type": inputType}
+		tokens, err := ba.invoke(ctx, modelID, cohereReq, &cohere)
+		if err != nil {
+			return nil, err
+		}
+		resp.Usage.PromptTokens = tokens
+
+		for i, embedding := range cohere.Embeddings {
+			resp.Data = append(resp.Data, adapters.Embedding{Object: "embedding", Index: i, Embedding: embedding})
+		}
+
+	default:
+		return nil, fmt.Errorf("Bedrock embeddings are only supported for Amazon Titan and Cohere models: %w", adapters.ErrNotSupported)
+	}
+
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	return resp, nil
+}
+
+// GetModelInfo gets information about a model. Bedrock model details live
+// in the control plane API, so only the model family is reported.
+func (ba *BedrockAdapter) GetModelInfo(ctx context.Context, modelID string) (*adapters.ModelInfo, error) {
+	owner := modelFamily(ba.modelID(modelID))
+	if owner == "" {
+		owner = "bedrock"
+	}
+
+	return &adapters.ModelInfo{
+		ID:      modelID,
+		Object:  "model",
+		OwnedBy: owner,
+	}, nil
+}
+
+// ValidateConfig validates the adapter configuration
+func (ba *BedrockAdapter) ValidateConfig() error {
+	if ba.region == "" {
+		return fmt.Errorf("AWS region is required")
+	}
+
+	if ba.credentials == nil {
+		return fmt.Errorf("AWS credentials are required")
+	}
+
+	return nil
+}
+
+// GetCapabilities returns the adapter capabilities
+func (ba *BedrockAdapter) GetCapabilities() *adapters.AdapterCapabilities {
+	return &adapters.AdapterCapabilities{
+		Streaming:     true,
+		FunctionCalls: true,
+		Embeddings:    true,
+		ModelInfo:     true,
+		RateLimiting:  true,
+	}
+}
+
+// modelID returns the Bedrock model ID for a request model name
+func (ba *BedrockAdapter) modelID(model string) string {
+	if id, ok := ba.models[model]; ok {
+		return id
+	}
+	return model
+}
+
+// send signs and posts a request to a model operation and returns the response if it succeeded
+func (ba *BedrockAdapter) send(ctx context.Context, modelID, operation string, req interface{}) (*http.Response, error) {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", ba.endpoint, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Model IDs contain colons, which Bedrock expects percent-encoded
+	httpReq.URL.Path = "/model/" + modelID + "/" + operation
+	httpReq.URL.RawPath = "/model/" + strings.ReplaceAll(url.PathEscape(modelID), ":", "%3A") + "/" + operation
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	if err := ba.sign(ctx, httpReq, requestBody); err != nil {
+		return nil, err
+	}
+
+	httpResp, err := ba.breakers.Do(ba.httpClient, httpReq, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		return nil, parseAPIError(httpResp)
+	}
+
+	return httpResp, nil
+}
+
+// invoke calls InvokeModel and decodes the model's response into v. It
+// returns the input token count Bedrock reports in a response header.
+func (ba *BedrockAdapter) invoke(ctx context.Context, modelID string, req, v interface{}) (int, error) {
+	httpResp, err := ba.send(ctx, modelID, "invoke", req)
+	if err != nil {
+		return 0, err
+	}
+	defer httpResp.Body.Close()
+
+	if err := json.NewDecoder(httpResp.Body).Decode(v); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	tokens, _ := strconv.Atoi(httpResp.Header.Get("X-Amzn-Bedrock-Input-Token-Count"))
+	return tokens, nil
+}
+
+// sign signs a request with SigV4
+func (ba *BedrockAdapter) sign(ctx context.Context, req *http.Request, body []byte) error {
+	credentials, err := ba.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	payloadHash := sha256.Sum256(body)
+	if err := ba.signer.SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]), SigningName, ba.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	return nil
+}
+
+// toConverseRequest converts a chat completion request. System messages
+// become the system prompt, or are folded into the first user message for
+// model families that do not accept one, and consecutive messages from the
+// same role are merged because Converse requires alternating roles. Tool
+// results are sent as user content, and JSON output is requested in the
+// system prompt as Converse has no JSON mode.
+func toConverseRequest(modelID string, req *adapters.ChatCompletionRequest) (*ConverseRequest, error) {
+	var system []ContentBlock
+	var messages []Message
+
+	for _, message := range req.Messages {
+		if message.Role == "system" {
+			system = append(system, ContentBlock{Text: message.Text()})
+			continue
+		}
+
+		blocks, err := toContentBlocks(&message)
+		if err != nil {
+			return nil, err
+		}
+
+		role := message.Role
+		if role == "tool" {
+			role = "user"
+		}
+
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, Message{Role: role, Content: blocks})
+	}
+
+	if instruction := req.ResponseFormat.Instruction(); instruction != "" {
+		system = append(system, ContentBlock{Text: instruction})
+	}
+
+	if len(system) > 0 && !supportsSystemPrompt(modelID) {
+		if len(messages) > 0 && messages[0].Role == "user" {
+			messages[0].Content = append(system, messages[0].Content...)
+		} else {
+			messages = append([]Message{{Role: "user", Content: system}}, messages...)
+		}
+		system = nil
+	}
+
+	converseReq := &ConverseRequest{
+		Messages:   messages,
+		System:     system,
+		ToolConfig: toToolConfig(req),
+	}
+
+	if req.MaxTokens > 0 || req.Temperature != nil {
+		converseReq.InferenceConfig = &InferenceConfig{MaxTokens: req.MaxTokens, Temperature: req.Temperature}
+	}
+
+	return converseReq, nil
+}
+
+// toContentBlocks converts a message's content and tool calls. Converse
+// only accepts inline images, so image URLs must be data URLs.
+func toContentBlocks(message *adapters.Message) ([]ContentBlock, error) {
+	if message.Role == "tool" {
+		return []ContentBlock{{ToolResult: &ToolResult{
+			ToolUseID: message.ToolCallID,
+			Content:   []ContentBlock{{Text: message.Text()}},
+		}}}, nil
+	}
+
+	var blocks []ContentBlock
+	if message.Parts == nil {
+		if message.Content != "" || len(message.ToolCalls) == 0 {
+			blocks = append(blocks, ContentBlock{Text: message.Content})
+		}
+	}
+
+	for _, part := range message.Parts {
+		switch part.Type {
+		case "text":
+			blocks = append(blocks, ContentBlock{Text: part.Text})
+		case "image_url":
+			mediaType, data, ok := adapters.ParseDataURL(part.ImageURL.URL)
+			if !ok {
+				return nil, fmt.Errorf("Bedrock only supports images as base64 data URLs")
+			}
+			image := &Image{Format: strings.TrimPrefix(mediaType, "image/")}
+			image.Source.Bytes = data
+			blocks = append(blocks, ContentBlock{Image: image})
+		default:
+			return nil, fmt.Errorf("Bedrock does not support %s content", part.Type)
+		}
+	}
+
+	for _, call := range message.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if strings.TrimSpace(call.Function.Arguments) == "" {
+			input = json.RawMessage("{}")
+		}
+		if !json.Valid(input) {
+			return nil, fmt.Errorf("tool call %s has invalid JSON arguments", call.ID)
+		}
+		blocks = append(blocks, ContentBlock{ToolUse: &ToolUse{ToolUseID: call.ID, Name: call.Function.Name, Input: input}})
+	}
+
+	return blocks, nil
+}
+
+// toToolConfig converts the request's tools and tool choice. Converse has
+// no way to disable tools, so a tool choice of none sends no tools.
+func toToolConfig(req *adapters.ChatCompletionRequest) *ToolConfig {
+	if len(req.Tools) == 0 || (req.ToolChoice != nil && req.ToolChoice.Mode == "none") {
+		return nil
+	}
+
+	config := &ToolConfig{}
+	for _, tool := range req.Tools {
+		var spec Tool
+		spec.ToolSpec.Name = tool.Function.Name
+		spec.ToolSpec.Description = tool.Function.Description
+		spec.ToolSpec.InputSchema.JSON = tool.Function.Parameters
+		if len(spec.ToolSpec.InputSchema.JSON) == 0 {
+			spec.ToolSpec.InputSchema.JSON = json.RawMessage(`{"type":"object"}`)
+		}
+		config.Tools = append(config.Tools, spec)
+	}
+
+	switch choice := req.ToolChoice; {
+	case choice == nil:
+	case choice.Function != "":
+		config.ToolChoice = map[string]interface{}{"tool": map[string]string{"name": choice.Function}}
+	case choice.Mode == "required":
+		config.ToolChoice = map[string]interface{}{"any": struct{}{}}
+	case choice.Mode == "auto":
+		config.ToolChoice = map[string]interface{}{"auto": struct{}{}}
+	}
+	return config
+}
+
+// toMessage converts response content blocks into an assistant message
+func toMessage(blocks []ContentBlock) adapters.Message {
+	message := adapters.Message{Role: "assistant", Content: joinText(blocks)}
+	for _, block := range blocks {
+		if block.ToolUse != nil {
+			message.ToolCalls = append(message.ToolCalls, adapters.ToolCall{
+				ID:       block.ToolUse.ToolUseID,
+				Type:     "function",
+				Function: adapters.ToolCallFunction{Name: block.ToolUse.Name, Arguments: string(block.ToolUse.Input)},
+			})
+		}
+	}
+	return message
+}
+
+// crossRegionPrefixes are the inference profile prefixes that may precede a model ID
+var crossRegionPrefixes = map[string]bool{
+	"us":     true,
+	"us-gov": true,
+	"eu":     true,
+	"apac":   true,
+	"global": true,
+}
+
+// modelFamily returns the provider of a Bedrock model ID, e.g. anthropic for
+// anthropic.claude-3-haiku-20240307-v1:0 or us.anthropic.claude-3-5-sonnet-20241022-v2:0.
+// ARNs and unrecognised IDs return an empty string.
+func modelFamily(modelID string) string {
+	if strings.HasPrefix(modelID, "arn:") {
+		return ""
+	}
+
+	parts := strings.Split(modelID, ".")
+	if len(parts) > 2 && crossRegionPrefixes[parts[0]] {
+		parts = parts[1:]
+	}
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// noSystemPromptModels are model ID prefixes, after the family, of models
+// whose Converse support does not include a system prompt
+var noSystemPromptModels = map[string][]string{
+	"amazon":  {"titan-text"},
+	"mistral": {"mistral-7b-instruct", "mixtral-8x7b-instruct"},
+	"cohere":  {"command-text", "command-light-text"},
+	"ai21":    {"j2"},
+}
+
+// supportsSystemPrompt reports whether a model accepts a Converse system prompt
+func supportsSystemPrompt(modelID string) bool {
+	family := modelFamily(modelID)
+	if family == "" {
+		return true
+	}
+	_, name, _ := strings.Cut(modelID, family+".")
+
+	for _, prefix := range noSystemPromptModels[family] {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAPIError reads an error response into an *adapters.ProviderError.
+// Bedrock names the error type in the x-amzn-ErrorType header, e.g.
+// ThrottlingException:http://internal.amazon.com/.
+func parseAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+
+	apiErr.Type, _, _ = strings.Cut(resp.Header.Get("x-amzn-ErrorType"), ":")
+	if apiErr.Type == "" {
+		apiErr.Type = "UnknownError"
+	}
+
+	providerErr := adapters.NewProviderError("bedrock", resp, data, apiErr)
+	providerErr.Code, providerErr.Message = apiErr.Type, apiErr.Message
+
+	// Throttling is occasionally reported with a 400 status
+	if apiErr.Type == "ThrottlingException" {
+		providerErr.Kind = adapters.ErrRateLimited
+	}
+	return providerErr
+}
+
+// responseID returns the request ID Bedrock assigned to a response
+func responseID(resp *http.Response) string {
+	if id := resp.Header.Get("x-amzn-RequestId"); id != "" {
+		return "bedrock-" + id
+	}
+	return fmt.Sprintf("bedrock-%d", time.Now().UnixNano())
+}
+
+// joinText concatenates the text content blocks
+func joinText(blocks []ContentBlock) string {
+	var builder strings.Builder
+	for _, block := range blocks {
+		builder.WriteString(block.Text)
+	}
+	return builder.String()
+}
+
+// finishReason maps a Converse stop reason to an OpenAI finish reason
+func finishReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "guardrail_intervened", "content_filtered":
+		return "content_filter"
+	default:
+		return stopReason
+	}
+}
+
+// toUsage converts Converse usage to adapter usage
+func toUsage(usage Usage) adapters.Usage {
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.InputTokens + usage.OutputTokens
+	}
+
+	return adapters.Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      total,
+	}
+}
@@ -0,0 +1,171 @@
+package bedrock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// Event stream framing limits
+const (
+	preludeLength    = 12
+	messageCRCLength = 4
+	maxMessageLength = 16 << 20
+)
+
+// Event stream header value types
+const (
+	headerTrue byte = iota
+	headerFalse
+	headerByte
+	headerShort
+	headerInt
+	headerLong
+	headerBytes
+	headerString
+	headerTimestamp
+	headerUUID
+)
+
+// ErrChecksum is returned when an event stream message fails its CRC check
+var ErrChecksum = errors.New("event stream checksum mismatch")
+
+// EventMessage is a message in the AWS event stream encoding used by
+// streaming Bedrock responses. Only string headers are kept; other header
+// types are skipped.
+type EventMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// EventStreamReader reads application/vnd.amazon.eventstream messages
+type EventStreamReader struct {
+	reader io.Reader
+}
+
+// NewEventStreamReader creates a reader over an event stream
+func NewEventStreamReader(r io.Reader) *EventStreamReader {
+	return &EventStreamReader{reader: r}
+}
+
+// ReadMessage reads the next message. It returns io.EOF at the end of the
+// stream and io.ErrUnexpectedEOF if the stream ends inside a message.
+func (r *EventStreamReader) ReadMessage() (*EventMessage, error) {
+	prelude := make([]byte, preludeLength)
+	if _, err := io.ReadFull(r.reader, prelude); err != nil {
+		return nil, err
+	}
+
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("%w in prelude", ErrChecksum)
+	}
+	if totalLength > maxMessageLength || totalLength < preludeLength+messageCRCLength+headersLength {
+		return nil, fmt.Errorf("invalid event stream message length %d", totalLength)
+	}
+
+	message := make([]byte, totalLength)
+	copy(message, prelude)
+	if _, err := io.ReadFull(r.reader, message[preludeLength:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	crcOffset := totalLength - messageCRCLength
+	if crc32.ChecksumIEEE(message[:crcOffset]) != binary.BigEndian.Uint32(message[crcOffset:]) {
+		return nil, fmt.Errorf("%w in message", ErrChecksum)
+	}
+
+	headers, err := decodeHeaders(message[preludeLength : preludeLength+headersLength])
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventMessage{
+		Headers: headers,
+		Payload: message[preludeLength+headersLength : crcOffset],
+	}, nil
+}
+
+// EncodeEventMessage encodes a message with string headers. Bedrock only
+// sends event streams, so this is used by local stand-ins and recordings.
+func EncodeEventMessage(headers map[string]string, payload []byte) []byte {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headerBuf bytes.Buffer
+	for _, name := range names {
+		value := headers[name]
+		headerBuf.WriteByte(byte(len(name)))
+		headerBuf.WriteString(name)
+		headerBuf.WriteByte(headerString)
+		binary.Write(&headerBuf, binary.BigEndian, uint16(len(value)))
+		headerBuf.WriteString(value)
+	}
+
+	totalLength := preludeLength + headerBuf.Len() + len(payload) + messageCRCLength
+	message := make([]byte, 0, totalLength)
+	message = binary.BigEndian.AppendUint32(message, uint32(totalLength))
+	message = binary.BigEndian.AppendUint32(message, uint32(headerBuf.Len()))
+	message = binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+	message = append(message, headerBuf.Bytes()...)
+	message = append(message, payload...)
+	return binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+}
+
+// decodeHeaders decodes the headers section of a message
+func decodeHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLength := int(data[0])
+		if len(data) < 1+nameLength+1 {
+			return nil, errors.New("truncated event stream header")
+		}
+		name := string(data[1 : 1+nameLength])
+		valueType := data[1+nameLength]
+		data = data[2+nameLength:]
+
+		var size int
+		switch valueType {
+		case headerTrue, headerFalse:
+			size = 0
+		case headerByte:
+			size = 1
+		case headerShort:
+			size = 2
+		case headerInt:
+			size = 4
+		case headerLong, headerTimestamp:
+			size = 8
+		case headerUUID:
+			size = 16
+		case headerBytes, headerString:
+			if len(data) < 2 {
+				return nil, errors.New("truncated event stream header")
+			}
+			size = int(binary.BigEndian.Uint16(data[0:2]))
+			data = data[2:]
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+		}
+
+		if len(data) < size {
+			return nil, errors.New("truncated event stream header")
+		}
+		if valueType == headerString {
+			headers[name] = string(data[:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
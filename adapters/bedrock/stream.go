@@ -0,0 +1,196 @@
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+)
+
+// ConverseStream converts ConverseStream events into chat completion chunks
+type ConverseStream struct {
+	response   *http.Response
+	reader     *EventStreamReader
+	id         string
+	model      string
+	created    int64
+	stopReason string
+	tools      map[int]int
+	done       bool
+}
+
+// streamEvent is the payload of a ConverseStream event
+type streamEvent struct {
+	Role              string `json:"role"`
+	ContentBlockIndex int    `json:"contentBlockIndex"`
+	Start             struct {
+		ToolUse *struct {
+			ToolUseID string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse"`
+	} `json:"start"`
+	Delta struct {
+		Text    string `json:"text"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+	} `json:"delta"`
+	StopReason string `json:"stopReason"`
+	Usage      *Usage `json:"usage"`
+}
+
+// newConverseStream creates a stream over a ConverseStream response
+func newConverseStream(resp *http.Response, model string) *ConverseStream {
+	return &ConverseStream{
+		response: resp,
+		reader:   NewEventStreamReader(resp.Body),
+		id:       responseID(resp),
+		model:    model,
+		created:  time.Now().Unix(),
+		tools:    make(map[int]int),
+	}
+}
+
+// Recv receives the next chunk. The finish reason is sent with the usage
+// from the trailing metadata event. It returns io.EOF at the end of the
+// stream and an *adapters.ProviderError wrapping an *APIError if Bedrock
+// reports an exception mid-stream.
+func (s *ConverseStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	for {
+		message, err := s.reader.ReadMessage()
+		if err == io.EOF && s.stopReason != "" {
+			// The stream ended without metadata, so finish without usage
+			s.done = true
+			return s.chunk(adapters.StreamChoice{FinishReason: finishReason(s.stopReason)}), nil
+		}
+		if err != nil {
+			s.done = true
+			return nil, err
+		}
+
+		switch message.Headers[":message-type"] {
+		case "exception":
+			s.done = true
+			apiErr := &APIError{Type: message.Headers[":exception-type"]}
+			if err := json.Unmarshal(message.Payload, apiErr); err != nil {
+				apiErr.Message = string(message.Payload)
+			}
+			return nil, streamError(apiErr, message.Payload)
+		case "error":
+			s.done = true
+			apiErr := &APIError{Type: message.Headers[":error-code"], Message: message.Headers[":error-message"]}
+			return nil, streamError(apiErr, message.Payload)
+		}
+
+		var event streamEvent
+		if err := json.Unmarshal(message.Payload, &event); err != nil {
+			s.done = true
+			return nil, fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		switch message.Headers[":event-type"] {
+		case "messageStart":
+			return s.chunk(adapters.StreamChoice{Delta: adapters.Message{Role: event.Role}}), nil
+
+		case "contentBlockStart":
+			if event.Start.ToolUse == nil {
+				continue
+			}
+			// Tool calls are numbered in order, whatever their block index
+			index := len(s.tools)
+			s.tools[event.ContentBlockIndex] = index
+			return s.chunk(adapters.StreamChoice{Delta: adapters.Message{ToolCalls: []adapters.ToolCall{{
+				Index:    &index,
+				ID:       event.Start.ToolUse.ToolUseID,
+				Type:     "function",
+				Function: adapters.ToolCallFunction{Name: event.Start.ToolUse.Name},
+			}}}}), nil
+
+		case "contentBlockDelta":
+			if event.Delta.ToolUse != nil {
+				index, ok := s.tools[event.ContentBlockIndex]
+				if !ok || event.Delta.ToolUse.Input == "" {
+					continue
+				}
+				return s.chunk(adapters.StreamChoice{Delta: adapters.Message{ToolCalls: []adapters.ToolCall{{
+					Index:    &index,
+					Function: adapters.ToolCallFunction{Arguments: event.Delta.ToolUse.Input},
+				}}}}), nil
+			}
+			if event.Delta.Text == "" {
+				continue
+			}
+			return s.chunk(adapters.StreamChoice{Delta: adapters.Message{Content: event.Delta.Text}}), nil
+
+		case "messageStop":
+			s.stopReason = event.StopReason
+
+		case "metadata":
+			s.done = true
+			chunk := s.chunk(adapters.StreamChoice{FinishReason: finishReason(s.stopReason)})
+			if event.Usage != nil {
+				usage := toUsage(*event.Usage)
+				chunk.Usage = &usage
+			}
+			return chunk, nil
+		}
+
+		// contentBlockStop carries no chunk
+	}
+}
+
+// Close closes the stream
+func (s *ConverseStream) Close() error {
+	if s.response != nil && s.response.Body != nil {
+		return s.response.Body.Close()
+	}
+	return nil
+}
+
+// chunk builds a stream chunk with the response metadata
+func (s *ConverseStream) chunk(choice adapters.StreamChoice) *adapters.ChatCompletionStreamResponse {
+	return &adapters.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []adapters.StreamChoice{choice},
+	}
+}
+
+// streamError wraps an exception received mid-stream in an
+// *adapters.ProviderError, classified by its exception type since the
+// response status was already 200
+func streamError(apiErr *APIError, payload []byte) *adapters.ProviderError {
+	return &adapters.ProviderError{
+		Provider: "bedrock",
+		Kind:     exceptionKind(apiErr.Type),
+		Code:     apiErr.Type,
+		Message:  apiErr.Message,
+		Body:     payload,
+		Err:      apiErr,
+	}
+}
+
+// exceptionKind classifies a Bedrock exception type. Stream events name
+// them in lower camel case, e.g. throttlingException.
+func exceptionKind(exceptionType string) error {
+	switch strings.ToLower(exceptionType) {
+	case "throttlingexception":
+		return adapters.ErrRateLimited
+	case "accessdeniedexception", "unrecognizedclientexception":
+		return adapters.ErrAuthentication
+	case "validationexception", "resourcenotfoundexception":
+		return adapters.ErrInvalidRequest
+	default:
+		return adapters.ErrServerError
+	}
+}
@@ -0,0 +1,498 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+)
+
+// Modes
+const (
+	// ModeReplay serves recorded interactions and fails unmatched requests
+	ModeReplay = "replay"
+	// ModeRecord serves recorded interactions and forwards unmatched
+	// requests to the wrapped adapter, adding its replies to the cassette
+	ModeRecord = "record"
+	// ModeScripted serves interactions in order, whatever the request
+	ModeScripted = "scripted"
+)
+
+// ErrNoInteraction is returned when no interaction is left to serve a request
+var ErrNoInteraction = errors.New("no cassette interaction matches request")
+
+// Adapter is an LLMAdapter that replays interactions from a cassette. In
+// record mode it records what the wrapped adapter returns for requests the
+// cassette has not seen. Requests are matched on their normalised JSON, so
+// redacted requests match as long as redaction is deterministic, as
+// tokenization is. Each interaction is served once before a repeated
+// request reuses the last match.
+type Adapter struct {
+	path     string
+	mode     string
+	inner    adapters.LLMAdapter
+	cassette *Cassette
+	used     []bool
+	next     int
+	requests []json.RawMessage
+	mutex    sync.Mutex
+}
+
+// Ensure Adapter implements adapters.LLMAdapter
+var _ adapters.LLMAdapter = (*Adapter)(nil)
+
+// New creates an adapter for the cassette at path. Record mode starts a new
+// cassette if none exists and needs inner to forward unmatched requests to;
+// the other modes ignore inner.
+func New(path, mode string, inner adapters.LLMAdapter) (*Adapter, error) {
+	switch mode {
+	case "":
+		mode = ModeReplay
+	case ModeReplay, ModeRecord, ModeScripted:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode: %s", mode)
+	}
+
+	cassette, err := Load(path)
+	if errors.Is(err, fs.ErrNotExist) && mode == ModeRecord {
+		cassette, err = &Cassette{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Adapter{
+		path:     path,
+		mode:     mode,
+		inner:    inner,
+		cassette: cassette,
+		used:     make([]bool, len(cassette.Interactions)),
+	}, nil
+}
+
+// NewScripted creates an adapter that serves interactions in order without
+// a cassette file, for canned or adversarial provider output in tests
+func NewScripted(interactions ...Interaction) *Adapter {
+	return &Adapter{
+		mode:     ModeScripted,
+		cassette: &Cassette{Interactions: interactions},
+		used:     make([]bool, len(interactions)),
+	}
+}
+
+// Reply returns a scripted interaction answering with an assistant message
+func Reply(content string) Interaction {
+	return Interaction{Response: &adapters.ChatCompletionResponse{
+		ID:     "chatcmpl-cassette",
+		Object: "chat.completion",
+		Choices: []adapters.Choice{{
+			Message:      adapters.Message{Role: "assistant", Content: content},
+			FinishReason: "stop",
+		}},
+	}}
+}
+
+// StreamReply returns a scripted interaction streaming one content delta
+// per chunk, so tests can choose exactly where text is split
+func StreamReply(deltas ...string) Interaction {
+	chunks := make([]*adapters.ChatCompletionStreamResponse, 0, len(deltas)+1)
+	for i, delta := range deltas {
+		message := adapters.Message{Content: delta}
+		if i == 0 {
+			message.Role = "assistant"
+		}
+		chunks = append(chunks, streamChunk(message, ""))
+	}
+	chunks = append(chunks, streamChunk(adapters.Message{}, "stop"))
+	return Interaction{Kind: KindStream, Chunks: chunks}
+}
+
+// Fail returns a scripted interaction that fails with err
+func Fail(err error) Interaction {
+	return Interaction{Error: newError(err)}
+}
+
+// Constructor returns a registry constructor for cassette adapters. The
+// settings are path, mode (replay, record or scripted; default replay) and,
+// for record mode, adapter: the type and settings of the adapter to record,
+// which is created with factory.
+func Constructor(factory adapters.AdapterFactory) adapters.Constructor {
+	return func(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+		path, err := adapters.StringSetting(settings, "path", "")
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return nil, fmt.Errorf("path is required for cassette adapters")
+		}
+
+		mode, err := adapters.StringSetting(settings, "mode", ModeReplay)
+		if err != nil {
+			return nil, err
+		}
+
+		innerSettings, err := adapters.MapSetting(settings, "adapter")
+		if err != nil {
+			return nil, err
+		}
+
+		var inner adapters.LLMAdapter
+		if innerSettings != nil {
+			innerType, err := adapters.StringSetting(innerSettings, "type", "")
+			if err != nil {
+				return nil, err
+			}
+			config, err := adapters.MapSetting(innerSettings, "settings")
+			if err != nil {
+				return nil, err
+			}
+			inner, err = factory.CreateAdapter(&adapters.AdapterConfig{Type: innerType, Settings: config})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return New(path, mode, inner)
+	}
+}
+
+// SetCircuitBreakers passes circuit breakers on to the recorded adapter
+func (a *Adapter) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	if breakable, ok := a.inner.(interface {
+		SetCircuitBreakers(*circuitbreaker.Manager)
+	}); ok {
+		breakable.SetCircuitBreakers(breakers)
+	}
+}
+
+// SetDetectionHandler passes the detection handler on to the recorded adapter
+func (a *Adapter) SetDetectionHandler(handler adapters.DetectionHandler) {
+	if filtered, ok := a.inner.(interface {
+		SetDetectionHandler(adapters.DetectionHandler)
+	}); ok {
+		filtered.SetDetectionHandler(handler)
+	}
+}
+
+// Requests returns the normalised form of every request the adapter has
+// received, so tests can check what would have reached the provider
+func (a *Adapter) Requests() []json.RawMessage {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append([]json.RawMessage(nil), a.requests...)
+}
+
+// ChatCompletion replays or records a chat completion
+func (a *Adapter) ChatCompletion(ctx context.Context, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	request, interaction, err := a.lookup(KindChat, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if interaction == nil {
+		resp, err := a.inner.ChatCompletion(ctx, req)
+		recorded := Interaction{Kind: KindChat, Request: request}
+		if err != nil {
+			recorded.Error = newError(err)
+		} else if err := clone(resp, &recorded.Response); err != nil {
+			return nil, err
+		}
+		if err := a.record(recorded); err != nil {
+			return nil, err
+		}
+		return resp, err
+	}
+
+	if interaction.Response == nil {
+		return nil, interaction.failure(KindChat)
+	}
+	var resp *adapters.ChatCompletionResponse
+	if err := clone(interaction.Response, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ChatCompletionStream replays or records a streaming chat completion. A
+// stream being recorded is added to the cassette once it has been read to
+// the end; streams closed early are not recorded.
+func (a *Adapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	request, interaction, err := a.lookup(KindStream, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if interaction == nil {
+		stream, err := a.inner.ChatCompletionStream(ctx, req)
+		if err != nil {
+			if recordErr := a.record(Interaction{Kind: KindStream, Request: request, Error: newError(err)}); recordErr != nil {
+				return nil, recordErr
+			}
+			return nil, err
+		}
+		return &recordingStream{stream: stream, adapter: a, interaction: Interaction{Kind: KindStream, Request: request}}, nil
+	}
+
+	chunks := interaction.Chunks
+	if chunks == nil && interaction.Response != nil {
+		chunks = chunksFromResponse(interaction.Response)
+	}
+	if chunks == nil {
+		return nil, interaction.failure(KindStream)
+	}
+
+	replay := &replayStream{}
+	if err := clone(chunks, &replay.chunks); err != nil {
+		return nil, err
+	}
+	if interaction.Error != nil {
+		replay.err = interaction.Error.err()
+	}
+	return replay, nil
+}
+
+// Embed replays or records an embeddings request
+func (a *Adapter) Embed(ctx context.Context, req *adapters.EmbeddingRequest) (*adapters.EmbeddingResponse, error) {
+	request, interaction, err := a.lookup(KindEmbed, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if interaction == nil {
+		resp, err := a.inner.Embed(ctx, req)
+		recorded := Interaction{Kind: KindEmbed, Request: request}
+		if err != nil {
+			recorded.Error = newError(err)
+		} else if err := clone(resp, &recorded.Embedding); err != nil {
+			return nil, err
+		}
+		if err := a.record(recorded); err != nil {
+			return nil, err
+		}
+		return resp, err
+	}
+
+	if interaction.Embedding == nil {
+		return nil, interaction.failure(KindEmbed)
+	}
+	var resp *adapters.EmbeddingResponse
+	if err := clone(interaction.Embedding, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetModelInfo gets model information from the recorded adapter, or
+// describes the model as served by the cassette
+func (a *Adapter) GetModelInfo(ctx context.Context, modelID string) (*adapters.ModelInfo, error) {
+	if a.mode == ModeRecord && a.inner != nil {
+		return a.inner.GetModelInfo(ctx, modelID)
+	}
+	return &adapters.ModelInfo{ID: modelID, Object: "model", OwnedBy: "cassette"}, nil
+}
+
+// ValidateConfig validates the adapter configuration
+func (a *Adapter) ValidateConfig() error {
+	if a.mode == ModeRecord {
+		if a.inner == nil {
+			return fmt.Errorf("record mode requires an adapter to record")
+		}
+		return a.inner.ValidateConfig()
+	}
+	return nil
+}
+
+// GetCapabilities returns the adapter capabilities
+func (a *Adapter) GetCapabilities() *adapters.AdapterCapabilities {
+	if a.inner != nil {
+		return a.inner.GetCapabilities()
+	}
+	return &adapters.AdapterCapabilities{
+		Streaming:     true,
+		FunctionCalls: true,
+		Embeddings:    true,
+		ModelInfo:     true,
+	}
+}
+
+// lookup normalises and logs a request and finds the interaction to serve
+// it. It returns a nil interaction when the request should be recorded.
+func (a *Adapter) lookup(kind string, req interface{}) (json.RawMessage, *Interaction, error) {
+	request, err := NormaliseRequest(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to normalise request: %w", err)
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.requests = append(a.requests, request)
+	interactions := a.cassette.Interactions
+
+	if a.mode == ModeScripted {
+		if a.next >= len(interactions) {
+			return nil, nil, fmt.Errorf("%w: the script has only %d interactions", ErrNoInteraction, len(interactions))
+		}
+		a.next++
+		return request, &interactions[a.next-1], nil
+	}
+
+	last := -1
+	for i := range interactions {
+		if interactions[i].kind() != kind || !bytes.Equal(interactions[i].Request, request) {
+			continue
+		}
+		if !a.used[i] {
+			a.used[i] = true
+			return request, &interactions[i], nil
+		}
+		last = i
+	}
+	if last >= 0 {
+		return request, &interactions[last], nil
+	}
+
+	if a.mode != ModeRecord {
+		return nil, nil, fmt.Errorf("%w: %s request %s", ErrNoInteraction, kind, request)
+	}
+	return request, nil, nil
+}
+
+// record adds an interaction to the cassette and saves it
+func (a *Adapter) record(interaction Interaction) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.cassette.Interactions = append(a.cassette.Interactions, interaction)
+	a.used = append(a.used, true)
+	if err := a.cassette.Save(a.path); err != nil {
+		return fmt.Errorf("failed to record interaction: %w", err)
+	}
+	return nil
+}
+
+// failure returns the interaction's error, or ErrNoInteraction if it has
+// no reply of the kind requested
+func (i *Interaction) failure(kind string) error {
+	if i.Error != nil {
+		return i.Error.err()
+	}
+	return fmt.Errorf("%w: interaction has no %s reply", ErrNoInteraction, kind)
+}
+
+// recordingStream passes a recorded adapter's stream through and records
+// its chunks
+type recordingStream struct {
+	stream      adapters.ChatCompletionStream
+	adapter     *Adapter
+	interaction Interaction
+	done        bool
+}
+
+// Recv receives the next chunk, recording the interaction at the end of
+// the stream
+func (s *recordingStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	chunk, err := s.stream.Recv()
+	if s.done {
+		return chunk, err
+	}
+
+	if err == nil {
+		var recorded *adapters.ChatCompletionStreamResponse
+		if cloneErr := clone(chunk, &recorded); cloneErr != nil {
+			return nil, cloneErr
+		}
+		s.interaction.Chunks = append(s.interaction.Chunks, recorded)
+		return chunk, nil
+	}
+
+	s.done = true
+	if err != io.EOF {
+		s.interaction.Error = newError(err)
+	}
+	if recordErr := s.adapter.record(s.interaction); recordErr != nil {
+		return nil, recordErr
+	}
+	return chunk, err
+}
+
+// Close closes the recorded stream
+func (s *recordingStream) Close() error {
+	s.done = true
+	return s.stream.Close()
+}
+
+// replayStream replays recorded chunks followed by err, or io.EOF
+type replayStream struct {
+	chunks []*adapters.ChatCompletionStreamResponse
+	err    error
+}
+
+// Recv returns the next recorded chunk
+func (s *replayStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+// Close closes the stream
+func (s *replayStream) Close() error {
+	s.chunks = nil
+	return nil
+}
+
+// chunksFromResponse streams a complete response as one chunk per choice
+func chunksFromResponse(resp *adapters.ChatCompletionResponse) []*adapters.ChatCompletionStreamResponse {
+	chunk := &adapters.ChatCompletionStreamResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion.chunk",
+		Created: resp.Created,
+		Model:   resp.Model,
+		Usage:   &resp.Usage,
+	}
+	for _, choice := range resp.Choices {
+		chunk.Choices = append(chunk.Choices, adapters.StreamChoice{
+			Index:        choice.Index,
+			Delta:        choice.Message,
+			FinishReason: choice.FinishReason,
+		})
+	}
+	return []*adapters.ChatCompletionStreamResponse{chunk}
+}
+
+// streamChunk returns a scripted chunk for the first choice
+func streamChunk(delta adapters.Message, finishReason string) *adapters.ChatCompletionStreamResponse {
+	return &adapters.ChatCompletionStreamResponse{
+		ID:      "chatcmpl-cassette",
+		Object:  "chat.completion.chunk",
+		Choices: []adapters.StreamChoice{{Delta: delta, FinishReason: finishReason}},
+	}
+}
+
+// clone deep copies src into dst through JSON, so replies handed to the
+// gateway, which rewrites them in place, never alias the cassette
+func clone(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return fmt.Errorf("failed to copy interaction: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to copy interaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Package cassette provides an LLMAdapter that records provider traffic to
+// disk and replays it, so the gateway can be tested end to end without a
+// network. A scripted mode serves canned or adversarial responses in order.
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+)
+
+// Interaction kinds
+const (
+	KindChat   = "chat"
+	KindStream = "stream"
+	KindEmbed  = "embed"
+)
+
+// ignoredFields are request fields that do not change the response and are
+// left out when matching requests
+var ignoredFields = []string{"stream", "stream_options", "user"}
+
+// Cassette is a list of recorded or scripted interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request and the provider's reply. Request is the
+// normalised request and is left empty in scripted cassettes. A reply is
+// a Response, stream Chunks or an Embedding, optionally followed by Error;
+// a streamed reply ends with Error after its chunks. Without a Kind, the
+// kind is taken from the reply, and interactions with neither chunks nor an
+// embedding are chat completions.
+type Interaction struct {
+	Kind      string                                   `json:"kind,omitempty"`
+	Request   json.RawMessage                          `json:"request,omitempty"`
+	Response  *adapters.ChatCompletionResponse         `json:"response,omitempty"`
+	Chunks    []*adapters.ChatCompletionStreamResponse `json:"chunks,omitempty"`
+	Embedding *adapters.EmbeddingResponse              `json:"embedding,omitempty"`
+	Error     *Error                                   `json:"error,omitempty"`
+}
+
+// kind returns the interaction's kind, inferred from its reply if unset
+func (i *Interaction) kind() string {
+	switch {
+	case i.Kind != "":
+		return i.Kind
+	case len(i.Chunks) > 0:
+		return KindStream
+	case i.Embedding != nil:
+		return KindEmbed
+	default:
+		return KindChat
+	}
+}
+
+// Error is a recorded error. Errors with a Kind or StatusCode are replayed
+// as an *adapters.ProviderError, and others as a plain error.
+type Error struct {
+	// Kind is rate_limited, authentication, invalid_request,
+	// content_filtered or server_error
+	Kind       string      `json:"kind,omitempty"`
+	Provider   string      `json:"provider,omitempty"`
+	StatusCode int         `json:"status_code,omitempty"`
+	Code       string      `json:"code,omitempty"`
+	Message    string      `json:"message"`
+	Body       string      `json:"body,omitempty"`
+	RetryAfter string      `json:"retry_after,omitempty"`
+	RateLimit  http.Header `json:"rate_limit,omitempty"`
+}
+
+// errorKinds names the provider error kinds in cassettes
+var errorKinds = map[string]error{
+	"rate_limited":     adapters.ErrRateLimited,
+	"authentication":   adapters.ErrAuthentication,
+	"invalid_request":  adapters.ErrInvalidRequest,
+	"content_filtered": adapters.ErrContentFiltered,
+	"server_error":     adapters.ErrServerError,
+}
+
+// Load reads a cassette file
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+
+	// Compact recorded requests so they compare equal to normalised ones
+	for i, interaction := range cassette.Interactions {
+		if len(interaction.Request) == 0 {
+			continue
+		}
+		normalised, err := normalise(interaction.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request in interaction %d: %w", i, err)
+		}
+		cassette.Interactions[i].Request = normalised
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to path, replacing any existing file atomically
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// NormaliseRequest encodes a request in the canonical form used to match
+// interactions: object keys sorted, no insignificant whitespace and fields
+// that do not affect the response, such as stream, removed
+func NormaliseRequest(req interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return normalise(data)
+}
+
+// normalise re-encodes JSON in canonical form
+func normalise(data []byte) (json.RawMessage, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	if object, ok := decoded.(map[string]interface{}); ok {
+		for _, field := range ignoredFields {
+			delete(object, field)
+		}
+	}
+	return json.Marshal(decoded)
+}
+
+// err returns the error an interaction replays
+func (e *Error) err() error {
+	kind, known := errorKinds[e.Kind]
+	if !known && e.StatusCode == 0 {
+		return errors.New(e.Message)
+	}
+	if !known {
+		kind = adapters.KindForStatus(e.StatusCode)
+	}
+
+	retryAfter, _ := time.ParseDuration(e.RetryAfter)
+	providerErr := &adapters.ProviderError{
+		Provider:   e.Provider,
+		Kind:       kind,
+		StatusCode: e.StatusCode,
+		Code:       e.Code,
+		Message:    e.Message,
+		RetryAfter: retryAfter,
+		RateLimit:  e.RateLimit,
+	}
+	if e.Body != "" {
+		providerErr.Body = []byte(e.Body)
+	}
+	return providerErr
+}
+
+// newError records err, keeping the provider error details if it has them
+func newError(err error) *Error {
+	var providerErr *adapters.ProviderError
+	if errors.As(err, &providerErr) {
+		recorded := &Error{
+			Provider:   providerErr.Provider,
+			StatusCode: providerErr.StatusCode,
+			Code:       providerErr.Code,
+			Message:    providerErr.Message,
+			Body:       string(providerErr.Body),
+			RateLimit:  providerErr.RateLimit,
+		}
+		if recorded.Message == "" {
+			recorded.Message = err.Error()
+		}
+		if providerErr.RetryAfter > 0 {
+			recorded.RetryAfter = providerErr.RetryAfter.String()
+		}
+		recorded.Kind = kindName(err)
+		return recorded
+	}
+
+	return &Error{Kind: kindName(err), Message: err.Error()}
+}
+
+// kindName returns the cassette name of err's provider error kind, if any
+func kindName(err error) string {
+	for name, kind := range errorKinds {
+		if errors.Is(err, kind) {
+			return name
+		}
+	}
+	return ""
+}
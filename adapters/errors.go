@@ -0,0 +1,145 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider error kinds. Adapters report failed provider calls as a
+// *ProviderError whose Kind is one of these, so callers can match them with
+// errors.Is without knowing which provider served the request.
+var (
+	ErrRateLimited     = errors.New("provider rate limit exceeded")
+	ErrAuthentication  = errors.New("provider rejected credentials")
+	ErrInvalidRequest  = errors.New("provider rejected request")
+	ErrContentFiltered = errors.New("provider content filter blocked request")
+	ErrServerError     = errors.New("provider server error")
+)
+
+// ProviderError is an error response from a provider API
+type ProviderError struct {
+	// Provider names the adapter's provider, e.g. openai or bedrock
+	Provider string
+	// Kind is one of the provider error kinds
+	Kind       error
+	StatusCode int
+	// Code is the provider's error type or code, e.g. rate_limit_error
+	Code    string
+	Message string
+	// Body is the provider's error payload
+	Body []byte
+	// RetryAfter is how long the provider asked callers to wait, if it said
+	RetryAfter time.Duration
+	// RateLimit holds the provider's x-ratelimit-* style headers
+	RateLimit http.Header
+	// Err is the adapter's own error type, such as *anthropic.APIError
+	Err error
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s API returned status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// Is reports whether target is the error's kind
+func (e *ProviderError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the adapter's own error
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed if sent again later or
+// to another upstream
+func (e *ProviderError) Retryable() bool {
+	return e.Kind == ErrRateLimited || e.Kind == ErrServerError
+}
+
+// NewProviderError builds a ProviderError from an error response, its body
+// and the adapter's parsed error. err is classified as content filtered if
+// it matches ErrContentFiltered, and by status code otherwise.
+func NewProviderError(provider string, resp *http.Response, body []byte, err error) *ProviderError {
+	kind := KindForStatus(resp.StatusCode)
+	if errors.Is(err, ErrContentFiltered) {
+		kind = ErrContentFiltered
+	}
+
+	return &ProviderError{
+		Provider:   provider,
+		Kind:       kind,
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		RetryAfter: ParseRetryAfter(resp.Header),
+		RateLimit:  RateLimitHeaders(resp.Header),
+		Err:        err,
+	}
+}
+
+// KindForStatus classifies an HTTP error status. Request timeouts and
+// Anthropic's 529 overloaded status count as server errors.
+func KindForStatus(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuthentication
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status == http.StatusRequestTimeout:
+		return ErrServerError
+	case status >= 400 && status < 500:
+		return ErrInvalidRequest
+	default:
+		return ErrServerError
+	}
+}
+
+// ParseRetryAfter returns the wait requested by a Retry-After header, given
+// in seconds or as an HTTP date, or by OpenAI's retry-after-ms header. It
+// returns zero if neither is present.
+func ParseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// RateLimitHeaders returns the rate limit headers in header, such as
+// x-ratelimit-remaining-requests and anthropic-ratelimit-tokens-reset, or
+// nil if there are none
+func RateLimitHeaders(header http.Header) http.Header {
+	var limits http.Header
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-ratelimit-") || strings.HasPrefix(lower, "anthropic-ratelimit-") {
+			if limits == nil {
+				limits = make(http.Header)
+			}
+			limits[name] = append([]string(nil), values...)
+		}
+	}
+	return limits
+}
@@ -0,0 +1,760 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// DefaultBaseURL is the Gemini API endpoint
+const DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiAdapter implements the LLMAdapter interface for the Gemini API
+type GeminiAdapter struct {
+	apiKey      string
+	baseURL     string
+	httpClient  *http.Client
+	breakers    *circuitbreaker.Manager
+	onDetection adapters.DetectionHandler
+}
+
+// Ensure GeminiAdapter implements adapters.LLMAdapter and adapters.ModelLister
+var (
+	_ adapters.LLMAdapter  = (*GeminiAdapter)(nil)
+	_ adapters.ModelLister = (*GeminiAdapter)(nil)
+)
+
+// GenerateContentRequest is a generateContent request
+type GenerateContentRequest struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// Content is a turn of the conversation
+type Content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+// Part is a piece of content. Exactly one field is set.
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	InlineData       *Blob             `json:"inlineData,omitempty"`
+	FileData         *FileData         `json:"fileData,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// Blob is inline media; Data is base64 encoded
+type Blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// FileData is media referenced by URI
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// FunctionCall is a function call made by the model
+type FunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// FunctionResponse is the result of a function call
+type FunctionResponse struct {
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Tool holds the functions a model may call
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations"`
+}
+
+// FunctionDeclaration is a function definition
+type FunctionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+// ToolConfig controls function calling
+type ToolConfig struct {
+	FunctionCallingConfig struct {
+		Mode                 string   `json:"mode"`
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig"`
+}
+
+// GenerationConfig holds the generation parameters
+type GenerationConfig struct {
+	Temperature        *float32        `json:"temperature,omitempty"`
+	MaxOutputTokens    int             `json:"maxOutputTokens,omitempty"`
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+// GenerateContentResponse is a generateContent response, or one chunk of a
+// streamGenerateContent response
+type GenerateContentResponse struct {
+	Candidates     []Candidate    `json:"candidates"`
+	PromptFeedback PromptFeedback `json:"promptFeedback"`
+	UsageMetadata  *UsageMetadata `json:"usageMetadata"`
+	ModelVersion   string         `json:"modelVersion"`
+	ResponseID     string         `json:"responseId"`
+}
+
+// Candidate is a generated response
+type Candidate struct {
+	Index         int           `json:"index"`
+	Content       Content       `json:"content"`
+	FinishReason  string        `json:"finishReason"`
+	SafetyRatings SafetyRatings `json:"safetyRatings"`
+}
+
+// PromptFeedback reports whether and why the prompt was blocked
+type PromptFeedback struct {
+	BlockReason   string        `json:"blockReason"`
+	SafetyRatings SafetyRatings `json:"safetyRatings"`
+}
+
+// UsageMetadata is Gemini token usage
+type UsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// APIError is an error reported by the Gemini API
+type APIError struct {
+	StatusCode int    `json:"code"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("Gemini API returned status %d: %s: %s", e.StatusCode, e.Status, e.Message)
+	}
+	return fmt.Sprintf("Gemini API error: %s: %s", e.Status, e.Message)
+}
+
+// NewGeminiAdapter creates a new Gemini adapter
+func NewGeminiAdapter(apiKey, baseURL string, timeout time.Duration) *GeminiAdapter {
+	return &GeminiAdapter{
+		apiKey:  apiKey,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// NewAdapterFromSettings creates a Gemini adapter from registry settings:
+// apiKey or apiKeyEnv, baseUrl and timeout
+func NewAdapterFromSettings(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+	apiKey, err := adapters.SecretSetting(settings, "apiKey")
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := adapters.StringSetting(settings, "baseUrl", DefaultBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := adapters.DurationSetting(settings, "timeout", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGeminiAdapter(apiKey, baseURL, timeout), nil
+}
+
+// SetDetectionHandler sets the handler for safety ratings on responses that
+// were not blocked
+func (ga *GeminiAdapter) SetDetectionHandler(handler adapters.DetectionHandler) {
+	ga.onDetection = handler
+}
+
+// SetCircuitBreakers enables per-model circuit breaking for requests to Gemini
+func (ga *GeminiAdapter) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	ga.breakers = breakers
+}
+
+// ChatCompletion sends a chat completion request to generateContent
+func (ga *GeminiAdapter) ChatCompletion(ctx context.Context, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	geminiReq, err := toGenerateContentRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := ga.send(ctx, req.Model, "generateContent", geminiReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp GenerateContentResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	detections, err := checkSafety(&resp)
+	if err != nil {
+		return nil, err
+	}
+	ga.reportDetections(ctx, req.Model, detections)
+
+	choices := make([]adapters.Choice, len(resp.Candidates))
+	for i, candidate := range resp.Candidates {
+		message := adapters.Message{Role: "assistant", Content: joinText(candidate.Content.Parts)}
+		message.ToolCalls = toToolCalls(candidate.Content.Parts, 0)
+
+		choices[i] = adapters.Choice{
+			Index:        candidate.Index,
+			Message:      message,
+			FinishReason: finishReason(candidate.FinishReason),
+		}
+		if len(message.ToolCalls) > 0 && choices[i].FinishReason == "stop" {
+			choices[i].FinishReason = "tool_calls"
+		}
+	}
+
+	return &adapters.ChatCompletionResponse{
+		ID:      responseID(&resp),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   modelName(&resp, req.Model),
+		Choices: choices,
+		Usage:   toUsage(resp.UsageMetadata),
+	}, nil
+}
+
+// ChatCompletionStream sends a streaming chat completion request to streamGenerateContent
+func (ga *GeminiAdapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	geminiReq, err := toGenerateContentRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := ga.send(ctx, req.Model, "streamGenerateContent", geminiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return newContentStream(ctx, ga, req.Model, httpResp), nil
+}
+
+// Embed creates embeddings with batchEmbedContents
+func (ga *GeminiAdapter) Embed(ctx context.Context, req *adapters.EmbeddingRequest) (*adapters.EmbeddingResponse, error) {
+	type embedContentRequest struct {
+		Model                string  `json:"model"`
+		Content              Content `json:"content"`
+		OutputDimensionality int     `json:"outputDimensionality,omitempty"`
+	}
+
+	var batch struct {
+		Requests []embedContentRequest `json:"requests"`
+	}
+	for _, input := range req.Input {
+		batch.Requests = append(batch.Requests, embedContentRequest{
+			Model:                modelPath(req.Model),
+			Content:              Content{Parts: []Part{{Text: input}}},
+			OutputDimensionality: req.Dimensions,
+		})
+	}
+
+	httpResp, err := ga.send(ctx, req.Model, "batchEmbedContents", &batch)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var embedded struct {
+		Embeddings []struct {
+			Values []float64 `json:"values"`
+		} `json:"embeddings"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&embedded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Gemini does not report token usage for embeddings
+	resp := &adapters.EmbeddingResponse{Object: "list", Model: req.Model}
+	for i, embedding := range embedded.Embeddings {
+		resp.Data = append(resp.Data, adapters.Embedding{Object: "embedding", Index: i, Embedding: embedding.Values})
+	}
+	return resp, nil
+}
+
+// model is a Gemini model resource
+type model struct {
+	Name                       string   `json:"name"`
+	DisplayName                string   `json:"displayName"`
+	SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+}
+
+// GetModelInfo gets information about a model
+func (ga *GeminiAdapter) GetModelInfo(ctx context.Context, modelID string) (*adapters.ModelInfo, error) {
+	var m model
+	if err := ga.get(ctx, "/"+modelPath(modelID), &m); err != nil {
+		return nil, err
+	}
+
+	return &adapters.ModelInfo{
+		ID:      strings.TrimPrefix(m.Name, "models/"),
+		Object:  "model",
+		OwnedBy: "google",
+	}, nil
+}
+
+// ListModels lists the models that support generateContent
+func (ga *GeminiAdapter) ListModels(ctx context.Context) ([]adapters.ModelInfo, error) {
+	var models []adapters.ModelInfo
+	pageToken := ""
+	for {
+		path := "/models?pageSize=1000"
+		if pageToken != "" {
+			path += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		var page struct {
+			Models        []model `json:"models"`
+			NextPageToken string  `json:"nextPageToken"`
+		}
+		if err := ga.get(ctx, path, &page); err != nil {
+			return nil, err
+		}
+
+		for _, m := range page.Models {
+			if !supports(m.SupportedGenerationMethods, "generateContent") {
+				continue
+			}
+			models = append(models, adapters.ModelInfo{
+				ID:      strings.TrimPrefix(m.Name, "models/"),
+				Object:  "model",
+				OwnedBy: "google",
+			})
+		}
+
+		if page.NextPageToken == "" {
+			return models, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// ValidateConfig validates the adapter configuration
+func (ga *GeminiAdapter) ValidateConfig() error {
+	if ga.apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+
+	if ga.baseURL == "" {
+		ga.baseURL = DefaultBaseURL
+	}
+
+	return nil
+}
+
+// GetCapabilities returns the adapter capabilities
+func (ga *GeminiAdapter) GetCapabilities() *adapters.AdapterCapabilities {
+	return &adapters.AdapterCapabilities{
+		Streaming:     true,
+		FunctionCalls: true,
+		Embeddings:    true,
+		ModelInfo:     true,
+		RateLimiting:  true,
+	}
+}
+
+// send posts a request to a model method and returns the response if it
+// succeeded. Streaming methods are requested as server-sent events.
+func (ga *GeminiAdapter) send(ctx context.Context, modelID, method string, req interface{}) (*http.Response, error) {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := ga.baseURL + "/" + modelPath(modelID) + ":" + method
+	if strings.HasPrefix(method, "stream") {
+		endpoint += "?alt=sse"
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", ga.apiKey)
+
+	httpResp, err := ga.breakers.Do(ga.httpClient, httpReq, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		return nil, parseAPIError(httpResp)
+	}
+
+	return httpResp, nil
+}
+
+// get fetches a resource and decodes it into v
+func (ga *GeminiAdapter) get(ctx context.Context, path string, v interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", ga.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("x-goog-api-key", ga.apiKey)
+
+	httpResp, err := ga.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return parseAPIError(httpResp)
+	}
+
+	if err := json.NewDecoder(httpResp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// reportDetections passes non-blocking detections to the handler
+func (ga *GeminiAdapter) reportDetections(ctx context.Context, model string, detections []detector.DetectionResult) {
+	if ga.onDetection != nil && len(detections) > 0 {
+		ga.onDetection(ctx, model, detections)
+	}
+}
+
+// toGenerateContentRequest converts a chat completion request. System
+// messages become the system instruction, assistant turns use the model
+// role, tool results are sent as user function responses, and consecutive
+// turns from the same role are merged into one content with several parts.
+func toGenerateContentRequest(req *adapters.ChatCompletionRequest) (*GenerateContentRequest, error) {
+	var system []Part
+	var contents []Content
+
+	// Function responses are matched to calls by name, not by call ID
+	functionNames := make(map[string]string)
+
+	for _, message := range req.Messages {
+		if message.Role == "system" {
+			system = append(system, Part{Text: message.Text()})
+			continue
+		}
+
+		for _, call := range message.ToolCalls {
+			functionNames[call.ID] = call.Function.Name
+		}
+
+		parts, err := toParts(&message, functionNames)
+		if err != nil {
+			return nil, err
+		}
+
+		role := "user"
+		if message.Role == "assistant" {
+			role = "model"
+		}
+
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			continue
+		}
+		contents = append(contents, Content{Role: role, Parts: parts})
+	}
+
+	geminiReq := &GenerateContentRequest{Contents: contents}
+	if len(system) > 0 {
+		geminiReq.SystemInstruction = &Content{Parts: system}
+	}
+
+	if len(req.Tools) > 0 {
+		tool := Tool{}
+		for _, t := range req.Tools {
+			tool.FunctionDeclarations = append(tool.FunctionDeclarations, FunctionDeclaration{
+				Name:                 t.Function.Name,
+				Description:          t.Function.Description,
+				ParametersJSONSchema: t.Function.Parameters,
+			})
+		}
+		geminiReq.Tools = []Tool{tool}
+		geminiReq.ToolConfig = toToolConfig(req.ToolChoice)
+	}
+
+	format := req.ResponseFormat
+	jsonMode := format != nil && (format.Type == "json_object" || format.Type == "json_schema")
+
+	if req.MaxTokens > 0 || req.Temperature != nil || jsonMode {
+		geminiReq.GenerationConfig = &GenerationConfig{MaxOutputTokens: req.MaxTokens, Temperature: req.Temperature}
+		if jsonMode {
+			geminiReq.GenerationConfig.ResponseMimeType = "application/json"
+			if format.JSONSchema != nil {
+				geminiReq.GenerationConfig.ResponseJSONSchema = format.JSONSchema.Schema
+			}
+		}
+	}
+
+	return geminiReq, nil
+}
+
+// toParts converts a message's content and tool calls. Data URLs are sent
+// inline and other URLs by reference.
+func toParts(message *adapters.Message, functionNames map[string]string) ([]Part, error) {
+	if message.Role == "tool" {
+		response := json.RawMessage(message.Text())
+		if !strings.HasPrefix(strings.TrimSpace(message.Text()), "{") || !json.Valid(response) {
+			response, _ = json.Marshal(map[string]string{"content": message.Text()})
+		}
+		return []Part{{FunctionResponse: &FunctionResponse{
+			Name:     functionNames[message.ToolCallID],
+			Response: response,
+		}}}, nil
+	}
+
+	var parts []Part
+	if message.Parts == nil {
+		if message.Content != "" || len(message.ToolCalls) == 0 {
+			parts = append(parts, Part{Text: message.Content})
+		}
+	}
+
+	for _, part := range message.Parts {
+		switch part.Type {
+		case "text":
+			parts = append(parts, Part{Text: part.Text})
+		case "image_url":
+			if mediaType, data, ok := adapters.ParseDataURL(part.ImageURL.URL); ok {
+				parts = append(parts, Part{InlineData: &Blob{MimeType: mediaType, Data: data}})
+				continue
+			}
+			var mimeType string
+			if u, err := url.Parse(part.ImageURL.URL); err == nil {
+				mimeType = mime.TypeByExtension(path.Ext(u.Path))
+			}
+			parts = append(parts, Part{FileData: &FileData{MimeType: mimeType, FileURI: part.ImageURL.URL}})
+		case "input_audio":
+			parts = append(parts, Part{InlineData: &Blob{MimeType: "audio/" + part.InputAudio.Format, Data: part.InputAudio.Data}})
+		default:
+			return nil, fmt.Errorf("Gemini does not support %s content", part.Type)
+		}
+	}
+
+	for _, call := range message.ToolCalls {
+		var args json.RawMessage
+		if strings.TrimSpace(call.Function.Arguments) != "" {
+			args = json.RawMessage(call.Function.Arguments)
+			if !json.Valid(args) {
+				return nil, fmt.Errorf("tool call %s has invalid JSON arguments", call.ID)
+			}
+		}
+		parts = append(parts, Part{FunctionCall: &FunctionCall{Name: call.Function.Name, Args: args}})
+	}
+
+	return parts, nil
+}
+
+// toToolConfig converts a tool choice into a function calling mode
+func toToolConfig(choice *adapters.ToolChoice) *ToolConfig {
+	if choice == nil {
+		return nil
+	}
+
+	config := &ToolConfig{}
+	switch {
+	case choice.Function != "":
+		config.FunctionCallingConfig.Mode = "ANY"
+		config.FunctionCallingConfig.AllowedFunctionNames = []string{choice.Function}
+	case choice.Mode == "required":
+		config.FunctionCallingConfig.Mode = "ANY"
+	case choice.Mode == "none":
+		config.FunctionCallingConfig.Mode = "NONE"
+	default:
+		config.FunctionCallingConfig.Mode = "AUTO"
+	}
+	return config
+}
+
+// toToolCalls converts the function call parts of a candidate into tool
+// calls. Gemini may not assign call IDs, so missing ones are numbered from
+// first.
+func toToolCalls(parts []Part, first int) []adapters.ToolCall {
+	var calls []adapters.ToolCall
+	for _, part := range parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+
+		id := part.FunctionCall.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", first+len(calls))
+		}
+
+		args := string(part.FunctionCall.Args)
+		if args == "" {
+			args = "{}"
+		}
+
+		calls = append(calls, adapters.ToolCall{
+			ID:       id,
+			Type:     "function",
+			Function: adapters.ToolCallFunction{Name: part.FunctionCall.Name, Arguments: args},
+		})
+	}
+	return calls
+}
+
+// checkSafety returns the safety detections of a response, or a
+// *SafetyError if the prompt or a candidate was blocked
+func checkSafety(resp *GenerateContentResponse) ([]detector.DetectionResult, error) {
+	detections := resp.PromptFeedback.SafetyRatings.Detections()
+	if resp.PromptFeedback.BlockReason != "" {
+		return nil, &SafetyError{Source: "prompt", Reason: resp.PromptFeedback.BlockReason, Detections: detections}
+	}
+
+	for _, candidate := range resp.Candidates {
+		detections = append(detections, candidate.SafetyRatings.Detections()...)
+		if finishReason(candidate.FinishReason) == "content_filter" {
+			return nil, &SafetyError{Source: "completion", Reason: candidate.FinishReason, Detections: detections}
+		}
+	}
+
+	return detections, nil
+}
+
+// modelPath returns the resource path of a model, e.g. models/gemini-1.5-flash
+func modelPath(modelID string) string {
+	if strings.Contains(modelID, "/") {
+		return modelID
+	}
+	return "models/" + modelID
+}
+
+// modelName returns the model that generated a response
+func modelName(resp *GenerateContentResponse, requested string) string {
+	if resp.ModelVersion != "" {
+		return resp.ModelVersion
+	}
+	return requested
+}
+
+// responseID returns the ID Gemini assigned to a response
+func responseID(resp *GenerateContentResponse) string {
+	if resp.ResponseID != "" {
+		return resp.ResponseID
+	}
+	return fmt.Sprintf("gemini-%d", time.Now().UnixNano())
+}
+
+// supports reports whether method is in methods
+func supports(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAPIError reads an error response into an *adapters.ProviderError.
+// Gemini sends the retry delay for quota errors in a RetryInfo detail
+// rather than a Retry-After header.
+func parseAPIError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			APIError
+			Details []struct {
+				Type       string `json:"@type"`
+				RetryDelay string `json:"retryDelay"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, &body); err != nil || body.Error.Message == "" {
+		body.Error.APIError = APIError{Status: "UNKNOWN", Message: strings.TrimSpace(string(data))}
+	}
+	apiErr := body.Error.APIError
+	apiErr.StatusCode = resp.StatusCode
+
+	providerErr := adapters.NewProviderError("gemini", resp, data, &apiErr)
+	providerErr.Code, providerErr.Message = apiErr.Status, apiErr.Message
+	for _, detail := range body.Error.Details {
+		if delay, err := time.ParseDuration(detail.RetryDelay); err == nil && providerErr.RetryAfter == 0 {
+			providerErr.RetryAfter = delay
+		}
+	}
+	return providerErr
+}
+
+// joinText concatenates the text parts
+func joinText(parts []Part) string {
+	var builder strings.Builder
+	for _, part := range parts {
+		builder.WriteString(part.Text)
+	}
+	return builder.String()
+}
+
+// finishReason maps a Gemini finish reason to an OpenAI finish reason
+func finishReason(reason string) string {
+	switch reason {
+	case "":
+		return ""
+	case "STOP":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+// toUsage converts Gemini usage metadata to adapter usage. Thinking tokens
+// are billed as output, so they count as completion tokens.
+func toUsage(usage *UsageMetadata) adapters.Usage {
+	if usage == nil {
+		return adapters.Usage{}
+	}
+
+	completion := usage.CandidatesTokenCount + usage.ThoughtsTokenCount
+	total := usage.TotalTokenCount
+	if total == 0 {
+		total = usage.PromptTokenCount + completion
+	}
+
+	return adapters.Usage{
+		PromptTokens:     usage.PromptTokenCount,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+	}
+}
@@ -0,0 +1,109 @@
+package gemini
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// SafetyRating is Gemini's verdict for one harm category
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// SafetyRatings are the ratings attached to a prompt or a candidate
+type SafetyRatings []SafetyRating
+
+// Detections converts the ratings into Sentinel detections, one per
+// category that was blocked or rated above negligible
+func (r SafetyRatings) Detections() []detector.DetectionResult {
+	var detections []detector.DetectionResult
+	for _, rating := range r {
+		score := probabilityScore(rating.Probability)
+		if score == 0 && !rating.Blocked {
+			continue
+		}
+
+		blocked := 0.0
+		recommendation := "Allow the request"
+		if rating.Blocked {
+			blocked = 1
+			recommendation = "Block the request"
+		}
+
+		detections = append(detections, detector.DetectionResult{
+			Score:          score,
+			Confidence:     1.0,
+			ViolationType:  violationType(rating.Category),
+			Details:        map[string]float64{"probability": score, "blocked": blocked},
+			Recommendation: recommendation,
+		})
+	}
+	return detections
+}
+
+// violationType names a harm category, e.g. HARM_CATEGORY_DANGEROUS_CONTENT
+// becomes safety_dangerous_content
+func violationType(category string) string {
+	return "safety_" + strings.ToLower(strings.TrimPrefix(category, "HARM_CATEGORY_"))
+}
+
+// probabilityScore maps a Gemini harm probability to a score in [0, 1]
+func probabilityScore(probability string) float64 {
+	switch probability {
+	case "LOW":
+		return 0.33
+	case "MEDIUM":
+		return 0.66
+	case "HIGH":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SafetyError is returned when Gemini blocks a prompt or stops a candidate
+// for safety. Detections describes the categories that triggered.
+type SafetyError struct {
+	// Source is "prompt" or "completion"
+	Source string
+	// Reason is Gemini's block or finish reason, e.g. SAFETY or PROHIBITED_CONTENT
+	Reason     string
+	Detections []detector.DetectionResult
+}
+
+// Error implements the error interface
+func (e *SafetyError) Error() string {
+	types := make([]string, len(e.Detections))
+	for i, detection := range e.Detections {
+		types[i] = detection.ViolationType
+	}
+
+	message := fmt.Sprintf("Gemini blocked the %s: %s", e.Source, e.Reason)
+	if len(types) > 0 {
+		message += " (" + strings.Join(types, ", ") + ")"
+	}
+	return message
+}
+
+// ViolationType returns the violation type of the first blocking detection
+func (e *SafetyError) ViolationType() string {
+	for _, detection := range e.Detections {
+		if detection.Details["blocked"] == 1 {
+			return detection.ViolationType
+		}
+	}
+	if len(e.Detections) > 0 {
+		return e.Detections[0].ViolationType
+	}
+	return "safety_" + strings.ToLower(e.Reason)
+}
+
+// Is reports whether target is adapters.ErrContentFiltered
+func (e *SafetyError) Is(target error) bool {
+	return target == adapters.ErrContentFiltered
+}
@@ -0,0 +1,139 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/sse"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// ContentStream converts streamGenerateContent chunks into chat completion chunks
+type ContentStream struct {
+	ctx        context.Context
+	adapter    *GeminiAdapter
+	model      string
+	response   *http.Response
+	reader     *sse.Reader
+	id         string
+	created    int64
+	detections []detector.DetectionResult
+	toolCalls  map[int]int
+	done       bool
+}
+
+// newContentStream creates a stream over a streamGenerateContent response
+func newContentStream(ctx context.Context, adapter *GeminiAdapter, model string, resp *http.Response) *ContentStream {
+	return &ContentStream{
+		ctx:       ctx,
+		adapter:   adapter,
+		model:     model,
+		response:  resp,
+		reader:    sse.NewReader(resp.Body),
+		created:   time.Now().Unix(),
+		toolCalls: make(map[int]int),
+	}
+}
+
+// Recv receives the next chunk. It returns io.EOF once the stream is
+// finished and a *SafetyError if the prompt or the candidate is blocked.
+func (s *ContentStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	for {
+		event, err := s.reader.ReadEvent()
+		if err == io.EOF {
+			return nil, s.finish()
+		}
+		if err != nil {
+			s.done = true
+			return nil, err
+		}
+		if event.Data == "" {
+			continue
+		}
+
+		var resp GenerateContentResponse
+		if err := json.Unmarshal([]byte(event.Data), &resp); err != nil {
+			s.done = true
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		detections, err := checkSafety(&resp)
+		if err != nil {
+			s.done = true
+			return nil, err
+		}
+		s.detections = append(s.detections, detections...)
+
+		if s.id == "" {
+			s.id = responseID(&resp)
+		}
+
+		choices := make([]adapters.StreamChoice, len(resp.Candidates))
+		for i, candidate := range resp.Candidates {
+			delta := adapters.Message{Role: "assistant", Content: joinText(candidate.Content.Parts)}
+
+			// Function calls arrive whole, numbered across the candidate's chunks
+			first := s.toolCalls[candidate.Index]
+			delta.ToolCalls = toToolCalls(candidate.Content.Parts, first)
+			for j := range delta.ToolCalls {
+				index := first + j
+				delta.ToolCalls[j].Index = &index
+			}
+			s.toolCalls[candidate.Index] += len(delta.ToolCalls)
+
+			choices[i] = adapters.StreamChoice{
+				Index:        candidate.Index,
+				Delta:        delta,
+				FinishReason: finishReason(candidate.FinishReason),
+			}
+			if s.toolCalls[candidate.Index] > 0 && choices[i].FinishReason == "stop" {
+				choices[i].FinishReason = "tool_calls"
+			}
+		}
+
+		// Chunks carrying only safety ratings or usage are not passed on
+		if len(choices) == 0 {
+			continue
+		}
+
+		chunk := &adapters.ChatCompletionStreamResponse{
+			ID:      s.id,
+			Object:  "chat.completion.chunk",
+			Created: s.created,
+			Model:   modelName(&resp, s.model),
+			Choices: choices,
+		}
+
+		// Usage metadata is cumulative, so only the final chunk's is reported
+		if resp.UsageMetadata != nil && choices[0].FinishReason != "" {
+			usage := toUsage(resp.UsageMetadata)
+			chunk.Usage = &usage
+		}
+
+		return chunk, nil
+	}
+}
+
+// Close closes the stream
+func (s *ContentStream) Close() error {
+	if s.response != nil && s.response.Body != nil {
+		return s.response.Body.Close()
+	}
+	return nil
+}
+
+// finish reports the collected detections and ends the stream
+func (s *ContentStream) finish() error {
+	s.done = true
+	s.adapter.reportDetections(s.ctx, s.model, s.detections)
+	return io.EOF
+}
@@ -0,0 +1,363 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Extra holds the JSON object fields a type does not model, so that they
+// survive decoding and re-encoding unchanged
+type Extra map[string]json.RawMessage
+
+// MarshalJSON encodes the request with its extra fields
+func (r ChatCompletionRequest) MarshalJSON() ([]byte, error) {
+	type request ChatCompletionRequest
+	return encodeWithExtra(request(r), r.Extra)
+}
+
+// UnmarshalJSON decodes the request and keeps unknown fields in Extra
+func (r *ChatCompletionRequest) UnmarshalJSON(data []byte) error {
+	type request ChatCompletionRequest
+	var decoded request
+	extra, err := decodeWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*r = ChatCompletionRequest(decoded)
+	r.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the response with its extra fields
+func (r ChatCompletionResponse) MarshalJSON() ([]byte, error) {
+	type response ChatCompletionResponse
+	return encodeWithExtra(response(r), r.Extra)
+}
+
+// UnmarshalJSON decodes the response and keeps unknown fields in Extra
+func (r *ChatCompletionResponse) UnmarshalJSON(data []byte) error {
+	type response ChatCompletionResponse
+	var decoded response
+	extra, err := decodeWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*r = ChatCompletionResponse(decoded)
+	r.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the request with its extra fields
+func (r EmbeddingRequest) MarshalJSON() ([]byte, error) {
+	type request EmbeddingRequest
+	return encodeWithExtra(request(r), r.Extra)
+}
+
+// UnmarshalJSON decodes the request and keeps unknown fields in Extra
+func (r *EmbeddingRequest) UnmarshalJSON(data []byte) error {
+	type request EmbeddingRequest
+	var decoded request
+	extra, err := decodeWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*r = EmbeddingRequest(decoded)
+	r.Extra = extra
+	return nil
+}
+
+// UnmarshalJSON decodes a string or an array of strings
+func (in *EmbeddingInput) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*in = EmbeddingInput{text}
+		return nil
+	}
+
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return fmt.Errorf("embedding input must be a string or an array of strings")
+	}
+	*in = texts
+	return nil
+}
+
+// wireMessage is the JSON form of a Message, whose content may be a
+// string, an array of parts or null
+type wireMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	Name       string          `json:"name,omitempty"`
+	ToolCalls  []ToolCall      `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+// MarshalJSON encodes the message. Parts are sent as a content array, and
+// assistant messages with only tool calls have null content.
+func (m Message) MarshalJSON() ([]byte, error) {
+	var content interface{} = m.Content
+	switch {
+	case m.Parts != nil:
+		content = m.Parts
+	case m.Content == "" && len(m.ToolCalls) > 0:
+		content = nil
+	}
+
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeWithExtra(wireMessage{
+		Role:       m.Role,
+		Content:    encoded,
+		Name:       m.Name,
+		ToolCalls:  m.ToolCalls,
+		ToolCallID: m.ToolCallID,
+	}, m.Extra)
+}
+
+// UnmarshalJSON decodes a message with string, array or null content and
+// keeps unknown fields in Extra
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var wire wireMessage
+	extra, err := decodeWithExtra(data, &wire)
+	if err != nil {
+		return err
+	}
+
+	*m = Message{
+		Role:       wire.Role,
+		Name:       wire.Name,
+		ToolCalls:  wire.ToolCalls,
+		ToolCallID: wire.ToolCallID,
+		Extra:      extra,
+	}
+
+	content := bytes.TrimSpace(wire.Content)
+	switch {
+	case len(content) == 0 || bytes.Equal(content, []byte("null")):
+	case content[0] == '[':
+		if err := json.Unmarshal(content, &m.Parts); err != nil {
+			return fmt.Errorf("invalid message content parts: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(content, &m.Content); err != nil {
+			return fmt.Errorf("invalid message content: %w", err)
+		}
+	}
+	return nil
+}
+
+// Text returns the message's text: Content, or its text parts joined
+// with newlines
+func (m *Message) Text() string {
+	if m.Parts == nil {
+		return m.Content
+	}
+
+	var texts []string
+	for _, part := range m.Parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// MarshalJSON encodes the content part with its extra fields
+func (p ContentPart) MarshalJSON() ([]byte, error) {
+	type part ContentPart
+	return encodeWithExtra(part(p), p.Extra)
+}
+
+// UnmarshalJSON decodes the content part and keeps unknown fields in Extra.
+// Image and audio parts without their payload are rejected.
+func (p *ContentPart) UnmarshalJSON(data []byte) error {
+	type part ContentPart
+	var decoded part
+	extra, err := decodeWithExtra(data, &decoded)
+	if err != nil {
+		return err
+	}
+	if decoded.Type == "image_url" && decoded.ImageURL == nil {
+		return fmt.Errorf("image_url content part has no image_url")
+	}
+	if decoded.Type == "input_audio" && decoded.InputAudio == nil {
+		return fmt.Errorf("input_audio content part has no input_audio")
+	}
+	*p = ContentPart(decoded)
+	p.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the tool choice as a mode string or a function object
+func (c ToolChoice) MarshalJSON() ([]byte, error) {
+	if c.Function == "" {
+		return json.Marshal(c.Mode)
+	}
+
+	choice := map[string]interface{}{
+		"type":     "function",
+		"function": map[string]string{"name": c.Function},
+	}
+	return json.Marshal(choice)
+}
+
+// UnmarshalJSON decodes a mode string or a function object
+func (c *ToolChoice) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*c = ToolChoice{}
+		return json.Unmarshal(data, &c.Mode)
+	}
+
+	var choice struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(data, &choice); err != nil {
+		return err
+	}
+	*c = ToolChoice{Function: choice.Function.Name}
+	return nil
+}
+
+// ParseDataURL splits a base64 data URL such as data:image/png;base64,...
+// into its media type and base64 data
+func ParseDataURL(url string) (mediaType, data string, ok bool) {
+	rest, found := strings.CutPrefix(url, "data:")
+	if !found {
+		return "", "", false
+	}
+
+	header, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+
+	mediaType, found = strings.CutSuffix(header, ";base64")
+	if !found {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
+
+// Instruction returns a system prompt asking for the requested format, for
+// providers without a native JSON mode. Text output needs no instruction.
+func (f *ResponseFormat) Instruction() string {
+	if f == nil {
+		return ""
+	}
+
+	switch f.Type {
+	case "json_object":
+		return "Respond only with a valid JSON object."
+	case "json_schema":
+		if f.JSONSchema == nil || len(f.JSONSchema.Schema) == 0 {
+			return "Respond only with a valid JSON object."
+		}
+		return "Respond only with a valid JSON object that matches this JSON schema:\n" + string(f.JSONSchema.Schema)
+	default:
+		return ""
+	}
+}
+
+// decodeWithExtra decodes data into v, a pointer to a struct without its
+// own UnmarshalJSON, and returns the object fields v has no field for
+func decodeWithExtra(data []byte, v interface{}) (Extra, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	known := jsonFields(reflect.TypeOf(v).Elem())
+	var extra Extra
+	for name, value := range fields {
+		if known[strings.ToLower(name)] {
+			continue
+		}
+		if extra == nil {
+			extra = make(Extra)
+		}
+		extra[name] = value
+	}
+	return extra, nil
+}
+
+// encodeWithExtra encodes v, a struct without its own MarshalJSON, and
+// appends the extra fields that v does not model, in name order
+func encodeWithExtra(v interface{}, extra Extra) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	known := jsonFields(reflect.TypeOf(v))
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		if !known[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	buf := bytes.NewBuffer(data[:len(data)-1])
+	for _, name := range names {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(extra[name])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// fieldCache maps struct types to their lowercased JSON field names
+var fieldCache sync.Map
+
+// jsonFields returns the lowercased JSON names of a struct's fields.
+// encoding/json matches names case-insensitively when decoding.
+func jsonFields(t reflect.Type) map[string]bool {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+			continue
+		case field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct:
+			for embedded := range jsonFields(field.Type) {
+				fields[embedded] = true
+			}
+			continue
+		case !field.IsExported():
+			continue
+		case name == "":
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = true
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,531 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+)
+
+// DefaultBaseURL is the address of a local Ollama server
+const DefaultBaseURL = "http://localhost:11434"
+
+// OllamaAdapter implements the LLMAdapter interface for the Ollama native API
+type OllamaAdapter struct {
+	baseURL    string
+	httpClient *http.Client
+	breakers   *circuitbreaker.Manager
+}
+
+// Ensure OllamaAdapter implements adapters.LLMAdapter and adapters.ModelLister
+var (
+	_ adapters.LLMAdapter  = (*OllamaAdapter)(nil)
+	_ adapters.ModelLister = (*OllamaAdapter)(nil)
+)
+
+// ChatRequest is an Ollama /api/chat request
+type ChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Tools    []adapters.Tool        `json:"tools,omitempty"`
+	Format   json.RawMessage        `json:"format,omitempty"`
+	Stream   bool                   `json:"stream"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+// Message is an Ollama chat message. Images are base64 encoded.
+type Message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+// ToolCall is a tool call made by the model, with object arguments
+type ToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// ChatResponse is an Ollama /api/chat response, or one line of a streamed response
+type ChatResponse struct {
+	Model           string    `json:"model"`
+	CreatedAt       time.Time `json:"created_at"`
+	Message         Message   `json:"message"`
+	Done            bool      `json:"done"`
+	DoneReason      string    `json:"done_reason"`
+	PromptEvalCount int       `json:"prompt_eval_count"`
+	EvalCount       int       `json:"eval_count"`
+	Error           string    `json:"error"`
+}
+
+// EmbedRequest is an Ollama /api/embed request
+type EmbedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+// EmbedResponse is an Ollama /api/embed response
+type EmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// NewOllamaAdapter creates a new Ollama adapter
+func NewOllamaAdapter(baseURL string, timeout time.Duration) *OllamaAdapter {
+	return &OllamaAdapter{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// NewAdapterFromSettings creates an Ollama adapter from registry settings:
+// baseUrl and timeout
+func NewAdapterFromSettings(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+	baseURL, err := adapters.StringSetting(settings, "baseUrl", DefaultBaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Local models can be slow to load, so allow more time by default
+	timeout, err := adapters.DurationSetting(settings, "timeout", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOllamaAdapter(baseURL, timeout), nil
+}
+
+// SetCircuitBreakers enables per-model circuit breaking
+func (oa *OllamaAdapter) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	oa.breakers = breakers
+}
+
+// ChatCompletion sends a chat completion request to Ollama
+func (oa *OllamaAdapter) ChatCompletion(ctx context.Context, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	chatReq, err := toChatRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := oa.send(ctx, "/api/chat", req.Model, chatReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp ChatResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("Ollama error: %s", resp.Error)
+	}
+
+	message := toMessage(&resp.Message, 0)
+
+	return &adapters.ChatCompletionResponse{
+		ID:      fmt.Sprintf("ollama-%d", resp.CreatedAt.UnixNano()),
+		Object:  "chat.completion",
+		Created: resp.CreatedAt.Unix(),
+		Model:   resp.Model,
+		Choices: []adapters.Choice{
+			{
+				Index:        0,
+				Message:      message,
+				FinishReason: finishReason(resp.DoneReason, len(message.ToolCalls) > 0),
+			},
+		},
+		Usage: usage(&resp),
+	}, nil
+}
+
+// ChatCompletionStream sends a streaming chat completion request to Ollama
+func (oa *OllamaAdapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	chatReq, err := toChatRequest(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := oa.send(ctx, "/api/chat", req.Model, chatReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChatStream{
+		response: httpResp,
+		decoder:  json.NewDecoder(httpResp.Body),
+		id:       fmt.Sprintf("ollama-%d", time.Now().UnixNano()),
+	}, nil
+}
+
+// Embed sends an embeddings request to /api/embed
+func (oa *OllamaAdapter) Embed(ctx context.Context, req *adapters.EmbeddingRequest) (*adapters.EmbeddingResponse, error) {
+	embedReq := EmbedRequest{Model: req.Model, Input: req.Input, Dimensions: req.Dimensions}
+
+	httpResp, err := oa.send(ctx, "/api/embed", req.Model, &embedReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp EmbedResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	data := make([]adapters.Embedding, len(resp.Embeddings))
+	for i, embedding := range resp.Embeddings {
+		data[i] = adapters.Embedding{Object: "embedding", Index: i, Embedding: embedding}
+	}
+
+	return &adapters.EmbeddingResponse{
+		Object: "list",
+		Data:   data,
+		Model:  resp.Model,
+		Usage:  adapters.Usage{PromptTokens: resp.PromptEvalCount, TotalTokens: resp.PromptEvalCount},
+	}, nil
+}
+
+// GetModelInfo gets information about a locally available model
+func (oa *OllamaAdapter) GetModelInfo(ctx context.Context, modelID string) (*adapters.ModelInfo, error) {
+	body, err := json.Marshal(map[string]string{"model": modelID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", oa.baseURL+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := oa.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, parseError(httpResp)
+	}
+
+	var show struct {
+		ModifiedAt time.Time `json:"modified_at"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&show); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &adapters.ModelInfo{
+		ID:      modelID,
+		Object:  "model",
+		Created: show.ModifiedAt.Unix(),
+		OwnedBy: "ollama",
+	}, nil
+}
+
+// ListModels lists the models pulled into the Ollama server
+func (oa *OllamaAdapter) ListModels(ctx context.Context) ([]adapters.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", oa.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	httpResp, err := oa.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, parseError(httpResp)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name       string    `json:"name"`
+			ModifiedAt time.Time `json:"modified_at"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	models := make([]adapters.ModelInfo, len(tags.Models))
+	for i, model := range tags.Models {
+		models[i] = adapters.ModelInfo{
+			ID:      model.Name,
+			Object:  "model",
+			Created: model.ModifiedAt.Unix(),
+			OwnedBy: "ollama",
+		}
+	}
+	return models, nil
+}
+
+// ValidateConfig validates the adapter configuration
+func (oa *OllamaAdapter) ValidateConfig() error {
+	if oa.baseURL == "" {
+		oa.baseURL = DefaultBaseURL
+	}
+	return nil
+}
+
+// GetCapabilities returns the adapter capabilities
+func (oa *OllamaAdapter) GetCapabilities() *adapters.AdapterCapabilities {
+	return &adapters.AdapterCapabilities{
+		Streaming:     true,
+		FunctionCalls: true,
+		Embeddings:    true,
+		ModelInfo:     true,
+		RateLimiting:  false,
+	}
+}
+
+// send posts a request for a model to an API path and returns the response
+// if it succeeded
+func (oa *OllamaAdapter) send(ctx context.Context, path, model string, req interface{}) (*http.Response, error) {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", oa.baseURL+path, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := oa.breakers.Do(oa.httpClient, httpReq, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		defer httpResp.Body.Close()
+		return nil, parseError(httpResp)
+	}
+
+	return httpResp, nil
+}
+
+// toChatRequest converts a chat completion request. Ollama has no tool
+// choice, so a tool choice of none sends no tools.
+func toChatRequest(req *adapters.ChatCompletionRequest, stream bool) (*ChatRequest, error) {
+	options := make(map[string]interface{})
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+
+	chatReq := &ChatRequest{
+		Model:   req.Model,
+		Stream:  stream,
+		Options: options,
+	}
+
+	// Tool results name the function they answer, not the call ID
+	functionNames := make(map[string]string)
+	for _, message := range req.Messages {
+		for _, call := range message.ToolCalls {
+			functionNames[call.ID] = call.Function.Name
+		}
+
+		converted, err := fromMessage(&message, functionNames)
+		if err != nil {
+			return nil, err
+		}
+		chatReq.Messages = append(chatReq.Messages, converted)
+	}
+
+	if req.ToolChoice == nil || req.ToolChoice.Mode != "none" {
+		chatReq.Tools = req.Tools
+	}
+
+	if format := req.ResponseFormat; format != nil {
+		switch {
+		case format.Type == "json_schema" && format.JSONSchema != nil && len(format.JSONSchema.Schema) > 0:
+			chatReq.Format = format.JSONSchema.Schema
+		case format.Type == "json_object" || format.Type == "json_schema":
+			chatReq.Format = json.RawMessage(`"json"`)
+		}
+	}
+
+	return chatReq, nil
+}
+
+// fromMessage converts a chat message. Ollama only accepts inline images,
+// so image URLs must be data URLs.
+func fromMessage(message *adapters.Message, functionNames map[string]string) (Message, error) {
+	converted := Message{Role: message.Role, Content: message.Text()}
+	if message.Role == "tool" {
+		converted.ToolName = functionNames[message.ToolCallID]
+	}
+
+	for _, part := range message.Parts {
+		switch part.Type {
+		case "text":
+		case "image_url":
+			_, data, ok := adapters.ParseDataURL(part.ImageURL.URL)
+			if !ok {
+				return Message{}, fmt.Errorf("Ollama only supports images as base64 data URLs")
+			}
+			converted.Images = append(converted.Images, data)
+		default:
+			return Message{}, fmt.Errorf("Ollama does not support %s content", part.Type)
+		}
+	}
+
+	for _, call := range message.ToolCalls {
+		var toolCall ToolCall
+		toolCall.Function.Name = call.Function.Name
+		toolCall.Function.Arguments = json.RawMessage(call.Function.Arguments)
+		if strings.TrimSpace(call.Function.Arguments) == "" {
+			toolCall.Function.Arguments = json.RawMessage("{}")
+		}
+		if !json.Valid(toolCall.Function.Arguments) {
+			return Message{}, fmt.Errorf("tool call %s has invalid JSON arguments", call.ID)
+		}
+		converted.ToolCalls = append(converted.ToolCalls, toolCall)
+	}
+
+	return converted, nil
+}
+
+// toMessage converts an Ollama message. Ollama does not assign call IDs, so
+// tool calls are numbered from first.
+func toMessage(message *Message, first int) adapters.Message {
+	converted := adapters.Message{Role: message.Role, Content: message.Content}
+	for i, call := range message.ToolCalls {
+		converted.ToolCalls = append(converted.ToolCalls, adapters.ToolCall{
+			ID:       fmt.Sprintf("call_%d", first+i),
+			Type:     "function",
+			Function: adapters.ToolCallFunction{Name: call.Function.Name, Arguments: string(call.Function.Arguments)},
+		})
+	}
+	return converted
+}
+
+// parseError reads an Ollama error response into an *adapters.ProviderError
+func parseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(data))
+	}
+
+	providerErr := adapters.NewProviderError("ollama", resp, data, fmt.Errorf("Ollama API returned status %d: %s", resp.StatusCode, body.Error))
+	providerErr.Message = body.Error
+	return providerErr
+}
+
+// finishReason maps an Ollama done reason to an OpenAI finish reason.
+// Ollama reports stop when the model called tools.
+func finishReason(doneReason string, toolCalls bool) string {
+	switch {
+	case toolCalls && (doneReason == "" || doneReason == "stop"):
+		return "tool_calls"
+	case doneReason == "":
+		return "stop"
+	default:
+		return doneReason
+	}
+}
+
+// usage converts Ollama evaluation counts to adapter usage
+func usage(resp *ChatResponse) adapters.Usage {
+	return adapters.Usage{
+		PromptTokens:     resp.PromptEvalCount,
+		CompletionTokens: resp.EvalCount,
+		TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+	}
+}
+
+// ChatStream reads a newline-delimited JSON chat stream
+type ChatStream struct {
+	response  *http.Response
+	decoder   *json.Decoder
+	id        string
+	toolCalls int
+	done      bool
+}
+
+// Recv receives the next chunk. It returns io.EOF after the final message
+// and an *adapters.ProviderError if Ollama reports an error mid-stream.
+func (s *ChatStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	var resp ChatResponse
+	if err := s.decoder.Decode(&resp); err != nil {
+		s.done = true
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+	}
+	if resp.Error != "" {
+		s.done = true
+		// Ollama's errors carry no type, so a failure after the response
+		// started counts as a server error
+		return nil, &adapters.ProviderError{
+			Provider: "ollama",
+			Kind:     adapters.ErrServerError,
+			Message:  resp.Error,
+			Err:      fmt.Errorf("Ollama error: %s", resp.Error),
+		}
+	}
+
+	chunk := &adapters.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: resp.CreatedAt.Unix(),
+		Model:   resp.Model,
+		Choices: []adapters.StreamChoice{{Delta: toMessage(&resp.Message, s.toolCalls)}},
+	}
+
+	// Tool calls arrive whole, numbered across the stream
+	for i := range chunk.Choices[0].Delta.ToolCalls {
+		index := s.toolCalls + i
+		chunk.Choices[0].Delta.ToolCalls[i].Index = &index
+	}
+	s.toolCalls += len(chunk.Choices[0].Delta.ToolCalls)
+
+	if resp.Done {
+		s.done = true
+		chunk.Choices[0].FinishReason = finishReason(resp.DoneReason, s.toolCalls > 0)
+		u := usage(&resp)
+		chunk.Usage = &u
+	}
+
+	return chunk, nil
+}
+
+// Close closes the stream
+func (s *ChatStream) Close() error {
+	if s.response != nil && s.response.Body != nil {
+		return s.response.Body.Close()
+	}
+	return nil
+}
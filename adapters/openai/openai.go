@@ -7,66 +7,52 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
 )
 
 // OpenAIAdapter implements the LLMAdapter interface for OpenAI
 type OpenAIAdapter struct {
 	apiKey     string
+	keyless    bool
 	baseURL    string
 	httpClient *http.Client
 	timeout    time.Duration
+	breakers   *circuitbreaker.Manager
 }
 
-// ChatCompletionRequest represents a chat completion request
-type ChatCompletionRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float32   `json:"temperature,omitempty"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	Stream      bool      `json:"stream,omitempty"`
-}
-
-// Message represents a chat message
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-// ChatCompletionResponse represents a chat completion response
-type ChatCompletionResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
-}
-
-// Choice represents a response choice
-type Choice struct {
-	Index        int     `json:"index"`
-	Message      Message `json:"message"`
-	FinishReason string  `json:"finish_reason"`
-}
+// The OpenAI adapter uses the shared adapter types; these aliases keep
+// existing callers of the openai package compiling
+type (
+	ChatCompletionRequest        = adapters.ChatCompletionRequest
+	Message                      = adapters.Message
+	ContentPart                  = adapters.ContentPart
+	Tool                         = adapters.Tool
+	ToolChoice                   = adapters.ToolChoice
+	ToolCall                     = adapters.ToolCall
+	ResponseFormat               = adapters.ResponseFormat
+	ChatCompletionResponse       = adapters.ChatCompletionResponse
+	Choice                       = adapters.Choice
+	Usage                        = adapters.Usage
+	ChatCompletionStreamResponse = adapters.ChatCompletionStreamResponse
+	StreamChoice                 = adapters.StreamChoice
+	EmbeddingRequest             = adapters.EmbeddingRequest
+	EmbeddingResponse            = adapters.EmbeddingResponse
+	ModelInfo                    = adapters.ModelInfo
+	AdapterCapabilities          = adapters.AdapterCapabilities
+)
 
-// Usage represents token usage information
-type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
-}
+// Ensure OpenAIAdapter implements adapters.LLMAdapter
+var _ adapters.LLMAdapter = (*OpenAIAdapter)(nil)
 
 // ChatCompletionStream represents a streaming chat completion
 type ChatCompletionStream struct {
 	response *http.Response
 	reader   *StreamReader
-}
-
-// StreamReader reads streaming responses
-type StreamReader struct {
-	reader io.Reader
-	buffer []byte
+	done     bool
 }
 
 // NewOpenAIAdapter creates a new OpenAI adapter
@@ -81,6 +67,57 @@ func NewOpenAIAdapter(apiKey, baseURL string, timeout time.Duration) *OpenAIAdap
 	}
 }
 
+// NewAdapterFromSettings creates an OpenAI adapter from registry settings:
+// apiKey or apiKeyEnv, baseUrl and timeout
+func NewAdapterFromSettings(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+	apiKey, err := adapters.SecretSetting(settings, "apiKey")
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := adapters.StringSetting(settings, "baseUrl", "https://api.openai.com/v1")
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := adapters.DurationSetting(settings, "timeout", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOpenAIAdapter(apiKey, baseURL, timeout), nil
+}
+
+// NewCompatibleAdapterFromSettings creates an adapter for self-hosted
+// OpenAI-compatible servers such as vLLM. It takes the same settings as
+// NewAdapterFromSettings, but the API key is optional.
+func NewCompatibleAdapterFromSettings(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+	baseURL, err := adapters.StringSetting(settings, "baseUrl", "")
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("baseUrl is required for OpenAI-compatible servers")
+	}
+
+	adapter, err := NewAdapterFromSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	adapter.(*OpenAIAdapter).keyless = true
+	return adapter, nil
+}
+
+// SetCircuitBreakers enables per-model circuit breaking for requests to OpenAI
+func (oa *OpenAIAdapter) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	oa.breakers = breakers
+}
+
+// do sends a request, failing fast when the model's circuit breaker is open
+func (oa *OpenAIAdapter) do(req *http.Request, model string) (*http.Response, error) {
+	return oa.breakers.Do(oa.httpClient, req, model)
+}
+
 // ChatCompletion sends a chat completion request to OpenAI
 func (oa *OpenAIAdapter) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
 	// Convert request to JSON
@@ -97,10 +134,10 @@ func (oa *OpenAIAdapter) ChatCompletion(ctx context.Context, req *ChatCompletion
 
 	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+oa.apiKey)
+	oa.setAuthorization(httpReq)
 
 	// Send request
-	httpResp, err := oa.httpClient.Do(httpReq)
+	httpResp, err := oa.do(httpReq, req.Model)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -108,7 +145,7 @@ func (oa *OpenAIAdapter) ChatCompletion(ctx context.Context, req *ChatCompletion
 
 	// Check status code
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenAI API returned status %d", httpResp.StatusCode)
+		return nil, parseAPIError(httpResp)
 	}
 
 	// Parse response
@@ -121,12 +158,13 @@ func (oa *OpenAIAdapter) ChatCompletion(ctx context.Context, req *ChatCompletion
 }
 
 // ChatCompletionStream sends a streaming chat completion request to OpenAI
-func (oa *OpenAIAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionStream, error) {
-	// Set streaming flag
-	req.Stream = true
+func (oa *OpenAIAdapter) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	// Set streaming flag without modifying the caller's request
+	streamReq := *req
+	streamReq.Stream = true
 
 	// Convert request to JSON
-	requestBody, err := json.Marshal(req)
+	requestBody, err := json.Marshal(&streamReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -139,37 +177,108 @@ func (oa *OpenAIAdapter) ChatCompletionStream(ctx context.Context, req *ChatComp
 
 	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+oa.apiKey)
+	oa.setAuthorization(httpReq)
 
 	// Send request
-	httpResp, err := oa.httpClient.Do(httpReq)
+	httpResp, err := oa.do(httpReq, req.Model)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	// Check status code
 	if httpResp.StatusCode != http.StatusOK {
-		httpResp.Body.Close()
-		return nil, fmt.Errorf("OpenAI API returned status %d", httpResp.StatusCode)
+		defer httpResp.Body.Close()
+		return nil, parseAPIError(httpResp)
 	}
 
 	// Create stream
-	stream := &ChatCompletionStream{
+	return &ChatCompletionStream{
 		response: httpResp,
-		reader: &StreamReader{
-			reader: httpResp.Body,
-			buffer: make([]byte, 0, 4096),
-		},
+		reader:   NewStreamReader(httpResp.Body),
+	}, nil
+}
+
+// Embed sends an embeddings request to OpenAI
+func (oa *OpenAIAdapter) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	// Embeddings are decoded as floats, so base64 encoding is never requested
+	embedReq := *req
+	embedReq.EncodingFormat = ""
+
+	// Convert request to JSON
+	requestBody, err := json.Marshal(&embedReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", oa.baseURL+"/embeddings", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
-	return stream, nil
+	// Set headers
+	httpReq.Header.Set("Content-Type", "application/json")
+	oa.setAuthorization(httpReq)
+
+	// Send request
+	httpResp, err := oa.do(httpReq, req.Model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	// Check status code
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, parseAPIError(httpResp)
+	}
+
+	// Parse response
+	var resp EmbeddingResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &resp, nil
 }
 
-// Recv receives the next chunk from the stream
+// Recv receives the next chunk from the stream. It returns io.EOF once the
+// stream is finished and an *adapters.ProviderError wrapping an *APIError if
+// OpenAI reports an error mid-stream.
 func (s *ChatCompletionStream) Recv() (*ChatCompletionStreamResponse, error) {
-	// This is a simplified implementation
-	// In a real implementation, we'd parse the SSE format
-	return nil, fmt.Errorf("streaming not fully implemented")
+	if s.done {
+		return nil, io.EOF
+	}
+
+	for {
+		event, err := s.reader.ReadEvent()
+		if err != nil {
+			s.done = true
+			return nil, err
+		}
+
+		// Events without data, such as keep-alives, carry no chunk
+		if event.Data == "" {
+			continue
+		}
+
+		if event.Data == "[DONE]" {
+			s.done = true
+			return nil, io.EOF
+		}
+
+		if apiErr := parseStreamError(event); apiErr != nil {
+			s.done = true
+			return nil, streamError(apiErr, []byte(event.Data))
+		}
+
+		var chunk ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			s.done = true
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		return &chunk, nil
+	}
 }
 
 // Close closes the stream
@@ -180,22 +289,6 @@ func (s *ChatCompletionStream) Close() error {
 	return nil
 }
 
-// ChatCompletionStreamResponse represents a streaming response chunk
-type ChatCompletionStreamResponse struct {
-	ID      string         `json:"id"`
-	Object  string         `json:"object"`
-	Created int64          `json:"created"`
-	Model   string         `json:"model"`
-	Choices []StreamChoice `json:"choices"`
-}
-
-// StreamChoice represents a streaming response choice
-type StreamChoice struct {
-	Index        int     `json:"index"`
-	Delta        Message `json:"delta"`
-	FinishReason string  `json:"finish_reason"`
-}
-
 // GetModelInfo gets information about a model
 func (oa *OpenAIAdapter) GetModelInfo(ctx context.Context, modelID string) (*ModelInfo, error) {
 	// Create HTTP request
@@ -205,7 +298,7 @@ func (oa *OpenAIAdapter) GetModelInfo(ctx context.Context, modelID string) (*Mod
 	}
 
 	// Set headers
-	httpReq.Header.Set("Authorization", "Bearer "+oa.apiKey)
+	oa.setAuthorization(httpReq)
 
 	// Send request
 	httpResp, err := oa.httpClient.Do(httpReq)
@@ -216,7 +309,7 @@ func (oa *OpenAIAdapter) GetModelInfo(ctx context.Context, modelID string) (*Mod
 
 	// Check status code
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenAI API returned status %d", httpResp.StatusCode)
+		return nil, parseAPIError(httpResp)
 	}
 
 	// Parse response
@@ -228,17 +321,69 @@ func (oa *OpenAIAdapter) GetModelInfo(ctx context.Context, modelID string) (*Mod
 	return &resp, nil
 }
 
-// ModelInfo represents model information
-type ModelInfo struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	OwnedBy string `json:"owned_by"`
+// ListModels lists the models served by the API
+func (oa *OpenAIAdapter) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	// Create HTTP request
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", oa.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Set headers
+	oa.setAuthorization(httpReq)
+
+	// Send request
+	httpResp, err := oa.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	// Check status code
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, parseAPIError(httpResp)
+	}
+
+	// Parse response
+	var resp struct {
+		Data []ModelInfo `json:"data"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return resp.Data, nil
+}
+
+// setAuthorization sets the bearer token, if one is configured
+func (oa *OpenAIAdapter) setAuthorization(req *http.Request) {
+	if oa.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+oa.apiKey)
+	}
+}
+
+// parseAPIError reads an error response into an *adapters.ProviderError
+func parseAPIError(resp *http.Response) error {
+	var body struct {
+		Error APIError `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, &body); err != nil || body.Error.Message == "" {
+		body.Error = APIError{Type: "api_error", Message: strings.TrimSpace(string(data))}
+	}
+	body.Error.StatusCode = resp.StatusCode
+
+	providerErr := adapters.NewProviderError("openai", resp, data, &body.Error)
+	providerErr.Code, providerErr.Message = body.Error.Type, body.Error.Message
+	if body.Error.Code != "" {
+		providerErr.Code = body.Error.Code
+	}
+	return providerErr
 }
 
 // ValidateConfig validates the adapter configuration
 func (oa *OpenAIAdapter) ValidateConfig() error {
-	if oa.apiKey == "" {
+	if oa.apiKey == "" && !oa.keyless {
 		return fmt.Errorf("API key is required")
 	}
 
@@ -259,12 +404,3 @@ func (oa *OpenAIAdapter) GetCapabilities() *AdapterCapabilities {
 		RateLimiting:  true,
 	}
 }
-
-// AdapterCapabilities represents adapter capabilities
-type AdapterCapabilities struct {
-	Streaming     bool `json:"streaming"`
-	FunctionCalls bool `json:"function_calls"`
-	Embeddings    bool `json:"embeddings"`
-	ModelInfo     bool `json:"model_info"`
-	RateLimiting  bool `json:"rate_limiting"`
-}
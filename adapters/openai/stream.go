@@ -0,0 +1,98 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/sse"
+)
+
+// StreamReader reads server-sent events from a text/event-stream body
+type StreamReader = sse.Reader
+
+// StreamEvent is a single server-sent event
+type StreamEvent = sse.Event
+
+// APIError is an error reported by the OpenAI API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+	Type       string `json:"type"`
+	Param      string `json:"param"`
+	Code       string `json:"code"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("OpenAI API returned status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+	}
+	if e.Type != "" {
+		return fmt.Sprintf("OpenAI API error (%s): %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("OpenAI API error: %s", e.Message)
+}
+
+// NewStreamReader creates a new server-sent event reader
+func NewStreamReader(r io.Reader) *StreamReader {
+	return sse.NewReader(r)
+}
+
+// parseStreamError returns the error carried by an event, or nil if the
+// event is a regular chunk
+func parseStreamError(event *StreamEvent) *APIError {
+	data := []byte(event.Data)
+	if event.Event != "error" && !bytes.Contains(data, []byte(`"error"`)) {
+		return nil
+	}
+
+	var payload struct {
+		Error *APIError `json:"error"`
+	}
+	if err := json.Unmarshal(data, &payload); err == nil && payload.Error != nil {
+		return payload.Error
+	}
+
+	if event.Event == "error" {
+		return &APIError{Message: event.Data}
+	}
+	return nil
+}
+
+// streamError wraps an error received mid-stream in an
+// *adapters.ProviderError, classified by its code or type since the response
+// status was already 200
+func streamError(apiErr *APIError, payload []byte) *adapters.ProviderError {
+	code := apiErr.Code
+	if code == "" {
+		code = apiErr.Type
+	}
+	return &adapters.ProviderError{
+		Provider: "openai",
+		Kind:     errorKind(apiErr),
+		Code:     code,
+		Message:  apiErr.Message,
+		Body:     payload,
+		Err:      apiErr,
+	}
+}
+
+// errorKind classifies an OpenAI error by its code, then its type. Errors
+// without either count as server errors.
+func errorKind(apiErr *APIError) error {
+	for _, name := range []string{apiErr.Code, apiErr.Type} {
+		switch {
+		case strings.HasPrefix(name, "rate_limit"), name == "insufficient_quota":
+			return adapters.ErrRateLimited
+		case name == "invalid_api_key", name == "authentication_error", name == "permission_error":
+			return adapters.ErrAuthentication
+		case name == "invalid_request_error", name == "context_length_exceeded", name == "model_not_found":
+			return adapters.ErrInvalidRequest
+		}
+	}
+	return adapters.ErrServerError
+}
@@ -0,0 +1,245 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrNoAdapter is returned when no adapter serves the requested model
+var ErrNoAdapter = errors.New("no adapter configured for model")
+
+// ErrNotSupported is returned when an adapter's provider does not offer an
+// operation, such as embeddings
+var ErrNotSupported = errors.New("operation not supported by provider")
+
+// Constructor builds an adapter from its settings
+type Constructor func(settings map[string]interface{}) (LLMAdapter, error)
+
+// Registry is an AdapterFactory that builds adapters from registered
+// constructors and resolves them by model name. It implements LLMAdapter
+// itself by forwarding each request to the adapter for its model.
+type Registry struct {
+	constructors map[string]Constructor
+	routes       []modelRoute
+	mutex        sync.RWMutex
+}
+
+// modelRoute maps a model pattern to an adapter
+type modelRoute struct {
+	pattern string
+	adapter LLMAdapter
+}
+
+// Ensure Registry implements AdapterFactory, LLMAdapter and ModelLister
+var (
+	_ AdapterFactory = (*Registry)(nil)
+	_ LLMAdapter     = (*Registry)(nil)
+	_ ModelLister    = (*Registry)(nil)
+)
+
+// NewRegistry creates a new, empty adapter registry
+func NewRegistry() *Registry {
+	return &Registry{
+		constructors: make(map[string]Constructor),
+	}
+}
+
+// Register registers the constructor for an adapter type
+func (r *Registry) Register(adapterType string, constructor Constructor) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.constructors[adapterType] = constructor
+}
+
+// CreateAdapter creates a new adapter from its configuration
+func (r *Registry) CreateAdapter(config *AdapterConfig) (LLMAdapter, error) {
+	r.mutex.RLock()
+	constructor, exists := r.constructors[config.Type]
+	r.mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unsupported adapter type: %s", config.Type)
+	}
+
+	adapter, err := constructor(config.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s adapter: %w", config.Type, err)
+	}
+	return adapter, nil
+}
+
+// AddAdapter creates an adapter and routes its configured models to it.
+// An adapter without models serves every model.
+func (r *Registry) AddAdapter(config *AdapterConfig) (LLMAdapter, error) {
+	adapter, err := r.CreateAdapter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	models := config.Models
+	if len(models) == 0 {
+		models = []string{"*"}
+	}
+	r.Route(adapter, models...)
+
+	return adapter, nil
+}
+
+// Route sends requests for the given model patterns to adapter
+func (r *Registry) Route(adapter LLMAdapter, patterns ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, pattern := range patterns {
+		r.routes = append(r.routes, modelRoute{pattern: pattern, adapter: adapter})
+	}
+}
+
+// Resolve returns the adapter for a model. Exact names win over prefix
+// patterns, longer prefixes win over shorter ones, and * is the fallback.
+// Among equal matches the first route added wins.
+func (r *Registry) Resolve(model string) (LLMAdapter, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var best LLMAdapter
+	bestScore := -1
+	for _, route := range r.routes {
+		score := matchModel(route.pattern, model)
+		if score > bestScore {
+			best = route.adapter
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoAdapter, model)
+	}
+	return best, nil
+}
+
+// Adapters returns every distinct routed adapter in the order added
+func (r *Registry) Adapters() []LLMAdapter {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	seen := make(map[LLMAdapter]bool)
+	var adapters []LLMAdapter
+	for _, route := range r.routes {
+		if !seen[route.adapter] {
+			seen[route.adapter] = true
+			adapters = append(adapters, route.adapter)
+		}
+	}
+	return adapters
+}
+
+// ChatCompletion sends a chat completion request to the adapter for its model
+func (r *Registry) ChatCompletion(ctx context.Context, req *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	adapter, err := r.Resolve(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.ChatCompletion(ctx, req)
+}
+
+// ChatCompletionStream sends a streaming chat completion request to the adapter for its model
+func (r *Registry) ChatCompletionStream(ctx context.Context, req *ChatCompletionRequest) (ChatCompletionStream, error) {
+	adapter, err := r.Resolve(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.ChatCompletionStream(ctx, req)
+}
+
+// Embed creates embeddings with the adapter for the request's model
+func (r *Registry) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	adapter, err := r.Resolve(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.Embed(ctx, req)
+}
+
+// GetModelInfo gets information about a model from the adapter serving it
+func (r *Registry) GetModelInfo(ctx context.Context, modelID string) (*ModelInfo, error) {
+	adapter, err := r.Resolve(modelID)
+	if err != nil {
+		return nil, err
+	}
+	return adapter.GetModelInfo(ctx, modelID)
+}
+
+// ListModels lists the models of every routed adapter that supports model
+// discovery. Models from adapters that fail are skipped and their errors
+// are returned alongside the rest.
+func (r *Registry) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var models []ModelInfo
+	var errs []error
+	for _, adapter := range r.Adapters() {
+		lister, ok := adapter.(ModelLister)
+		if !ok {
+			continue
+		}
+
+		listed, err := lister.ListModels(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		models = append(models, listed...)
+	}
+	return models, errors.Join(errs...)
+}
+
+// ValidateConfig validates every routed adapter
+func (r *Registry) ValidateConfig() error {
+	adapters := r.Adapters()
+	if len(adapters) == 0 {
+		return errors.New("no adapters configured")
+	}
+
+	var errs []error
+	for _, adapter := range adapters {
+		if err := adapter.ValidateConfig(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetCapabilities returns the capabilities supported by any routed adapter
+func (r *Registry) GetCapabilities() *AdapterCapabilities {
+	capabilities := &AdapterCapabilities{}
+	for _, adapter := range r.Adapters() {
+		c := adapter.GetCapabilities()
+		capabilities.Streaming = capabilities.Streaming || c.Streaming
+		capabilities.FunctionCalls = capabilities.FunctionCalls || c.FunctionCalls
+		capabilities.Embeddings = capabilities.Embeddings || c.Embeddings
+		capabilities.ModelInfo = capabilities.ModelInfo || c.ModelInfo
+		capabilities.RateLimiting = capabilities.RateLimiting || c.RateLimiting
+	}
+	return capabilities
+}
+
+// matchModel scores how specifically pattern matches model, or returns -1
+// if it does not match
+func matchModel(pattern, model string) int {
+	switch {
+	case pattern == "*":
+		return 0
+	case strings.HasSuffix(pattern, "*"):
+		prefix := strings.TrimSuffix(pattern, "*")
+		if strings.HasPrefix(model, prefix) {
+			return 1 + len(prefix)
+		}
+	case pattern == model:
+		// Exact matches beat any prefix
+		return 1 << 30
+	}
+	return -1
+}
@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting looks up a key in adapter settings. Config loaders such as viper
+// lowercase map keys, so the lookup falls back to a case-insensitive match.
+func setting(settings map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := settings[key]; ok {
+		return value, true
+	}
+	for k, value := range settings {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// StringSetting returns a string setting or def if it is not set
+func StringSetting(settings map[string]interface{}, key, def string) (string, error) {
+	value, ok := setting(settings, key)
+	if !ok || value == nil {
+		return def, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("setting %s must be a string, got %T", key, value)
+	}
+	return str, nil
+}
+
+// IntSetting returns an integer setting or def if it is not set
+func IntSetting(settings map[string]interface{}, key string, def int) (int, error) {
+	value, ok := setting(settings, key)
+	if !ok || value == nil {
+		return def, nil
+	}
+
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("setting %s must be a whole number, got %v", key, v)
+		}
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("setting %s is not a valid integer: %w", key, err)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("setting %s must be an integer, got %T", key, value)
+	}
+}
+
+// StringMapSetting returns a map of strings, such as model to deployment
+// names, or nil if it is not set
+func StringMapSetting(settings map[string]interface{}, key string) (map[string]string, error) {
+	value, ok := setting(settings, key)
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	result := make(map[string]string)
+	switch v := value.(type) {
+	case map[string]string:
+		for k, item := range v {
+			result[k] = item
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("setting %s.%s must be a string, got %T", key, k, item)
+			}
+			result[k] = str
+		}
+	default:
+		return nil, fmt.Errorf("setting %s must be a map, got %T", key, value)
+	}
+	return result, nil
+}
+
+// DurationSetting returns a duration setting or def if it is not set.
+// Strings are parsed with time.ParseDuration and numbers are read as seconds.
+func DurationSetting(settings map[string]interface{}, key string, def time.Duration) (time.Duration, error) {
+	value, ok := setting(settings, key)
+	if !ok || value == nil {
+		return def, nil
+	}
+
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("setting %s is not a valid duration: %w", key, err)
+		}
+		return duration, nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case int64:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("setting %s must be a duration, got %T", key, value)
+	}
+}
+
+// SecretSetting returns a secret given either directly under key or through
+// an environment variable named by keyEnv, e.g. apiKey or apiKeyEnv
+func SecretSetting(settings map[string]interface{}, key string) (string, error) {
+	secret, err := StringSetting(settings, key, "")
+	if err != nil || secret != "" {
+		return secret, err
+	}
+
+	envVar, err := StringSetting(settings, key+"Env", "")
+	if err != nil || envVar == "" {
+		return "", err
+	}
+	return os.Getenv(envVar), nil
+}
+
+// MapSetting returns a nested settings map, such as the configuration of a
+// wrapped adapter, or nil if it is not set
+func MapSetting(settings map[string]interface{}, key string) (map[string]interface{}, error) {
+	value, ok := setting(settings, key)
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[fmt.Sprint(k)] = item
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("setting %s must be a map, got %T", key, value)
+	}
+}
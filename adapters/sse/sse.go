@@ -0,0 +1,84 @@
+// Package sse reads server-sent event streams as used by LLM provider
+// streaming APIs
+package sse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reader reads server-sent events from a text/event-stream body
+type Reader struct {
+	reader *bufio.Reader
+}
+
+// Event is a single server-sent event
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// NewReader creates a new server-sent event reader
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		reader: bufio.NewReaderSize(r, 4096),
+	}
+}
+
+// ReadEvent reads the next event. Multiple data lines are joined with a
+// newline and comment lines are skipped. It returns io.EOF when the stream
+// ends without a pending event.
+func (r *Reader) ReadEvent() (*Event, error) {
+	event := &Event{}
+	var data []string
+	pending := false
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+		eof := err == io.EOF
+
+		line = strings.TrimRight(line, "\r\n")
+
+		// A blank line, or the end of the stream, dispatches the event
+		if line == "" {
+			if pending {
+				event.Data = strings.Join(data, "\n")
+				return event, nil
+			}
+			if eof {
+				return nil, io.EOF
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(line, ":") {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+
+			switch field {
+			case "data":
+				data = append(data, value)
+				pending = true
+			case "event":
+				event.Event = value
+				pending = true
+			case "id":
+				event.ID = value
+			}
+		}
+
+		if eof {
+			if pending {
+				event.Data = strings.Join(data, "\n")
+				return event, nil
+			}
+			return nil, io.EOF
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets every call through and counts failures
+	StateClosed State = iota
+	// StateOpen rejects every call until the open timeout elapses
+	StateOpen
+	// StateHalfOpen lets a limited number of trial calls through
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
+// Settings configures when a breaker trips and recovers
+type Settings struct {
+	// Window is the length of the window over which the error rate is measured
+	Window time.Duration `mapstructure:"window"`
+	// MinRequests is the number of calls in a window before the breaker can trip
+	MinRequests int `mapstructure:"minRequests"`
+	// ErrorRateThreshold trips the breaker when the failure ratio reaches it
+	ErrorRateThreshold float64 `mapstructure:"errorRateThreshold"`
+	// LatencyThreshold counts calls slower than this as failures; zero disables it
+	LatencyThreshold time.Duration `mapstructure:"latencyThreshold"`
+	// OpenTimeout is how long the breaker stays open before allowing trial calls
+	OpenTimeout time.Duration `mapstructure:"openTimeout"`
+	// HalfOpenRequests is the number of successful trial calls needed to close again
+	HalfOpenRequests int `mapstructure:"halfOpenRequests"`
+}
+
+// DefaultSettings returns conservative breaker settings
+func DefaultSettings() Settings {
+	return Settings{
+		Window:             time.Minute,
+		MinRequests:        10,
+		ErrorRateThreshold: 0.5,
+		OpenTimeout:        30 * time.Second,
+		HalfOpenRequests:   1,
+	}
+}
+
+// OpenError is returned when a call is rejected by an open breaker
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open", e.Name)
+}
+
+// StateObserver receives breaker state changes. admin.ObservabilityManager
+// implements it.
+type StateObserver interface {
+	RecordCircuitStateChange(ctx context.Context, name, from, to string)
+}
+
+// Breaker is a closed/open/half-open circuit breaker
+type Breaker struct {
+	name     string
+	settings Settings
+	observer StateObserver
+
+	state       State
+	openedAt    time.Time
+	windowStart time.Time
+	requests    int
+	failures    int
+	trials      int
+	successes   int
+	mutex       sync.Mutex
+}
+
+// NewBreaker creates a new closed circuit breaker. A non-positive Window or
+// ErrorRateThreshold takes its DefaultSettings value, and a non-positive
+// MinRequests or HalfOpenRequests becomes one. observer may be nil.
+func NewBreaker(name string, settings Settings, observer StateObserver) *Breaker {
+	defaults := DefaultSettings()
+	if settings.Window <= 0 {
+		settings.Window = defaults.Window
+	}
+	if settings.ErrorRateThreshold <= 0 {
+		settings.ErrorRateThreshold = defaults.ErrorRateThreshold
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = 1
+	}
+
+	return &Breaker{
+		name:        name,
+		settings:    settings,
+		observer:    observer,
+		state:       StateClosed,
+		windowStart: time.Now(),
+	}
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by exactly one call to Record.
+func (b *Breaker) Allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+
+	switch b.state {
+	case StateOpen:
+		remaining := b.settings.OpenTimeout - now.Sub(b.openedAt)
+		if remaining > 0 {
+			return &OpenError{Name: b.name, RetryAfter: remaining}
+		}
+		b.transition(StateHalfOpen, now)
+		fallthrough
+	case StateHalfOpen:
+		if b.trials >= b.settings.HalfOpenRequests {
+			return &OpenError{Name: b.name, RetryAfter: b.settings.OpenTimeout}
+		}
+		b.trials++
+	}
+
+	return nil
+}
+
+// Record records the outcome and latency of an allowed call
+func (b *Breaker) Record(success bool, latency time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+
+	if b.settings.LatencyThreshold > 0 && latency > b.settings.LatencyThreshold {
+		success = false
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		if !success {
+			b.transition(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			b.transition(StateClosed, now)
+		}
+	case StateClosed:
+		if now.Sub(b.windowStart) >= b.settings.Window {
+			b.resetWindow(now)
+		}
+
+		b.requests++
+		if !success {
+			b.failures++
+		}
+
+		if b.requests >= b.settings.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.settings.ErrorRateThreshold {
+			b.transition(StateOpen, now)
+		}
+	}
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.state
+}
+
+// Name returns the breaker name
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// transition moves the breaker to a new state and notifies the observer
+func (b *Breaker) transition(to State, now time.Time) {
+	from := b.state
+	b.state = to
+	b.trials = 0
+	b.successes = 0
+
+	switch to {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.resetWindow(now)
+	}
+
+	if b.observer != nil && from != to {
+		b.observer.RecordCircuitStateChange(context.Background(), b.name, from.String(), to.String())
+	}
+}
+
+// resetWindow starts a new error rate window
+func (b *Breaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
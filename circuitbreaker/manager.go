@@ -0,0 +1,78 @@
+package circuitbreaker
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Manager keeps one breaker per upstream and model
+type Manager struct {
+	settings Settings
+	observer StateObserver
+	breakers map[string]*Breaker
+	mutex    sync.Mutex
+}
+
+// NewManager creates a new breaker manager. observer may be nil.
+func NewManager(settings Settings, observer StateObserver) *Manager {
+	return &Manager{
+		settings: settings,
+		observer: observer,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Get returns the breaker for an upstream and model, creating it if needed
+func (m *Manager) Get(upstream, model string) *Breaker {
+	name := upstream
+	if model != "" {
+		name = upstream + "/" + model
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	breaker, exists := m.breakers[name]
+	if !exists {
+		breaker = NewBreaker(name, m.settings, m.observer)
+		m.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// States returns the state of every breaker by name
+func (m *Manager) States() map[string]string {
+	m.mutex.Lock()
+	breakers := make([]*Breaker, 0, len(m.breakers))
+	for _, breaker := range m.breakers {
+		breakers = append(breakers, breaker)
+	}
+	m.mutex.Unlock()
+
+	states := make(map[string]string, len(breakers))
+	for _, breaker := range breakers {
+		states[breaker.Name()] = breaker.State().String()
+	}
+	return states
+}
+
+// Do sends req with client through the breaker for its host and model. It
+// fails fast with an *OpenError while the breaker is open. A nil manager
+// sends the request directly.
+func (m *Manager) Do(client *http.Client, req *http.Request, model string) (*http.Response, error) {
+	if m == nil {
+		return client.Do(req)
+	}
+
+	breaker := m.Get(req.URL.Host, model)
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	breaker.Record(err == nil && resp.StatusCode < http.StatusInternalServerError, time.Since(start))
+
+	return resp, err
+}
@@ -0,0 +1,353 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/detectors"
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/redaction"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/router"
+	"github.com/sentinel-platform/sentinel/tokenizer"
+)
+
+// Gateway runs chat completion requests through the CipherMesh and Sentinel
+// pipeline before forwarding them to an LLM provider
+type Gateway struct {
+	detectors  *detectors.DetectorManager
+	redactor   *redaction.Redactor
+	violations *detector.ViolationDetector
+	router     *router.Router
+	adapter    adapters.LLMAdapter
+	actions    map[string]string
+	tokenizer  *tokenizer.Tokenizer
+}
+
+// PolicyError is returned when the router refuses to forward a request
+type PolicyError struct {
+	Action          string
+	Reason          string
+	ViolationType   string
+	Recommendations []string
+}
+
+// Error implements the error interface
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("request %s by policy: %s", pastTense(e.Action), e.Reason)
+}
+
+// ProviderError wraps a failure returned by the upstream LLM provider
+type ProviderError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("provider request failed: %v", e.Err)
+}
+
+// Unwrap returns the underlying provider error
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// NewGateway creates a new gateway. actions maps data classes (pii, phi, pci,
+// credentials, generic) to redaction action types.
+func NewGateway(
+	detectorManager *detectors.DetectorManager,
+	redactor *redaction.Redactor,
+	violationDetector *detector.ViolationDetector,
+	router *router.Router,
+	adapter adapters.LLMAdapter,
+	actions map[string]string) *Gateway {
+
+	if actions == nil {
+		actions = make(map[string]string)
+	}
+
+	return &Gateway{
+		detectors:  detectorManager,
+		redactor:   redactor,
+		violations: violationDetector,
+		router:     router,
+		adapter:    adapter,
+		actions:    actions,
+	}
+}
+
+// SetTokenizer enables local token counting: requests are checked against
+// their model's limits before they are forwarded, and usage the provider
+// does not report is estimated
+func (g *Gateway) SetTokenizer(t *tokenizer.Tokenizer) {
+	g.tokenizer = t
+}
+
+// ChatCompletion detects and redacts sensitive data, scores the prompt for
+// violations, routes it according to policy, forwards it to the provider and
+// post-processes the response
+func (g *Gateway) ChatCompletion(ctx context.Context, tenantID string, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	upstreamReq, tokens, decision, err := g.prepare(ctx, tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.adapter.ChatCompletion(ctx, upstreamReq)
+	if err != nil {
+		return nil, &ProviderError{Err: err}
+	}
+
+	g.estimateUsage(upstreamReq, resp)
+
+	// Post-process the response
+	if err := g.processResponse(resp, tokens, g.router.ShouldEncrypt(decision)); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ChatCompletionStream runs the same pipeline as ChatCompletion but streams
+// the response. Tokens are restored as chunks arrive. Responses the router
+// asks to encrypt are generated in full and returned as a single chunk.
+func (g *Gateway) ChatCompletionStream(ctx context.Context, tenantID string, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	upstreamReq, tokens, decision, err := g.prepare(ctx, tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.router.ShouldEncrypt(decision) {
+		upstreamReq.Stream = false
+
+		resp, err := g.adapter.ChatCompletion(ctx, upstreamReq)
+		if err != nil {
+			return nil, &ProviderError{Err: err}
+		}
+		g.estimateUsage(upstreamReq, resp)
+		if err := g.processResponse(resp, tokens, true); err != nil {
+			return nil, err
+		}
+
+		stream := newCompletedStream(resp)
+		if includeUsage(req) && resp.Usage.TotalTokens > 0 {
+			stream.chunk.Usage = &resp.Usage
+		}
+		return stream, nil
+	}
+
+	upstreamReq.Stream = true
+	stream, err := g.adapter.ChatCompletionStream(ctx, upstreamReq)
+	if err != nil {
+		return nil, &ProviderError{Err: err}
+	}
+
+	if len(tokens) > 0 {
+		stream = newRehydratingStream(stream, tokens)
+	}
+	return g.withUsage(req, upstreamReq, stream), nil
+}
+
+// estimateUsage fills in usage the provider did not report. It must run
+// before the response is post-processed, while it still holds what the
+// provider generated.
+func (g *Gateway) estimateUsage(upstreamReq *adapters.ChatCompletionRequest, resp *adapters.ChatCompletionResponse) {
+	if g.tokenizer == nil || resp.Usage.TotalTokens > 0 {
+		return
+	}
+	resp.Usage.PromptTokens = g.tokenizer.CountRequest(upstreamReq)
+	resp.Usage.CompletionTokens = g.tokenizer.CountCompletion(resp)
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+}
+
+// withUsage adds a usage chunk to the end of a stream when the client asked
+// for one with stream_options.include_usage and the provider did not send it
+func (g *Gateway) withUsage(req, upstreamReq *adapters.ChatCompletionRequest, stream adapters.ChatCompletionStream) adapters.ChatCompletionStream {
+	if g.tokenizer == nil || !includeUsage(req) {
+		return stream
+	}
+	return newUsageStream(stream, g.tokenizer, upstreamReq)
+}
+
+// Embed detects and redacts sensitive data in each input before forwarding
+// it to the provider. Sensitive values are replaced deterministically, so a
+// redacted query still retrieves documents that were redacted the same way.
+func (g *Gateway) Embed(ctx context.Context, tenantID string, req *adapters.EmbeddingRequest) (*adapters.EmbeddingResponse, error) {
+	// Tenant dictionaries apply to the tenant's inputs
+	detectCtx := detectors.WithTenant(ctx, tenantID)
+
+	inputs := make(adapters.EmbeddingInput, len(req.Input))
+	for i, input := range req.Input {
+		redacted, err := g.redactWith(detectCtx, input, map[string]string{}, map[string]int{}, g.embeddingActionFor)
+		if err != nil {
+			return nil, err
+		}
+		inputs[i] = redacted
+	}
+
+	// Forward a copy of the request with the redacted inputs
+	upstreamReq := *req
+	upstreamReq.Input = inputs
+
+	resp, err := g.adapter.Embed(ctx, &upstreamReq)
+	if err != nil {
+		return nil, &ProviderError{Err: err}
+	}
+
+	if g.tokenizer != nil && resp.Usage.TotalTokens == 0 {
+		for _, input := range inputs {
+			resp.Usage.PromptTokens += g.tokenizer.CountText(req.Model, input)
+		}
+		resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	}
+	return resp, nil
+}
+
+// ListModels lists the models available from the configured adapter, or
+// nothing if it does not support model discovery
+func (g *Gateway) ListModels(ctx context.Context) ([]adapters.ModelInfo, error) {
+	lister, ok := g.adapter.(adapters.ModelLister)
+	if !ok {
+		return nil, nil
+	}
+	return lister.ListModels(ctx)
+}
+
+// prepare redacts the request, scores it and routes it. It returns the
+// request to forward, the tokens to restore in the response and the routing
+// decision, or a *PolicyError if the request must not be forwarded and a
+// *tokenizer.LimitError if it exceeds its model's limits.
+func (g *Gateway) prepare(ctx context.Context, tenantID string, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionRequest, map[string]string, *router.RouteDecision, error) {
+	// Detect and redact sensitive data in every message, including the
+	// tenant's dictionary terms
+	messages, tokens, classes, err := g.redactMessages(detectors.WithTenant(ctx, tenantID), req.Messages)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Score the redacted prompt so raw sensitive data never reaches the detector stores
+	prompt := joinMessages(messages)
+	detection, err := g.violations.Detect(ctx, prompt)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to detect violations: %w", err)
+	}
+
+	// Decide what to do with the request
+	decision, err := g.router.Route(ctx, &router.RoutingInput{
+		TenantID:       tenantID,
+		Model:          req.Model,
+		Prompt:         prompt,
+		DetectionScore: detection.Score,
+		DataClasses:    classes,
+		Context: map[string]interface{}{
+			"violation_type": detection.ViolationType,
+		},
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to route request: %w", err)
+	}
+
+	if g.router.ShouldBlock(decision) || g.router.ShouldReframe(decision) {
+		return nil, nil, nil, &PolicyError{
+			Action:          decision.Action,
+			Reason:          decision.Reason,
+			ViolationType:   detection.ViolationType,
+			Recommendations: g.router.GetRecommendedActions(decision),
+		}
+	}
+
+	// Forward a copy of the request with the redacted messages
+	upstreamReq := *req
+	upstreamReq.Messages = messages
+	if g.router.ShouldRouteInternally(decision) {
+		upstreamReq.Model = decision.Model
+	}
+
+	// Check limits against the model that will serve the request
+	if g.tokenizer != nil {
+		if _, err := g.tokenizer.Check(&upstreamReq); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	return &upstreamReq, tokens, decision, nil
+}
+
+// processResponse restores tokens created for this request and, when the
+// router asked for containment, encrypts the generated content. Encrypted
+// tool call arguments are sent as a JSON string so they remain valid JSON.
+func (g *Gateway) processResponse(resp *adapters.ChatCompletionResponse, tokens map[string]string, encrypt bool) error {
+	rehydrator := newRehydrator(tokens)
+	jsonRehydrator := newJSONRehydrator(tokens)
+
+	contain := func(text string) (string, error) {
+		text = rehydrator.Replace(text)
+		if !encrypt || text == "" {
+			return text, nil
+		}
+
+		encrypted, err := g.redactor.Redact(text, redaction.RedactionAction{Type: "encrypt"})
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt response: %w", err)
+		}
+		return encrypted, nil
+	}
+
+	for i := range resp.Choices {
+		message := &resp.Choices[i].Message
+
+		content, err := contain(message.Content)
+		if err != nil {
+			return err
+		}
+		message.Content = content
+
+		for j := range message.Parts {
+			if message.Parts[j].Type != "text" {
+				continue
+			}
+			if message.Parts[j].Text, err = contain(message.Parts[j].Text); err != nil {
+				return err
+			}
+		}
+
+		for j := range message.ToolCalls {
+			function := &message.ToolCalls[j].Function
+			function.Arguments = jsonRehydrator.Replace(function.Arguments)
+			if !encrypt {
+				continue
+			}
+
+			encrypted, err := g.redactor.Redact(function.Arguments, redaction.RedactionAction{Type: "encrypt"})
+			if err != nil {
+				return fmt.Errorf("failed to encrypt tool call: %w", err)
+			}
+			arguments, _ := json.Marshal(encrypted)
+			function.Arguments = string(arguments)
+		}
+	}
+
+	return nil
+}
+
+// joinMessages concatenates message contents for violation scoring
+func joinMessages(messages []adapters.Message) string {
+	parts := make([]string, 0, len(messages))
+	for _, message := range messages {
+		parts = append(parts, message.Text())
+	}
+	return strings.Join(parts, "\n")
+}
+
+// pastTense returns the past tense of a router action for error messages
+func pastTense(action string) string {
+	switch action {
+	case "block":
+		return "blocked"
+	case "reframe":
+		return "requires reframing"
+	default:
+		return action
+	}
+}
@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/redaction"
+)
+
+// redactMessages redacts sensitive data in each message's text, text parts
+// and tool call arguments. It returns the redacted messages, a map of
+// reversible replacements to original values and the number of items
+// detected in each data class. Images and audio are passed on unchanged.
+func (g *Gateway) redactMessages(ctx context.Context, messages []adapters.Message) ([]adapters.Message, map[string]string, map[string]int, error) {
+	redacted := make([]adapters.Message, len(messages))
+	tokens := make(map[string]string)
+	classes := make(map[string]int)
+
+	for i, message := range messages {
+		content, err := g.redactText(ctx, message.Content, tokens, classes)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		redacted[i] = message
+		redacted[i].Content = content
+
+		if message.Parts != nil {
+			redacted[i].Parts = make([]adapters.ContentPart, len(message.Parts))
+			for j, part := range message.Parts {
+				if part.Type == "text" {
+					if part.Text, err = g.redactText(ctx, part.Text, tokens, classes); err != nil {
+						return nil, nil, nil, err
+					}
+				}
+				redacted[i].Parts[j] = part
+			}
+		}
+
+		if message.ToolCalls != nil {
+			redacted[i].ToolCalls = make([]adapters.ToolCall, len(message.ToolCalls))
+			for j, call := range message.ToolCalls {
+				if call.Function.Arguments, err = g.redactText(ctx, call.Function.Arguments, tokens, classes); err != nil {
+					return nil, nil, nil, err
+				}
+				redacted[i].ToolCalls[j] = call
+			}
+		}
+	}
+
+	return redacted, tokens, classes, nil
+}
+
+// redactText replaces every detected item in text, records reversible
+// replacements in tokens and counts the items of each data class in classes
+func (g *Gateway) redactText(ctx context.Context, text string, tokens map[string]string, classes map[string]int) (string, error) {
+	return g.redactWith(ctx, text, tokens, classes, g.actionFor)
+}
+
+// redactWith replaces every detected item in text using the action chosen
+// for its data class, records reversible replacements in tokens and counts
+// the items of each data class in classes
+func (g *Gateway) redactWith(ctx context.Context, text string, tokens map[string]string, classes map[string]int, actionFor func(string) redaction.RedactionAction) (string, error) {
+	results, err := g.detectors.Detect(ctx, text)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect sensitive data: %w", err)
+	}
+
+	var builder strings.Builder
+	last := 0
+	// Detections do not overlap and are sorted by position
+	for _, result := range results {
+		classes[result.Type]++
+		action := actionFor(result.Type)
+
+		replacement, err := g.redactor.Redact(result.Text, action)
+		if err != nil {
+			return "", fmt.Errorf("failed to redact %s: %w", result.Subtype, err)
+		}
+
+		if action.Type == "tokenize" || action.Type == "encrypt" {
+			tokens[replacement] = result.Text
+		}
+
+		builder.WriteString(text[last:result.Start])
+		builder.WriteString(replacement)
+		last = result.End
+	}
+	builder.WriteString(text[last:])
+
+	return builder.String(), nil
+}
+
+// actionFor returns the configured redaction action for a data class
+func (g *Gateway) actionFor(dataClass string) redaction.RedactionAction {
+	actionType, ok := g.actions[dataClass]
+	if !ok {
+		actionType, ok = g.actions["generic"]
+	}
+	if !ok {
+		actionType = "mask"
+	}
+
+	// The redactor's FPE mode does not encrypt yet, so reversible
+	// tokenization is used until a real FF3-1 cipher is wired in
+	if actionType == "fpe" {
+		actionType = "tokenize"
+	}
+
+	return redaction.RedactionAction{Type: actionType}
+}
+
+// embeddingActionFor returns the redaction action for a data class in
+// embedding input. Embeddings are never rehydrated, and retrieval needs the
+// same value to be replaced the same way in every document and query, so
+// reversible actions become deterministic pseudonyms.
+func (g *Gateway) embeddingActionFor(dataClass string) redaction.RedactionAction {
+	action := g.actionFor(dataClass)
+	if action.Type == "tokenize" || action.Type == "encrypt" {
+		action.Type = "pseudonymize"
+	}
+	return action
+}
+
+// newRehydrator builds a replacer that restores original values for tokens
+func newRehydrator(tokens map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, len(tokens)*2)
+	for token, original := range tokens {
+		pairs = append(pairs, token, original)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// newJSONRehydrator builds a replacer that restores original values inside
+// JSON strings, such as tool call arguments, escaping them as needed
+func newJSONRehydrator(tokens map[string]string) *strings.Replacer {
+	pairs := make([]string, 0, len(tokens)*2)
+	for token, original := range tokens {
+		encoded, _ := json.Marshal(original)
+		pairs = append(pairs, token, string(encoded[1:len(encoded)-1]))
+	}
+	return strings.NewReplacer(pairs...)
+}
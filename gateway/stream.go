@@ -0,0 +1,342 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/tokenizer"
+)
+
+// rehydratingStream restores tokens in streamed deltas. A token can be split
+// across chunks, so text that could be the start of a token is held back
+// until the next chunk shows whether it is one. Content and the arguments
+// of each tool call are held back separately.
+type rehydratingStream struct {
+	stream         adapters.ChatCompletionStream
+	rehydrator     *strings.Replacer
+	jsonRehydrator *strings.Replacer
+	tokens         []string
+	pending        map[streamSlot]string
+	last           *adapters.ChatCompletionStreamResponse
+	done           bool
+}
+
+// streamSlot identifies streamed text: the content of a choice, or the
+// arguments of one of its tool calls
+type streamSlot struct {
+	choice   int
+	toolCall int // -1 for content
+}
+
+// newRehydratingStream wraps a provider stream
+func newRehydratingStream(stream adapters.ChatCompletionStream, tokens map[string]string) *rehydratingStream {
+	keys := make([]string, 0, len(tokens))
+	for token := range tokens {
+		keys = append(keys, token)
+	}
+
+	return &rehydratingStream{
+		stream:         stream,
+		rehydrator:     newRehydrator(tokens),
+		jsonRehydrator: newJSONRehydrator(tokens),
+		tokens:         keys,
+		pending:        make(map[streamSlot]string),
+	}
+}
+
+// Recv receives the next chunk with tokens restored
+func (s *rehydratingStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	chunk, err := s.stream.Recv()
+	if err == io.EOF {
+		s.done = true
+		if flushed := s.flush(); flushed != nil {
+			return flushed, nil
+		}
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.last = chunk
+
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		// Release everything once the choice has finished
+		finished := choice.FinishReason != ""
+
+		choice.Delta.Content = s.release(streamSlot{choice.Index, -1}, choice.Delta.Content, finished, s.rehydrator)
+		for j := range choice.Delta.ToolCalls {
+			call := &choice.Delta.ToolCalls[j]
+			slot := streamSlot{choice.Index, j}
+			if call.Index != nil {
+				slot.toolCall = *call.Index
+			}
+			call.Function.Arguments = s.release(slot, call.Function.Arguments, finished, s.jsonRehydrator)
+		}
+
+		if finished {
+			s.releaseToolCalls(choice)
+		}
+	}
+
+	return chunk, nil
+}
+
+// Close closes the underlying stream
+func (s *rehydratingStream) Close() error {
+	return s.stream.Close()
+}
+
+// release appends text to the slot's held-back text and returns the part
+// that can be sent, with tokens restored
+func (s *rehydratingStream) release(slot streamSlot, text string, all bool, rehydrator *strings.Replacer) string {
+	text = s.pending[slot] + text
+
+	hold := len(text)
+	if !all {
+		hold = s.partialTokenStart(text)
+	}
+
+	if hold < len(text) {
+		s.pending[slot] = text[hold:]
+	} else {
+		delete(s.pending, slot)
+	}
+	return rehydrator.Replace(text[:hold])
+}
+
+// releaseToolCalls adds held-back arguments of tool calls that are not in
+// a finished choice's final delta
+func (s *rehydratingStream) releaseToolCalls(choice *adapters.StreamChoice) {
+	for _, slot := range s.sortedSlots() {
+		if slot.choice != choice.Index {
+			continue
+		}
+
+		arguments := s.jsonRehydrator.Replace(s.pending[slot])
+		delete(s.pending, slot)
+
+		index := slot.toolCall
+		choice.Delta.ToolCalls = append(choice.Delta.ToolCalls, adapters.ToolCall{
+			Index:    &index,
+			Function: adapters.ToolCallFunction{Arguments: arguments},
+		})
+	}
+}
+
+// sortedSlots returns the slots with held-back text in choice and tool call order
+func (s *rehydratingStream) sortedSlots() []streamSlot {
+	slots := make([]streamSlot, 0, len(s.pending))
+	for slot := range s.pending {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		if slots[i].choice != slots[j].choice {
+			return slots[i].choice < slots[j].choice
+		}
+		return slots[i].toolCall < slots[j].toolCall
+	})
+	return slots
+}
+
+// partialTokenStart returns the offset of the longest suffix of text that is
+// a proper prefix of a token, or len(text) if there is none
+func (s *rehydratingStream) partialTokenStart(text string) int {
+	for start := 0; start < len(text); start++ {
+		suffix := text[start:]
+		for _, token := range s.tokens {
+			if len(suffix) < len(token) && strings.HasPrefix(token, suffix) {
+				return start
+			}
+		}
+	}
+	return len(text)
+}
+
+// flush returns a final chunk carrying text still held back when the
+// provider stream ended, or nil if nothing is pending
+func (s *rehydratingStream) flush() *adapters.ChatCompletionStreamResponse {
+	if len(s.pending) == 0 || s.last == nil {
+		return nil
+	}
+
+	chunk := &adapters.ChatCompletionStreamResponse{
+		ID:      s.last.ID,
+		Object:  s.last.Object,
+		Created: s.last.Created,
+		Model:   s.last.Model,
+	}
+
+	choices := make(map[int]int)
+	for _, slot := range s.sortedSlots() {
+		i, ok := choices[slot.choice]
+		if !ok {
+			i = len(chunk.Choices)
+			choices[slot.choice] = i
+			chunk.Choices = append(chunk.Choices, adapters.StreamChoice{Index: slot.choice})
+		}
+
+		delta := &chunk.Choices[i].Delta
+		if slot.toolCall < 0 {
+			delta.Content = s.rehydrator.Replace(s.pending[slot])
+			continue
+		}
+
+		index := slot.toolCall
+		delta.ToolCalls = append(delta.ToolCalls, adapters.ToolCall{
+			Index:    &index,
+			Function: adapters.ToolCallFunction{Arguments: s.jsonRehydrator.Replace(s.pending[slot])},
+		})
+	}
+	s.pending = make(map[streamSlot]string)
+
+	return chunk
+}
+
+// usageStream ends a stream with a usage chunk, as OpenAI does for
+// stream_options.include_usage, when the provider did not send usage itself.
+// Completion tokens are counted from the streamed deltas.
+type usageStream struct {
+	stream     adapters.ChatCompletionStream
+	tokenizer  *tokenizer.Tokenizer
+	req        *adapters.ChatCompletionRequest
+	completion strings.Builder
+	last       *adapters.ChatCompletionStreamResponse
+	reported   bool
+	done       bool
+}
+
+// newUsageStream wraps a stream of responses to req
+func newUsageStream(stream adapters.ChatCompletionStream, t *tokenizer.Tokenizer, req *adapters.ChatCompletionRequest) *usageStream {
+	return &usageStream{stream: stream, tokenizer: t, req: req}
+}
+
+// Recv receives the next chunk, then the usage chunk once the stream ends
+func (s *usageStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.done {
+		return nil, io.EOF
+	}
+
+	chunk, err := s.stream.Recv()
+	if err == io.EOF {
+		s.done = true
+		if s.reported {
+			return nil, io.EOF
+		}
+		return s.usageChunk(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.last = chunk
+	if chunk.Usage != nil {
+		s.reported = true
+	}
+	for _, choice := range chunk.Choices {
+		s.completion.WriteString(choice.Delta.Text())
+		for _, call := range choice.Delta.ToolCalls {
+			s.completion.WriteString(call.Function.Name)
+			s.completion.WriteString(call.Function.Arguments)
+		}
+	}
+	return chunk, nil
+}
+
+// Close closes the underlying stream
+func (s *usageStream) Close() error {
+	return s.stream.Close()
+}
+
+// usageChunk returns a chunk with no choices carrying the estimated usage
+func (s *usageStream) usageChunk() *adapters.ChatCompletionStreamResponse {
+	promptTokens := s.tokenizer.CountRequest(s.req)
+	completionTokens := s.tokenizer.CountText(s.req.Model, s.completion.String())
+
+	chunk := &adapters.ChatCompletionStreamResponse{
+		Object:  "chat.completion.chunk",
+		Model:   s.req.Model,
+		Choices: []adapters.StreamChoice{},
+		Usage: &adapters.Usage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		},
+	}
+	if s.last != nil {
+		chunk.ID, chunk.Created, chunk.Model = s.last.ID, s.last.Created, s.last.Model
+	}
+	return chunk
+}
+
+// includeUsage reports whether a request asks for a usage chunk at the end
+// of its stream
+func includeUsage(req *adapters.ChatCompletionRequest) bool {
+	var options struct {
+		IncludeUsage bool `json:"include_usage"`
+	}
+	raw, ok := req.Extra["stream_options"]
+	if !ok || json.Unmarshal(raw, &options) != nil {
+		return false
+	}
+	return options.IncludeUsage
+}
+
+// completedStream replays a complete response as a single chunk
+type completedStream struct {
+	chunk *adapters.ChatCompletionStreamResponse
+}
+
+// newCompletedStream converts a response into a one-chunk stream
+func newCompletedStream(resp *adapters.ChatCompletionResponse) *completedStream {
+	choices := make([]adapters.StreamChoice, len(resp.Choices))
+	for i, choice := range resp.Choices {
+		// Stream deltas identify tool calls by index
+		if calls := choice.Message.ToolCalls; calls != nil {
+			choice.Message.ToolCalls = make([]adapters.ToolCall, len(calls))
+			for j, call := range calls {
+				index := j
+				call.Index = &index
+				choice.Message.ToolCalls[j] = call
+			}
+		}
+
+		choices[i] = adapters.StreamChoice{
+			Index:        choice.Index,
+			Delta:        choice.Message,
+			FinishReason: choice.FinishReason,
+		}
+	}
+
+	return &completedStream{
+		chunk: &adapters.ChatCompletionStreamResponse{
+			ID:      resp.ID,
+			Object:  "chat.completion.chunk",
+			Created: resp.Created,
+			Model:   resp.Model,
+			Choices: choices,
+		},
+	}
+}
+
+// Recv returns the response chunk, then io.EOF
+func (s *completedStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.chunk == nil {
+		return nil, io.EOF
+	}
+	chunk := s.chunk
+	s.chunk = nil
+	return chunk, nil
+}
+
+// Close is a no-op
+func (s *completedStream) Close() error {
+	return nil
+}
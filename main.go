@@ -2,8 +2,15 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,6 +19,25 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/anthropic"
+	"github.com/sentinel-platform/sentinel/adapters/azure"
+	"github.com/sentinel-platform/sentinel/adapters/bedrock"
+	"github.com/sentinel-platform/sentinel/adapters/cassette"
+	"github.com/sentinel-platform/sentinel/adapters/gemini"
+	"github.com/sentinel-platform/sentinel/adapters/ollama"
+	"github.com/sentinel-platform/sentinel/adapters/openai"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+	"github.com/sentinel-platform/sentinel/gateway"
+	"github.com/sentinel-platform/sentinel/proxy"
+	"github.com/sentinel-platform/sentinel/sentinel/admin"
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/detectors"
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/redaction"
+	"github.com/sentinel-platform/sentinel/sentinel/crypto/hkdf"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/router"
+	"github.com/sentinel-platform/sentinel/tokenizer"
 )
 
 // Config represents the application configuration
@@ -30,6 +56,49 @@ type Config struct {
 		MaxRetries   int    `mapstructure:"maxRetries"`
 		MinIdleConns int    `mapstructure:"minIdleConns"`
 	} `mapstructure:"redis"`
+	CipherMesh struct {
+		Detectors struct {
+			Languages     []string `mapstructure:"languages"`
+			CodeSecrets   bool     `mapstructure:"codeSecrets"`
+			Resolution    string   `mapstructure:"resolution"`
+			ClassPriority []string `mapstructure:"classPriority"`
+			Dictionaries  struct {
+				Dir            string        `mapstructure:"dir"`
+				ReloadInterval time.Duration `mapstructure:"reloadInterval"`
+			} `mapstructure:"dictionaries"`
+			SafeHarbor struct {
+				Enabled bool     `mapstructure:"enabled"`
+				Tenants []string `mapstructure:"tenants"`
+			} `mapstructure:"safeHarbor"`
+		} `mapstructure:"detectors"`
+		Actions map[string]string `mapstructure:"actions"`
+	} `mapstructure:"ciphermesh"`
+	Sentinel struct {
+		Mode       string `mapstructure:"mode"`
+		Thresholds struct {
+			ViolationSimilarity float64 `mapstructure:"violationSimilarity"`
+			ReflectConfidence   float64 `mapstructure:"reflectConfidence"`
+		} `mapstructure:"thresholds"`
+		Encryption struct {
+			Enabled       bool   `mapstructure:"enabled"`
+			BaseSecretEnv string `mapstructure:"baseSecretEnv"`
+		} `mapstructure:"encryption"`
+		Routing struct {
+			InternalModel   string   `mapstructure:"internalModel"`
+			InternalClasses []string `mapstructure:"internalClasses"`
+		} `mapstructure:"routing"`
+	} `mapstructure:"sentinel"`
+	Provider struct {
+		Adapters       []adapters.AdapterConfig `mapstructure:"adapters"`
+		CircuitBreaker circuitbreaker.Settings  `mapstructure:"circuitBreaker"`
+	} `mapstructure:"provider"`
+	RateLimiting struct {
+		RequestsPerMinute int                        `mapstructure:"requestsPerMinute"`
+		BurstLimit        int                        `mapstructure:"burstLimit"`
+		IdleTimeout       time.Duration              `mapstructure:"idleTimeout"`
+		Tenants           map[string]proxy.RateLimit `mapstructure:"tenants"`
+	} `mapstructure:"rateLimiting"`
+	Tokenizer tokenizer.Settings `mapstructure:"tokenizer"`
 }
 
 func main() {
@@ -39,13 +108,34 @@ func main() {
 		log.Fatalf("Failed to initialize config: %v", err)
 	}
 
+	// Load the tenant dictionaries and keep them up to date
+	dictionary, err := initDictionary(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load tenant dictionaries: %v", err)
+	}
+
+	// Initialize metrics, traces and event logging
+	observability, err := admin.NewObservabilityManager()
+	if err != nil {
+		log.Fatalf("Failed to initialize observability: %v", err)
+	}
+
+	// Initialize the security pipeline
+	gw, err := initGateway(cfg, dictionary, observability)
+	if err != nil {
+		log.Fatalf("Failed to initialize gateway: %v", err)
+	}
+
 	// Initialize Gin router
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	// Initialize per-tenant rate limiting
+	limiter := initRateLimiter(cfg)
+
 	// Register routes
-	registerRoutes(router)
+	registerRoutes(router, gw, limiter, dictionary)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -75,6 +165,9 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	if err := observability.Shutdown(ctx); err != nil {
+		log.Printf("Failed to shut down observability: %v", err)
+	}
 
 	log.Println("Server exiting")
 }
@@ -88,6 +181,34 @@ func initConfig() (*Config, error) {
 	viper.SetDefault("database.connectionTimeout", "30s")
 	viper.SetDefault("redis.maxRetries", 3)
 	viper.SetDefault("redis.minIdleConns", 5)
+	viper.SetDefault("ciphermesh.detectors.codeSecrets", true)
+	viper.SetDefault("ciphermesh.detectors.resolution", "confidence")
+	viper.SetDefault("ciphermesh.detectors.dictionaries.reloadInterval", "30s")
+	viper.SetDefault("sentinel.mode", "enforce")
+	viper.SetDefault("sentinel.thresholds.violationSimilarity", 0.78)
+	viper.SetDefault("sentinel.thresholds.reflectConfidence", 0.65)
+	viper.SetDefault("sentinel.encryption.baseSecretEnv", "SENTINEL_SECRET")
+	viper.SetDefault("sentinel.routing.internalClasses", []string{"phi", "pci", "credentials"})
+	viper.SetDefault("provider.adapters", []map[string]interface{}{
+		{
+			"type":   "openai",
+			"models": []string{"*"},
+			"settings": map[string]interface{}{
+				"baseUrl":   "https://api.openai.com/v1",
+				"apiKeyEnv": "OPENAI_API_KEY",
+				"timeout":   "60s",
+			},
+		},
+	})
+	viper.SetDefault("provider.circuitBreaker.window", "1m")
+	viper.SetDefault("provider.circuitBreaker.minRequests", 10)
+	viper.SetDefault("provider.circuitBreaker.errorRateThreshold", 0.5)
+	viper.SetDefault("provider.circuitBreaker.openTimeout", "30s")
+	viper.SetDefault("provider.circuitBreaker.halfOpenRequests", 1)
+	viper.SetDefault("rateLimiting.requestsPerMinute", 100)
+	viper.SetDefault("rateLimiting.burstLimit", 200)
+	viper.SetDefault("rateLimiting.idleTimeout", "10m")
+	viper.SetDefault("tokenizer.enforceLimits", true)
 
 	// Set config file name and paths
 	viper.SetConfigName("config")
@@ -115,8 +236,223 @@ func initConfig() (*Config, error) {
 	return &cfg, nil
 }
 
+// initDictionary creates the tenant dictionary detector. Term lists in the
+// configured directory are loaded and reloaded periodically when they change.
+func initDictionary(cfg *Config) (*detectors.DictionaryDetector, error) {
+	dictionary := detectors.NewDictionaryDetector()
+
+	settings := cfg.CipherMesh.Detectors.Dictionaries
+	if settings.Dir == "" {
+		return dictionary, nil
+	}
+	if err := dictionary.LoadDir(settings.Dir); err != nil {
+		return nil, err
+	}
+
+	if settings.ReloadInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(settings.ReloadInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := dictionary.LoadDir(settings.Dir); err != nil {
+					log.Printf("Failed to reload tenant dictionaries: %v", err)
+				}
+			}
+		}()
+	}
+
+	return dictionary, nil
+}
+
+// initGateway builds the CipherMesh and Sentinel pipeline from configuration
+func initGateway(cfg *Config, dictionary *detectors.DictionaryDetector, observability *admin.ObservabilityManager) (*gateway.Gateway, error) {
+	// Load the built-in detector packs, in a fixed order so overlapping
+	// detections resolve the same way on every start
+	detectorManager := detectors.NewDetectorManager()
+	packs := map[string]func() ([]detectors.Detector, error){
+		"common":       detectors.CommonRegexDetectors,
+		"us_financial": detectors.USFinancialDetectors,
+		"medical":      detectors.MedicalDetectors,
+	}
+	packNames := []string{"common", "us_financial", "medical"}
+	// Add the national identifier packs for the configured languages
+	for _, language := range cfg.CipherMesh.Detectors.Languages {
+		names, ok := detectors.LanguagePacks[language]
+		if !ok {
+			log.Printf("No detector packs for language %s", language)
+			continue
+		}
+		for _, name := range names {
+			if _, ok := packs[name]; !ok {
+				packs[name] = detectors.InternationalPacks[name]
+				packNames = append(packNames, name)
+			}
+		}
+	}
+	for _, name := range packNames {
+		packDetectors, err := packs[name]()
+		if err != nil {
+			log.Printf("Skipping detector pack %s: %v", name, err)
+			continue
+		}
+		for _, d := range packDetectors {
+			detectorManager.AddDetector(d)
+		}
+	}
+	// The HIPAA Safe Harbor pack, limited to the listed tenants if any
+	if safeHarbor := cfg.CipherMesh.Detectors.SafeHarbor; safeHarbor.Enabled {
+		packDetectors, err := detectors.SafeHarborDetectors()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Safe Harbor detectors: %w", err)
+		}
+		if len(safeHarbor.Tenants) > 0 {
+			packDetectors = detectors.ForTenants(safeHarbor.Tenants, packDetectors...)
+		}
+		for _, d := range packDetectors {
+			detectorManager.AddDetector(d)
+		}
+	}
+	if cfg.CipherMesh.Detectors.CodeSecrets {
+		detectorManager.AddDetector(detectors.NewSecretScannerDetector())
+	}
+	detectorManager.AddDetector(dictionary)
+	resolution := detectors.ResolutionStrategy(cfg.CipherMesh.Detectors.Resolution)
+	if err := detectorManager.SetResolution(resolution, cfg.CipherMesh.Detectors.ClassPriority); err != nil {
+		return nil, fmt.Errorf("failed to configure detectors: %w", err)
+	}
+
+	// Derive the redaction key from the configured base secret
+	key, err := redactionKey(cfg.Sentinel.Encryption.BaseSecretEnv)
+	if err != nil {
+		return nil, err
+	}
+	redactor := redaction.NewRedactor(key)
+
+	violationDetector, err := detector.NewDefaultViolationDetector(detector.DetectionThresholds{
+		ViolationSimilarity: cfg.Sentinel.Thresholds.ViolationSimilarity,
+		ReflectConfidence:   cfg.Sentinel.Thresholds.ReflectConfidence,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create violation detector: %w", err)
+	}
+
+	policyEngine := router.NewThresholdPolicyEngine(
+		cfg.Sentinel.Thresholds.ViolationSimilarity,
+		cfg.Sentinel.Thresholds.ReflectConfidence,
+	)
+	requestRouter := router.NewRouter(policyEngine, cfg.Sentinel.Mode)
+	if routing := cfg.Sentinel.Routing; routing.InternalModel != "" {
+		requestRouter.SetInternalModel(routing.InternalModel, routing.InternalClasses...)
+	}
+
+	adapter, err := initAdapter(cfg, observability)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count tokens locally for usage accounting and limits
+	tok, err := tokenizer.New(cfg.Tokenizer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tokenizer: %w", err)
+	}
+
+	gw := gateway.NewGateway(detectorManager, redactor, violationDetector, requestRouter, adapter, cfg.CipherMesh.Actions)
+	gw.SetTokenizer(tok)
+	return gw, nil
+}
+
+// initAdapter creates the configured LLM provider adapters and returns a
+// registry that routes each request to the adapter for its model
+func initAdapter(cfg *Config, observability *admin.ObservabilityManager) (adapters.LLMAdapter, error) {
+	registry := adapters.NewRegistry()
+	registry.Register("openai", openai.NewAdapterFromSettings)
+	registry.Register("anthropic", anthropic.NewAdapterFromSettings)
+	registry.Register("azure", azure.NewAdapterFromSettings)
+	registry.Register("bedrock", bedrock.NewAdapterFromSettings)
+	registry.Register("gemini", gemini.NewAdapterFromSettings)
+	registry.Register("ollama", ollama.NewAdapterFromSettings)
+	registry.Register("openai_compatible", openai.NewCompatibleAdapterFromSettings)
+	registry.Register("cassette", cassette.Constructor(registry))
+
+	// Breaker state changes are recorded as metrics and logged
+	breakers := circuitbreaker.NewManager(cfg.Provider.CircuitBreaker, observability)
+
+	for i := range cfg.Provider.Adapters {
+		config := &cfg.Provider.Adapters[i]
+
+		adapter, err := registry.AddAdapter(config)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := adapter.ValidateConfig(); err != nil {
+			log.Printf("%s adapter is not fully configured: %v", config.Type, err)
+		}
+
+		if filtered, ok := adapter.(interface {
+			SetDetectionHandler(adapters.DetectionHandler)
+		}); ok {
+			filtered.SetDetectionHandler(logContentFilterDetections)
+		}
+
+		if breakable, ok := adapter.(interface {
+			SetCircuitBreakers(*circuitbreaker.Manager)
+		}); ok {
+			breakable.SetCircuitBreakers(breakers)
+		}
+	}
+
+	if len(registry.Adapters()) == 0 {
+		return nil, fmt.Errorf("no provider adapters configured")
+	}
+
+	return registry, nil
+}
+
+// logContentFilterDetections logs provider content filter annotations on
+// responses that were not blocked
+func logContentFilterDetections(ctx context.Context, model string, detections []detector.DetectionResult) {
+	for _, detection := range detections {
+		log.Printf("Provider content filter flagged %s response: %s (score %.2f)", model, detection.ViolationType, detection.Score)
+	}
+}
+
+// redactionKey derives a 256-bit redaction key from the secret in envVar.
+// Without a secret an ephemeral key is used, so tokens do not survive restarts.
+func redactionKey(envVar string) ([]byte, error) {
+	secret := os.Getenv(envVar)
+	if secret == "" {
+		log.Printf("%s is not set, using an ephemeral redaction key", envVar)
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate redaction key: %w", err)
+		}
+		return key, nil
+	}
+
+	key, err := hkdf.DeriveKey([]byte(secret), nil, []byte("sentinel-ciphermesh-redaction"), 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive redaction key: %w", err)
+	}
+	return key, nil
+}
+
+// initRateLimiter creates the per-tenant rate limiter from configuration
+func initRateLimiter(cfg *Config) *proxy.TokenBucketRateLimiter {
+	limiter := proxy.NewTokenBucketRateLimiter(proxy.RateLimit{
+		RequestsPerMinute: cfg.RateLimiting.RequestsPerMinute,
+		BurstLimit:        cfg.RateLimiting.BurstLimit,
+	}, cfg.RateLimiting.IdleTimeout)
+
+	for tenantID, limit := range cfg.RateLimiting.Tenants {
+		limiter.SetTenantLimit(tenantID, limit)
+	}
+
+	return limiter
+}
+
 // registerRoutes registers all HTTP routes
-func registerRoutes(router *gin.Engine) {
+func registerRoutes(router *gin.Engine, gw *gateway.Gateway, limiter proxy.RateLimiter, dictionary *detectors.DictionaryDetector) {
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -135,7 +471,9 @@ func registerRoutes(router *gin.Engine) {
 	})
 
 	// OpenAI-compatible chat completions endpoint
-	router.POST("/v1/chat/completions", handleChatCompletions)
+	router.POST("/v1/chat/completions", rateLimit(limiter), handleChatCompletions(gw))
+	router.POST("/v1/embeddings", rateLimit(limiter), handleEmbeddings(gw))
+	router.GET("/v1/models", handleListModels(gw))
 
 	// Admin endpoints
 	admin := router.Group("/sentinel/admin")
@@ -144,45 +482,324 @@ func registerRoutes(router *gin.Engine) {
 		admin.POST("/policies", handleCreatePolicy)
 		admin.GET("/tenants", handleGetTenants)
 		admin.POST("/tenants", handleCreateTenant)
+		admin.GET("/tenants/:tenant/dictionaries", handleGetDictionaries(dictionary))
+		admin.PUT("/tenants/:tenant/dictionaries/:name", handlePutDictionary(dictionary))
+		admin.DELETE("/tenants/:tenant/dictionaries/:name", handleDeleteDictionary(dictionary))
 		admin.GET("/logs", handleGetLogs)
 	}
 }
 
+// rateLimit rejects requests from tenants that have exhausted their rate limit
+func rateLimit(limiter proxy.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant := c.GetHeader("X-Tenant")
+		if tenant == "" || limiter.Allow(tenant) {
+			c.Next()
+			return
+		}
+
+		retryAfter := limiter.GetRetryAfter(tenant)
+		c.Header("Retry-After", fmt.Sprintf("%.0f", math.Ceil(retryAfter.Seconds())))
+		writeError(c, http.StatusTooManyRequests, "rate_limit_exceeded", "Rate limit exceeded")
+		c.Abort()
+	}
+}
+
 // handleChatCompletions handles the OpenAI-compatible chat completions endpoint
-func handleChatCompletions(c *gin.Context) {
-	// Get tenant from header
-	tenant := c.GetHeader("X-Tenant")
-	if tenant == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "X-Tenant header is required",
+func handleChatCompletions(gw *gateway.Gateway) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get tenant from header
+		tenant := c.GetHeader("X-Tenant")
+		if tenant == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "X-Tenant header is required",
+			})
+			return
+		}
+
+		var req adapters.ChatCompletionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+
+		if req.Stream {
+			stream, err := gw.ChatCompletionStream(c.Request.Context(), tenant, &req)
+			if err != nil {
+				writeGatewayError(c, err)
+				return
+			}
+			writeStream(c, stream)
+			return
+		}
+
+		resp, err := gw.ChatCompletion(c.Request.Context(), tenant, &req)
+		if err != nil {
+			writeGatewayError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// handleEmbeddings handles the OpenAI-compatible embeddings endpoint
+func handleEmbeddings(gw *gateway.Gateway) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant := c.GetHeader("X-Tenant")
+		if tenant == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "X-Tenant header is required",
+			})
+			return
+		}
+
+		var req adapters.EmbeddingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		if len(req.Input) == 0 {
+			writeError(c, http.StatusBadRequest, "invalid_request_error", "input must not be empty")
+			return
+		}
+
+		resp, err := gw.Embed(c.Request.Context(), tenant, &req)
+		if err != nil {
+			writeGatewayError(c, err)
+			return
+		}
+
+		if req.EncodingFormat != "base64" {
+			c.JSON(http.StatusOK, resp)
+			return
+		}
+
+		// Adapters always return floats, so encode them as the client asked
+		data := make([]gin.H, len(resp.Data))
+		for i, embedding := range resp.Data {
+			data[i] = gin.H{
+				"object":    embedding.Object,
+				"index":     embedding.Index,
+				"embedding": encodeEmbedding(embedding.Embedding),
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"object": resp.Object,
+			"data":   data,
+			"model":  resp.Model,
+			"usage":  resp.Usage,
 		})
-		return
 	}
+}
+
+// encodeEmbedding encodes an embedding as base64 little-endian float32s,
+// the OpenAI base64 encoding format
+func encodeEmbedding(values []float64) string {
+	buf := make([]byte, 4*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(value)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
 
-	// TODO: Implement CipherMesh redaction
-	// TODO: Implement Sentinel security checks
-	// TODO: Forward to LLM provider
-	// TODO: Implement response processing
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":      "chatcmpl-example",
-		"object":  "chat.completion",
-		"created": time.Now().Unix(),
-		"model":   "sentinel-guarded-model",
-		"choices": []map[string]interface{}{
-			{
-				"index": 0,
-				"message": map[string]interface{}{
-					"role":    "assistant",
-					"content": "This is a placeholder response from Sentinel Gateway. Implementation pending.",
+// handleListModels lists the models served by the configured adapters.
+// Adapters that cannot be reached are logged and left out.
+func handleListModels(gw *gateway.Gateway) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		models, err := gw.ListModels(c.Request.Context())
+		if err != nil {
+			log.Printf("Failed to list models: %v", err)
+		}
+		if models == nil {
+			models = []adapters.ModelInfo{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"object": "list",
+			"data":   models,
+		})
+	}
+}
+
+// writeStream relays stream chunks to the client as server-sent events.
+// Errors after the first byte is written are reported as an error event.
+func writeStream(c *gin.Context, stream adapters.ChatCompletionStream) {
+	defer stream.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+			c.Writer.Flush()
+			return
+		}
+		if err != nil {
+			log.Printf("Chat completion stream failed: %v", err)
+			writeStreamEvent(c, gin.H{
+				"error": gin.H{
+					"message": err.Error(),
+					"type":    "upstream_error",
 				},
-				"finish_reason": "stop",
+			})
+			return
+		}
+
+		writeStreamEvent(c, chunk)
+	}
+}
+
+// writeStreamEvent writes a single server-sent event with a JSON payload
+func writeStreamEvent(c *gin.Context, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode stream event: %v", err)
+		return
+	}
+
+	fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+	c.Writer.Flush()
+}
+
+// providerFilterError is implemented by errors for prompts or completions
+// blocked by the provider's own content filter or safety settings
+type providerFilterError interface {
+	error
+	ViolationType() string
+}
+
+// writeGatewayError maps pipeline errors to HTTP responses
+func writeGatewayError(c *gin.Context, err error) {
+	var policyErr *gateway.PolicyError
+	var providerErr *gateway.ProviderError
+	var openErr *circuitbreaker.OpenError
+	var filterErr providerFilterError
+	var upstreamErr *adapters.ProviderError
+	var limitErr *tokenizer.LimitError
+
+	switch {
+	case errors.Is(err, adapters.ErrNotSupported):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"message": err.Error(),
+				"type":    "invalid_request_error",
+				"code":    "unsupported_operation",
+			},
+		})
+	case errors.Is(err, adapters.ErrNoAdapter):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": gin.H{
+				"message": err.Error(),
+				"type":    "invalid_request_error",
+				"code":    "model_not_found",
+			},
+		})
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"message":         policyErr.Error(),
+				"type":            "policy_violation",
+				"code":            policyErr.Action,
+				"violation_type":  policyErr.ViolationType,
+				"recommendations": policyErr.Recommendations,
+			},
+		})
+	case errors.As(err, &filterErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"message":        filterErr.Error(),
+				"type":           "policy_violation",
+				"code":           "content_filter",
+				"violation_type": filterErr.ViolationType(),
+			},
+		})
+	case errors.As(err, &openErr):
+		c.Header("Retry-After", fmt.Sprintf("%.0f", math.Ceil(openErr.RetryAfter.Seconds())))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": gin.H{
+				"message": fmt.Sprintf("Upstream is unavailable: %v", openErr),
+				"type":    "server_error",
+				"code":    "circuit_open",
+			},
+		})
+	case errors.As(err, &limitErr):
+		code := "context_length_exceeded"
+		if limitErr.Limit == "max_output_tokens" {
+			code = "max_tokens_exceeded"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": gin.H{
+				"message": limitErr.Error(),
+				"type":    "invalid_request_error",
+				"code":    code,
 			},
+		})
+	case errors.As(err, &upstreamErr):
+		writeUpstreamError(c, upstreamErr)
+	case errors.As(err, &providerErr):
+		writeError(c, http.StatusBadGateway, "upstream_error", providerErr.Error())
+	default:
+		log.Printf("Gateway request failed: %v", err)
+		writeError(c, http.StatusInternalServerError, "server_error", "internal error while processing request")
+	}
+}
+
+// writeUpstreamError maps a provider error response to the gateway's status
+// codes. Retry-After and the provider's rate limit headers are passed on so
+// clients can back off.
+func writeUpstreamError(c *gin.Context, err *adapters.ProviderError) {
+	for name, values := range err.RateLimit {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	if err.RetryAfter > 0 {
+		c.Header("Retry-After", fmt.Sprintf("%.0f", math.Ceil(err.RetryAfter.Seconds())))
+	}
+
+	status, errType, code, message := http.StatusBadGateway, "server_error", "upstream_error", err.Error()
+	switch err.Kind {
+	case adapters.ErrRateLimited:
+		status, errType, code = http.StatusTooManyRequests, "rate_limit_exceeded", "upstream_rate_limited"
+	case adapters.ErrInvalidRequest:
+		status, errType, code, message = http.StatusBadRequest, "invalid_request_error", err.Code, err.Message
+		if code == "" {
+			code = "invalid_request"
+		}
+	case adapters.ErrContentFiltered:
+		status, errType, code = http.StatusForbidden, "policy_violation", "content_filter"
+	case adapters.ErrAuthentication:
+		// Clients cannot fix the gateway's provider credentials
+		log.Printf("Provider %s rejected credentials: %v", err.Provider, err)
+		code, message = "upstream_authentication_failed", "upstream provider rejected the gateway's credentials"
+	default:
+		// Anthropic reports overload as 529
+		if err.StatusCode == http.StatusServiceUnavailable || err.StatusCode == 529 {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"message":  message,
+			"type":     errType,
+			"code":     code,
+			"provider": err.Provider,
 		},
-		"usage": map[string]interface{}{
-			"prompt_tokens":     0,
-			"completion_tokens": 0,
-			"total_tokens":      0,
+	})
+}
+
+// writeError writes an OpenAI-style error response
+func writeError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    errType,
 		},
 	})
 }
@@ -211,6 +828,60 @@ func handleCreateTenant(c *gin.Context) {
 	c.JSON(http.StatusCreated, map[string]interface{}{})
 }
 
+// handleGetDictionaries lists a tenant's term lists with their sizes. Terms
+// are not returned, as lists can be large and are sensitive themselves.
+func handleGetDictionaries(dictionary *detectors.DictionaryDetector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lists := dictionary.TermLists(c.Param("tenant"))
+		summaries := make([]gin.H, len(lists))
+		for i, list := range lists {
+			summaries[i] = gin.H{
+				"name":      list.Name,
+				"data_type": list.DataType,
+				"terms":     len(list.Terms),
+			}
+		}
+		c.JSON(http.StatusOK, summaries)
+	}
+}
+
+// handlePutDictionary creates or replaces a tenant's term list. The change
+// applies to requests that start after it returns.
+func handlePutDictionary(dictionary *detectors.DictionaryDetector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var list detectors.TermList
+		if err := c.ShouldBindJSON(&list); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+		list.Name = c.Param("name")
+		if list.DataType == "" {
+			list.DataType = detectors.DefaultDictionaryClass
+		}
+
+		if err := dictionary.SetTermList(c.Param("tenant"), list); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"name":      list.Name,
+			"data_type": list.DataType,
+			"terms":     len(list.Terms),
+		})
+	}
+}
+
+// handleDeleteDictionary removes a tenant's term list
+func handleDeleteDictionary(dictionary *detectors.DictionaryDetector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := dictionary.RemoveTermList(c.Param("tenant"), c.Param("name")); err != nil {
+			writeError(c, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
 // handleGetLogs handles getting logs
 func handleGetLogs(c *gin.Context) {
 	// TODO: Implement log retrieval
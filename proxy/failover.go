@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+)
+
+// maxRequestBodyBytes bounds how much of a request body is buffered so it can be replayed on failover
+const maxRequestBodyBytes = 10 << 20
+
+// ErrNoUpstream is returned when the pool has no upstream left to try
+var ErrNoUpstream = errors.New("no upstream available")
+
+// failoverTransport sends each request to an upstream chosen by the pool and
+// retries on another upstream after connection errors, server errors or rate
+// limiting. Rate limited upstreams are avoided for as long as their
+// Retry-After asks, and upstreams whose circuit breaker is open for the
+// requested model are skipped. Other 4xx responses are returned as they are,
+// since every upstream would reject the request the same way.
+type failoverTransport struct {
+	pool        *UpstreamPool
+	base        http.RoundTripper
+	maxAttempts int
+	breakers    *circuitbreaker.Manager
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	model := modelFromBody(body)
+	tried := make(map[*Upstream]bool)
+	var lastErr error
+
+	for attempt := 0; attempt < t.maxAttempts; {
+		upstream := t.pool.acquire(tried)
+		if upstream == nil {
+			break
+		}
+		tried[upstream] = true
+
+		// Fail fast on upstreams whose breaker is open without using an attempt
+		var breaker *circuitbreaker.Breaker
+		if t.breakers != nil {
+			breaker = t.breakers.Get(upstream.URL.Host, model)
+			if err := breaker.Allow(); err != nil {
+				t.pool.cancel(upstream)
+				lastErr = err
+				continue
+			}
+		}
+		attempt++
+
+		outreq := req.Clone(req.Context())
+		outreq.URL.Scheme = upstream.URL.Scheme
+		outreq.URL.Host = upstream.URL.Host
+		outreq.URL.Path = singleJoiningSlash(upstream.URL.Path, req.URL.Path)
+		outreq.URL.RawPath = ""
+		outreq.Host = upstream.URL.Host
+		if body != nil {
+			outreq.Body = io.NopCloser(bytes.NewReader(body))
+			outreq.ContentLength = int64(len(body))
+		}
+
+		start := time.Now()
+		resp, err := t.base.RoundTrip(outreq)
+		var upstreamErr *adapters.ProviderError
+		if err == nil {
+			upstreamErr = classifyResponse(upstream, resp)
+		}
+		serverError := upstreamErr != nil && upstreamErr.Kind == adapters.ErrServerError
+		if breaker != nil {
+			breaker.Record(err == nil && !serverError, time.Since(start))
+		}
+		if upstreamErr != nil && upstreamErr.Kind == adapters.ErrRateLimited {
+			t.pool.throttle(upstream, upstreamErr.RetryAfter)
+		}
+		lastAttempt := attempt == t.maxAttempts || req.Context().Err() != nil || !t.pool.hasCandidate(tried)
+
+		if err != nil {
+			t.pool.release(upstream, true)
+			lastErr = fmt.Errorf("upstream %s: %w", upstream.URL.Host, err)
+			if lastAttempt {
+				break
+			}
+			continue
+		}
+
+		if upstreamErr != nil && upstreamErr.Retryable() && !lastAttempt {
+			resp.Body.Close()
+			t.pool.release(upstream, serverError)
+			lastErr = fmt.Errorf("upstream %s: %w", upstream.URL.Host, upstreamErr)
+			continue
+		}
+
+		// Keep the upstream counted as outstanding until the body is consumed
+		resp.Body = &releasingBody{
+			ReadCloser: resp.Body,
+			release: func() {
+				t.pool.release(upstream, serverError)
+			},
+		}
+		return resp, nil
+	}
+
+	if lastErr == nil {
+		lastErr = ErrNoUpstream
+	}
+	return nil, lastErr
+}
+
+// replayableBody reads the request body into memory so it can be resent
+func replayableBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxRequestBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(body) > maxRequestBodyBytes {
+		return nil, fmt.Errorf("request body exceeds %d bytes", maxRequestBodyBytes)
+	}
+	return body, nil
+}
+
+// modelFromBody extracts the model name from an OpenAI-style request body
+func modelFromBody(body []byte) string {
+	var request struct {
+		Model string `json:"model"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &request) != nil {
+		return ""
+	}
+	return request.Model
+}
+
+// classifyResponse returns the provider error for an upstream's error
+// response, or nil if the request succeeded. The body is left unread so it
+// can still be passed on to the client.
+func classifyResponse(upstream *Upstream, resp *http.Response) *adapters.ProviderError {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	return adapters.NewProviderError(upstream.URL.Host, resp, nil, nil)
+}
+
+// releasingBody releases its upstream exactly once when closed
+type releasingBody struct {
+	io.ReadCloser
+	release  func()
+	released bool
+}
+
+// Close closes the body and releases the upstream
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.released {
+		b.released = true
+		b.release()
+	}
+	return err
+}
@@ -1,18 +1,34 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"mime"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"time"
+
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
 )
 
+// maxResponseBodyBytes bounds how much of an upstream response is buffered for processing
+const maxResponseBodyBytes = 10 << 20
+
+// ErrPolicyBlocked is returned (possibly wrapped) by processors to block a
+// response by policy instead of failing it as a bad gateway
+var ErrPolicyBlocked = errors.New("blocked by policy")
+
 // ReverseProxy implements a reverse proxy for LLM providers
 type ReverseProxy struct {
 	proxy       *httputil.ReverseProxy
-	targetURL   *url.URL
+	pool        *UpstreamPool
+	transport   *failoverTransport
 	cipherMesh  CipherMeshProcessor
 	sentinel    SentinelProcessor
 	rateLimiter RateLimiter
@@ -46,7 +62,7 @@ type RateLimiter interface {
 	GetRetryAfter(tenantID string) time.Duration
 }
 
-// NewReverseProxy creates a new reverse proxy
+// NewReverseProxy creates a new reverse proxy for a single target
 func NewReverseProxy(
 	targetURL *url.URL,
 	cipherMesh CipherMeshProcessor,
@@ -54,17 +70,48 @@ func NewReverseProxy(
 	rateLimiter RateLimiter,
 	timeout time.Duration) *ReverseProxy {
 
-	// Create the reverse proxy
-	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	// A single-upstream round robin pool cannot fail to construct
+	pool, _ := NewUpstreamPool(StrategyRoundRobin, 1, 0)
+	pool.AddUpstream(targetURL, 1)
+
+	return NewLoadBalancedReverseProxy(pool, cipherMesh, sentinel, rateLimiter, timeout)
+}
+
+// NewLoadBalancedReverseProxy creates a new reverse proxy that spreads requests
+// across the upstreams in pool and fails over between them
+func NewLoadBalancedReverseProxy(
+	pool *UpstreamPool,
+	cipherMesh CipherMeshProcessor,
+	sentinel SentinelProcessor,
+	rateLimiter RateLimiter,
+	timeout time.Duration) *ReverseProxy {
 
-	return &ReverseProxy{
-		proxy:       proxy,
-		targetURL:   targetURL,
+	rp := &ReverseProxy{
+		pool: pool,
+		transport: &failoverTransport{
+			pool:        pool,
+			base:        http.DefaultTransport,
+			maxAttempts: 3,
+		},
 		cipherMesh:  cipherMesh,
 		sentinel:    sentinel,
 		rateLimiter: rateLimiter,
 		timeout:     timeout,
 	}
+
+	rp.proxy = &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			// The failover transport fills in the upstream scheme and host
+			r.Header.Set("X-Forwarded-Host", r.Host)
+			// Let the transport negotiate compression so processors see plain bodies
+			r.Header.Del("Accept-Encoding")
+		},
+		Transport:      rp.transport,
+		ModifyResponse: rp.ProcessResponse,
+		ErrorHandler:   rp.handleError,
+	}
+
+	return rp
 }
 
 // ServeHTTP implements the http.Handler interface
@@ -83,7 +130,7 @@ func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Check rate limits
 	if !rp.rateLimiter.Allow(tenantID) {
 		retryAfter := rp.rateLimiter.GetRetryAfter(tenantID)
-		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", math.Ceil(retryAfter.Seconds())))
 		http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 		return
 	}
@@ -100,21 +147,23 @@ func (rp *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Modify request to forward to target
-	r.URL.Host = rp.targetURL.Host
-	r.URL.Scheme = rp.targetURL.Scheme
-	r.Header.Set("X-Forwarded-Host", r.Header.Get("Host"))
-	r.Host = rp.targetURL.Host
-
-	// Forward the request
-	rp.proxy.ServeHTTP(w, r)
+	// Forward the request under the timeout context
+	rp.proxy.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// ProcessResponse processes a response
-func (rp *ReverseProxy) ProcessResponse(w http.ResponseWriter, r *http.Request, resp *http.Response) error {
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(r.Context(), rp.timeout)
-	defer cancel()
+// ProcessResponse runs an upstream response through Sentinel and CipherMesh.
+// Regular responses are buffered so processors can inspect and rewrite the
+// whole body before any bytes reach the client. Event streams are passed
+// through unbuffered; processors that inspect them must wrap resp.Body.
+func (rp *ReverseProxy) ProcessResponse(resp *http.Response) error {
+	ctx := resp.Request.Context()
+
+	streaming := isEventStream(resp)
+	if !streaming {
+		if err := bufferBody(resp); err != nil {
+			return err
+		}
+	}
 
 	// Process response with Sentinel
 	if err := rp.sentinel.ProcessResponse(ctx, resp); err != nil {
@@ -126,16 +175,96 @@ func (rp *ReverseProxy) ProcessResponse(w http.ResponseWriter, r *http.Request,
 		return fmt.Errorf("CipherMesh processing failed: %w", err)
 	}
 
+	if !streaming {
+		// Processors may have rewritten the body, so recompute its length
+		if err := bufferBody(resp); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// GetTargetURL returns the target URL
+// handleError writes a clean error response when forwarding or response
+// processing fails, so partially processed upstream bytes are never sent
+func (rp *ReverseProxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrPolicyBlocked) {
+		http.Error(w, fmt.Sprintf("Response blocked by policy: %v", err), http.StatusForbidden)
+		return
+	}
+
+	var openErr *circuitbreaker.OpenError
+	if errors.As(err, &openErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", fmt.Sprintf("%.0f", math.Ceil(openErr.RetryAfter.Seconds())))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"message": fmt.Sprintf("Upstream is unavailable: %v", openErr),
+				"type":    "server_error",
+				"code":    "circuit_open",
+			},
+		})
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		http.Error(w, "Upstream request timed out", http.StatusGatewayTimeout)
+		return
+	}
+
+	http.Error(w, "Bad gateway", http.StatusBadGateway)
+}
+
+// bufferBody reads the response body into memory and replaces it with a
+// re-readable copy
+func bufferBody(resp *http.Response) error {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read upstream response: %w", err)
+	}
+	if len(body) > maxResponseBodyBytes {
+		return fmt.Errorf("upstream response exceeds %d bytes", maxResponseBodyBytes)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", fmt.Sprintf("%d", len(body)))
+	resp.Header.Del("Transfer-Encoding")
+	resp.TransferEncoding = nil
+
+	return nil
+}
+
+// isEventStream reports whether the response is a server-sent event stream
+func isEventStream(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// GetTargetURL returns the URL of the first upstream
 func (rp *ReverseProxy) GetTargetURL() *url.URL {
-	return rp.targetURL
+	return rp.pool.primary()
 }
 
-// SetTargetURL sets the target URL
+// SetTargetURL replaces every upstream with a single target. It is safe to
+// call while requests are being served.
 func (rp *ReverseProxy) SetTargetURL(targetURL *url.URL) {
-	rp.targetURL = targetURL
-	rp.proxy = httputil.NewSingleHostReverseProxy(targetURL)
+	rp.pool.SetUpstreams([]*url.URL{targetURL}, []int{1})
+}
+
+// SetCircuitBreakers enables per-upstream, per-model circuit breaking. It must
+// be called before the proxy starts serving requests.
+func (rp *ReverseProxy) SetCircuitBreakers(breakers *circuitbreaker.Manager) {
+	rp.transport.breakers = breakers
+}
+
+// GetPool returns the upstream pool
+func (rp *ReverseProxy) GetPool() *UpstreamPool {
+	return rp.pool
 }
@@ -0,0 +1,206 @@
+package proxy
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit defines the sustained request rate and burst allowed for a tenant
+type RateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute" mapstructure:"requestsPerMinute"`
+	BurstLimit        int `json:"burst_limit" mapstructure:"burstLimit"`
+}
+
+// TokenBucketRateLimiter implements RateLimiter with one token bucket per tenant.
+// Buckets start full, hold up to BurstLimit tokens and refill continuously at
+// RequestsPerMinute/60 tokens per second.
+type TokenBucketRateLimiter struct {
+	defaultLimit RateLimit
+	overrides    map[string]RateLimit
+	buckets      map[string]*tokenBucket
+	idleTimeout  time.Duration
+	lastSweep    time.Time
+	mutex        sync.Mutex
+}
+
+// tokenBucket holds the state of a single tenant's bucket
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// NewTokenBucketRateLimiter creates a new token bucket rate limiter. Buckets of
+// tenants that have been idle for idleTimeout are evicted once they have refilled.
+func NewTokenBucketRateLimiter(defaultLimit RateLimit, idleTimeout time.Duration) *TokenBucketRateLimiter {
+	return &TokenBucketRateLimiter{
+		defaultLimit: defaultLimit,
+		overrides:    make(map[string]RateLimit),
+		buckets:      make(map[string]*tokenBucket),
+		idleTimeout:  idleTimeout,
+		lastSweep:    time.Now(),
+	}
+}
+
+// SetTenantLimit overrides the rate limit for a tenant
+func (l *TokenBucketRateLimiter) SetTenantLimit(tenantID string, limit RateLimit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.overrides[tenantID] = limit
+
+	// Never leave a bucket holding more than the new burst allows
+	if bucket, exists := l.buckets[tenantID]; exists {
+		burst := burstOf(limit)
+		if bucket.tokens > burst {
+			bucket.tokens = burst
+		}
+	}
+}
+
+// RemoveTenantLimit removes a tenant's override so the default limit applies
+func (l *TokenBucketRateLimiter) RemoveTenantLimit(tenantID string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.overrides, tenantID)
+}
+
+// Allow checks if a request is allowed and consumes a token if it is
+func (l *TokenBucketRateLimiter) Allow(tenantID string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.maybeSweep(now)
+
+	limit := l.limitFor(tenantID)
+	if limit.RequestsPerMinute <= 0 {
+		return true
+	}
+
+	bucket := l.bucket(tenantID, limit, now)
+	bucket.lastSeen = now
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+	return true
+}
+
+// GetRetryAfter returns the time until the tenant's next token is available
+func (l *TokenBucketRateLimiter) GetRetryAfter(tenantID string) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	limit := l.limitFor(tenantID)
+	if limit.RequestsPerMinute <= 0 {
+		return 0
+	}
+
+	bucket := l.bucket(tenantID, limit, time.Now())
+	if bucket.tokens >= 1 {
+		return 0
+	}
+
+	seconds := (1 - bucket.tokens) / ratePerSecond(limit)
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// EvictIdle removes buckets that have been idle for the idle timeout and have
+// fully refilled. Evicting a full bucket is lossless because new buckets start
+// full. It returns the number of evicted buckets.
+func (l *TokenBucketRateLimiter) EvictIdle() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.evictIdle(time.Now())
+}
+
+// TenantCount returns the number of tenants currently tracked
+func (l *TokenBucketRateLimiter) TenantCount() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.buckets)
+}
+
+// maybeSweep evicts idle buckets at most once per idle timeout
+func (l *TokenBucketRateLimiter) maybeSweep(now time.Time) {
+	if l.idleTimeout <= 0 || now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	l.evictIdle(now)
+}
+
+// evictIdle removes idle, full buckets
+func (l *TokenBucketRateLimiter) evictIdle(now time.Time) int {
+	l.lastSweep = now
+
+	evicted := 0
+	for tenantID, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) < l.idleTimeout {
+			continue
+		}
+
+		limit := l.limitFor(tenantID)
+		refill(bucket, limit, now)
+		if bucket.tokens >= burstOf(limit) {
+			delete(l.buckets, tenantID)
+			evicted++
+		}
+	}
+
+	return evicted
+}
+
+// bucket returns the tenant's refilled bucket, creating a full one if needed
+func (l *TokenBucketRateLimiter) bucket(tenantID string, limit RateLimit, now time.Time) *tokenBucket {
+	bucket, exists := l.buckets[tenantID]
+	if !exists {
+		bucket = &tokenBucket{
+			tokens:   burstOf(limit),
+			updated:  now,
+			lastSeen: now,
+		}
+		l.buckets[tenantID] = bucket
+		return bucket
+	}
+
+	refill(bucket, limit, now)
+	return bucket
+}
+
+// limitFor returns the effective limit for a tenant
+func (l *TokenBucketRateLimiter) limitFor(tenantID string) RateLimit {
+	if limit, exists := l.overrides[tenantID]; exists {
+		return limit
+	}
+	return l.defaultLimit
+}
+
+// refill adds the tokens accrued since the bucket was last updated
+func refill(bucket *tokenBucket, limit RateLimit, now time.Time) {
+	elapsed := now.Sub(bucket.updated).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	bucket.tokens = math.Min(burstOf(limit), bucket.tokens+elapsed*ratePerSecond(limit))
+	bucket.updated = now
+}
+
+// ratePerSecond returns the refill rate of a limit in tokens per second
+func ratePerSecond(limit RateLimit) float64 {
+	return float64(limit.RequestsPerMinute) / 60
+}
+
+// burstOf returns the bucket capacity of a limit, defaulting to one minute of requests
+func burstOf(limit RateLimit) float64 {
+	if limit.BurstLimit <= 0 {
+		return float64(limit.RequestsPerMinute)
+	}
+	return float64(limit.BurstLimit)
+}
@@ -0,0 +1,361 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Load balancing strategies
+const (
+	StrategyRoundRobin       = "round_robin"
+	StrategyLeastOutstanding = "least_outstanding"
+)
+
+// Upstream is a weighted provider endpoint in an UpstreamPool
+type Upstream struct {
+	URL    *url.URL
+	Weight int
+
+	healthy        bool
+	failures       int
+	unhealthySince time.Time
+	throttledUntil time.Time
+	outstanding    int
+	currentWeight  int
+}
+
+// UpstreamStatus is a snapshot of an upstream's state
+type UpstreamStatus struct {
+	URL         string `json:"url"`
+	Weight      int    `json:"weight"`
+	Healthy     bool   `json:"healthy"`
+	Throttled   bool   `json:"throttled"`
+	Failures    int    `json:"failures"`
+	Outstanding int    `json:"outstanding"`
+}
+
+// UpstreamPool selects between weighted upstreams and tracks their health.
+// Upstreams are marked unhealthy after maxFails consecutive passive failures
+// and are retried once failTimeout has elapsed or an active check succeeds.
+type UpstreamPool struct {
+	upstreams   []*Upstream
+	strategy    string
+	maxFails    int
+	failTimeout time.Duration
+	mutex       sync.Mutex
+}
+
+// NewUpstreamPool creates a new upstream pool
+func NewUpstreamPool(strategy string, maxFails int, failTimeout time.Duration) (*UpstreamPool, error) {
+	switch strategy {
+	case "":
+		strategy = StrategyRoundRobin
+	case StrategyRoundRobin, StrategyLeastOutstanding:
+	default:
+		return nil, fmt.Errorf("unknown load balancing strategy: %s", strategy)
+	}
+
+	if maxFails <= 0 {
+		maxFails = 1
+	}
+
+	return &UpstreamPool{
+		strategy:    strategy,
+		maxFails:    maxFails,
+		failTimeout: failTimeout,
+	}, nil
+}
+
+// AddUpstream adds an upstream to the pool
+func (p *UpstreamPool) AddUpstream(target *url.URL, weight int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.upstreams = append(p.upstreams, newUpstream(target, weight))
+}
+
+// SetUpstreams atomically replaces every upstream in the pool
+func (p *UpstreamPool) SetUpstreams(targets []*url.URL, weights []int) error {
+	if len(targets) != len(weights) {
+		return fmt.Errorf("got %d targets but %d weights", len(targets), len(weights))
+	}
+
+	upstreams := make([]*Upstream, len(targets))
+	for i, target := range targets {
+		upstreams[i] = newUpstream(target, weights[i])
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.upstreams = upstreams
+	return nil
+}
+
+// Upstreams returns a snapshot of every upstream's status
+func (p *UpstreamPool) Upstreams() []UpstreamStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	statuses := make([]UpstreamStatus, len(p.upstreams))
+	for i, u := range p.upstreams {
+		statuses[i] = UpstreamStatus{
+			URL:         u.URL.String(),
+			Weight:      u.Weight,
+			Healthy:     u.healthy,
+			Throttled:   now.Before(u.throttledUntil),
+			Failures:    u.failures,
+			Outstanding: u.outstanding,
+		}
+	}
+	return statuses
+}
+
+// primary returns the first upstream's URL
+func (p *UpstreamPool) primary() *url.URL {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.upstreams) == 0 {
+		return nil
+	}
+	return p.upstreams[0].URL
+}
+
+// acquire selects an upstream that is not in exclude and counts a request
+// against it. Unhealthy and throttled upstreams are only used when nothing
+// else is left.
+func (p *UpstreamPool) acquire(exclude map[*Upstream]bool) *Upstream {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	var healthy, fallback []*Upstream
+	for _, u := range p.upstreams {
+		if exclude[u] {
+			continue
+		}
+
+		// Give unhealthy upstreams another chance once the fail timeout passes
+		if !u.healthy && p.failTimeout > 0 && now.Sub(u.unhealthySince) >= p.failTimeout {
+			u.healthy = true
+			u.failures = 0
+		}
+
+		if u.healthy && !now.Before(u.throttledUntil) {
+			healthy = append(healthy, u)
+		} else {
+			fallback = append(fallback, u)
+		}
+	}
+
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = fallback
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var selected *Upstream
+	switch p.strategy {
+	case StrategyLeastOutstanding:
+		selected = leastOutstanding(candidates)
+	default:
+		selected = smoothWeightedRoundRobin(candidates)
+	}
+
+	selected.outstanding++
+	return selected
+}
+
+// hasCandidate reports whether any upstream outside exclude remains
+func (p *UpstreamPool) hasCandidate(exclude map[*Upstream]bool) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, u := range p.upstreams {
+		if !exclude[u] {
+			return true
+		}
+	}
+	return false
+}
+
+// cancel releases an upstream that was acquired but never called
+func (p *UpstreamPool) cancel(u *Upstream) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	u.outstanding--
+}
+
+// release marks a request to an upstream as finished and records its outcome
+func (p *UpstreamPool) release(u *Upstream, failed bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	u.outstanding--
+	if !failed {
+		u.failures = 0
+		return
+	}
+
+	u.failures++
+	if u.healthy && u.failures >= p.maxFails {
+		u.healthy = false
+		u.unhealthySince = time.Now()
+	}
+}
+
+// throttle avoids an upstream that rate limited a request for the wait it
+// asked for. Throttling does not count as a failure.
+func (p *UpstreamPool) throttle(u *Upstream, wait time.Duration) {
+	if wait <= 0 {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if until := time.Now().Add(wait); until.After(u.throttledUntil) {
+		u.throttledUntil = until
+	}
+}
+
+// setHealth records the result of an active health check
+func (p *UpstreamPool) setHealth(u *Upstream, healthy bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if healthy {
+		u.healthy = true
+		u.failures = 0
+		return
+	}
+
+	if u.healthy {
+		u.healthy = false
+		u.unhealthySince = time.Now()
+	}
+}
+
+// StartHealthChecks actively probes every upstream at path on each interval
+// until ctx is cancelled. Any response below 500 counts as healthy.
+func (p *UpstreamPool) StartHealthChecks(ctx context.Context, client *http.Client, path string, interval time.Duration) {
+	if client == nil {
+		client = &http.Client{Timeout: interval}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			p.CheckHealth(ctx, client, path)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// CheckHealth probes every upstream once
+func (p *UpstreamPool) CheckHealth(ctx context.Context, client *http.Client, path string) {
+	p.mutex.Lock()
+	upstreams := make([]*Upstream, len(p.upstreams))
+	copy(upstreams, p.upstreams)
+	p.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, u := range upstreams {
+		wg.Add(1)
+		go func(u *Upstream) {
+			defer wg.Done()
+			p.setHealth(u, probe(ctx, client, u.URL, path))
+		}(u)
+	}
+	wg.Wait()
+}
+
+// probe performs a single health check request
+func probe(ctx context.Context, client *http.Client, target *url.URL, path string) bool {
+	checkURL := *target
+	checkURL.Path = singleJoiningSlash(target.Path, path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkURL.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// newUpstream creates a healthy upstream with a weight of at least one
+func newUpstream(target *url.URL, weight int) *Upstream {
+	if weight <= 0 {
+		weight = 1
+	}
+	return &Upstream{
+		URL:     target,
+		Weight:  weight,
+		healthy: true,
+	}
+}
+
+// smoothWeightedRoundRobin picks an upstream so that, over time, each one
+// is selected in proportion to its weight without bursts
+func smoothWeightedRoundRobin(candidates []*Upstream) *Upstream {
+	total := 0
+	var best *Upstream
+	for _, u := range candidates {
+		u.currentWeight += u.Weight
+		total += u.Weight
+		if best == nil || u.currentWeight > best.currentWeight {
+			best = u
+		}
+	}
+	best.currentWeight -= total
+	return best
+}
+
+// leastOutstanding picks the upstream with the fewest in-flight requests
+// relative to its weight
+func leastOutstanding(candidates []*Upstream) *Upstream {
+	best := candidates[0]
+	for _, u := range candidates[1:] {
+		// Compare outstanding/weight without floating point
+		if u.outstanding*best.Weight < best.outstanding*u.Weight {
+			best = u
+		}
+	}
+	return best
+}
+
+// singleJoiningSlash joins two URL paths with exactly one slash
+func singleJoiningSlash(a, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
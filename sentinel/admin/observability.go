@@ -31,6 +31,7 @@ type ObservabilityManager struct {
 	cryptoOperationTimer metric.Float64Histogram
 	detectionTimer       metric.Float64Histogram
 	redactionTimer       metric.Float64Histogram
+	circuitStateCounter  metric.Int64Counter
 }
 
 // NewObservabilityManager creates a new observability manager
@@ -94,6 +95,11 @@ func NewObservabilityManager() (*ObservabilityManager, error) {
 		return nil, fmt.Errorf("failed to create redaction timer: %w", err)
 	}
 
+	circuitStateCounter, err := meter.Int64Counter("sentinel.circuit_breaker.transitions", metric.WithDescription("Total number of circuit breaker state changes"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create circuit state counter: %w", err)
+	}
+
 	return &ObservabilityManager{
 		tracerProvider:       tracerProvider,
 		meterProvider:        meterProvider,
@@ -106,6 +112,7 @@ func NewObservabilityManager() (*ObservabilityManager, error) {
 		cryptoOperationTimer: cryptoOperationTimer,
 		detectionTimer:       detectionTimer,
 		redactionTimer:       redactionTimer,
+		circuitStateCounter:  circuitStateCounter,
 	}, nil
 }
 
@@ -180,6 +187,25 @@ func (o *ObservabilityManager) RecordViolation(ctx context.Context, violationTyp
 	o.violationCounter.Add(ctx, 1, metric.WithAttributes(additionalAttrs...))
 }
 
+// RecordCircuitStateChange records a circuit breaker state change
+func (o *ObservabilityManager) RecordCircuitStateChange(ctx context.Context, name, from, to string) {
+	o.circuitStateCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("breaker", name),
+		attribute.String("from", from),
+		attribute.String("to", to),
+	))
+
+	level := "INFO"
+	if to == "open" {
+		level = "WARN"
+	}
+	o.LogEvent(level, "Circuit breaker state changed", map[string]interface{}{
+		"breaker": name,
+		"from":    from,
+		"to":      to,
+	})
+}
+
 // Shutdown cleans up resources
 func (o *ObservabilityManager) Shutdown(ctx context.Context) error {
 	if err := o.tracerProvider.Shutdown(ctx); err != nil {
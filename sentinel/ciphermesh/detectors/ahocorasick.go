@@ -0,0 +1,103 @@
+package detectors
+
+// ahoCorasick finds every occurrence of a set of literals in a single pass
+// over the text. Matching folds ASCII case, so the literal "akia" also finds
+// "AKIA"; callers confirm candidates with a case-sensitive pattern where it
+// matters.
+type ahoCorasick struct {
+	// next is the transition table, 256 entries per state, with failure
+	// links already followed
+	next []int32
+	// outputs lists the literals that end at each state
+	outputs [][]int
+	lengths []int
+}
+
+// newAhoCorasick builds a matcher for literals. Empty literals never match.
+func newAhoCorasick(literals []string) *ahoCorasick {
+	ac := &ahoCorasick{
+		next:    make([]int32, 256),
+		outputs: [][]int{nil},
+		lengths: make([]int, len(literals)),
+	}
+
+	// Build the trie; 0 is both the root and "no transition yet"
+	for i, literal := range literals {
+		ac.lengths[i] = len(literal)
+		if literal == "" {
+			continue
+		}
+
+		state := int32(0)
+		for j := 0; j < len(literal); j++ {
+			c := foldASCII(literal[j])
+			if ac.next[int(state)*256+int(c)] == 0 {
+				ac.next = append(ac.next, make([]int32, 256)...)
+				ac.outputs = append(ac.outputs, nil)
+				ac.next[int(state)*256+int(c)] = int32(len(ac.outputs) - 1)
+			}
+			state = ac.next[int(state)*256+int(c)]
+		}
+		ac.outputs[state] = append(ac.outputs[state], i)
+	}
+
+	// Breadth-first, point missing transitions at the failure state's and
+	// inherit its outputs
+	fail := make([]int32, len(ac.outputs))
+	var queue []int32
+	for c := 0; c < 256; c++ {
+		if child := ac.next[c]; child != 0 {
+			queue = append(queue, child)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		ac.outputs[state] = append(ac.outputs[state], ac.outputs[fail[state]]...)
+
+		for c := 0; c < 256; c++ {
+			child := ac.next[int(state)*256+c]
+			fallback := ac.next[int(fail[state])*256+c]
+			if child == 0 {
+				ac.next[int(state)*256+c] = fallback
+				continue
+			}
+			fail[child] = fallback
+			queue = append(queue, child)
+		}
+	}
+
+	return ac
+}
+
+// findAll calls found with the index and start offset of every literal
+// occurrence in text, in order of where the occurrences end
+func (ac *ahoCorasick) findAll(text string, found func(literal, start int)) {
+	state := int32(0)
+	for i := 0; i < len(text); i++ {
+		state = ac.next[int(state)*256+int(foldASCII(text[i]))]
+		for _, literal := range ac.outputs[state] {
+			found(literal, i+1-ac.lengths[literal])
+		}
+	}
+}
+
+// contains reports whether any literal occurs in text
+func (ac *ahoCorasick) contains(text string) bool {
+	state := int32(0)
+	for i := 0; i < len(text); i++ {
+		state = ac.next[int(state)*256+int(foldASCII(text[i]))]
+		if len(ac.outputs[state]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// foldASCII lower-cases an ASCII letter
+func foldASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -2,8 +2,11 @@ package detectors
 
 import (
 	"context"
+	"fmt"
 	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // DetectionResult represents a detected sensitive data item
@@ -56,6 +59,23 @@ type RegexDetector struct {
 	pattern       *regexp.Regexp
 	confidence    float64
 	contextWindow int
+	validators    []validation
+	boundary      func(rune) bool
+	positive      []string
+	negative      []string
+	minConfidence float64
+	anchors       Anchors
+	anchored      *regexp.Regexp
+	prefilter     *ahoCorasick
+	charset       *[256]bool
+	separators    string
+	whole         *regexp.Regexp
+}
+
+// validation is a validator and the confidence of matches that fail it
+type validation struct {
+	validate         Validator
+	failedConfidence float64
 }
 
 // NewRegexDetector creates a new regex-based detector
@@ -75,17 +95,55 @@ func NewRegexDetector(name, dataType, subtype, pattern string, confidence float6
 	}, nil
 }
 
+// AddValidator checks each match with validator. Matches that fail are
+// dropped if failedConfidence is zero, and otherwise reported with at most
+// failedConfidence.
+func (rd *RegexDetector) AddValidator(validator Validator, failedConfidence float64) {
+	rd.validators = append(rd.validators, validation{validate: validator, failedConfidence: failedConfidence})
+}
+
+// SetBoundary drops matches directly preceded or followed by a rune for
+// which adjacent returns true. RE2 has no lookaround, so
+// SetBoundary(unicode.IsDigit) stands in for wrapping a pattern in (?<!\d)
+// and (?!\d).
+func (rd *RegexDetector) SetBoundary(adjacent func(rune) bool) {
+	rd.boundary = adjacent
+}
+
+// SetBacktrack retries a match that fails the boundary, validators or
+// minimum confidence with shorter candidates ending before one of the
+// separators, longest first. Greedy patterns such as card numbers otherwise
+// swallow trailing digit groups and lose the whole match.
+func (rd *RegexDetector) SetBacktrack(separators string) error {
+	whole, err := regexp.Compile(`^(?:` + rd.pattern.String() + `)$`)
+	if err != nil {
+		return fmt.Errorf("failed to anchor %s: %w", rd.name, err)
+	}
+	rd.separators = separators
+	rd.whole = whole
+	return nil
+}
+
 // Detect identifies sensitive data using regex patterns
 func (rd *RegexDetector) Detect(ctx context.Context, text string) ([]DetectionResult, error) {
+	var hits anchorHits
+	if rd.prefilter != nil {
+		hits = rd.findAnchors(text)
+	}
+	return rd.results(text, rd.spans(text, hits)), nil
+}
+
+// results turns matched spans into detection results, dropping those that
+// fail the boundary, validators or minimum confidence
+func (rd *RegexDetector) results(text string, spans [][]int) []DetectionResult {
 	var results []DetectionResult
 
-	matches := rd.pattern.FindAllStringSubmatchIndex(text, -1)
-	for _, match := range matches {
-		if len(match) < 2 {
+	for _, match := range spans {
+		start := match[0]
+		end, confidence, ok := rd.accept(text, start, match[1])
+		if !ok {
 			continue
 		}
-
-		start, end := match[0], match[1]
 		detectedText := text[start:end]
 
 		// Extract context
@@ -105,7 +163,7 @@ func (rd *RegexDetector) Detect(ctx context.Context, text string) ([]DetectionRe
 			ID:         generateID(),
 			Type:       rd.dataType,
 			Subtype:    rd.subtype,
-			Confidence: rd.confidence,
+			Confidence: confidence,
 			Start:      start,
 			End:        end,
 			Text:       detectedText,
@@ -116,7 +174,77 @@ func (rd *RegexDetector) Detect(ctx context.Context, text string) ([]DetectionRe
 		results = append(results, result)
 	}
 
-	return results, nil
+	return results
+}
+
+// accept returns the end and confidence of the match at text[start:end],
+// or with backtracking of the longest shorter candidate that passes
+func (rd *RegexDetector) accept(text string, start, end int) (int, float64, bool) {
+	if confidence, ok := rd.check(text, start, end); ok {
+		return end, confidence, true
+	}
+	if rd.whole == nil {
+		return 0, 0, false
+	}
+	for i := end - 1; i > start; i-- {
+		if !rd.isSeparator(text[i]) || rd.isSeparator(text[i-1]) || !rd.whole.MatchString(text[start:i]) {
+			continue
+		}
+		if confidence, ok := rd.check(text, start, i); ok {
+			return i, confidence, true
+		}
+	}
+	return 0, 0, false
+}
+
+// check scores the match at text[start:end], or returns false if it fails
+// the boundary, validators or minimum confidence
+func (rd *RegexDetector) check(text string, start, end int) (float64, bool) {
+	if !rd.atBoundary(text, start, end) {
+		return 0, false
+	}
+	confidence, ok := rd.validate(text[start:end])
+	if !ok {
+		return 0, false
+	}
+	confidence = rd.scoreContext(text, start, end, confidence)
+	return confidence, confidence >= rd.minConfidence
+}
+
+// isSeparator reports whether c is one of the backtracking separators
+func (rd *RegexDetector) isSeparator(c byte) bool {
+	return strings.IndexByte(rd.separators, c) >= 0
+}
+
+// atBoundary reports whether the match at text[start:end] is not directly
+// preceded or followed by a boundary rune
+func (rd *RegexDetector) atBoundary(text string, start, end int) bool {
+	if rd.boundary == nil {
+		return true
+	}
+	if before, size := utf8.DecodeLastRuneInString(text[:start]); size > 0 && rd.boundary(before) {
+		return false
+	}
+	if after, size := utf8.DecodeRuneInString(text[end:]); size > 0 && rd.boundary(after) {
+		return false
+	}
+	return true
+}
+
+// validate runs the validators on a match and returns its confidence, or
+// false if the match must be dropped
+func (rd *RegexDetector) validate(match string) (float64, bool) {
+	confidence := rd.confidence
+	for _, v := range rd.validators {
+		if v.validate(match) {
+			continue
+		}
+		if v.failedConfidence <= 0 {
+			return 0, false
+		}
+		confidence = min(confidence, v.failedConfidence)
+	}
+	return confidence, true
 }
 
 // GetType returns the type of data this detector identifies
@@ -0,0 +1,426 @@
+package detectors
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultDictionaryClass is the data class of term lists that do not set one
+const DefaultDictionaryClass = "confidential"
+
+// dictionaryConfidence is the confidence of dictionary matches. Terms are
+// listed by the tenant, so a match is almost certainly sensitive.
+const dictionaryConfidence = 0.95
+
+// dictionaryContextWindow is the number of bytes of text kept on either
+// side of a dictionary match as its context
+const dictionaryContextWindow = 50
+
+// TermList is a named list of terms a tenant wants detected, such as
+// project codenames, customer names or internal hostnames
+type TermList struct {
+	Name     string   `json:"name"`
+	DataType string   `json:"data_type"`
+	Terms    []string `json:"terms"`
+}
+
+// DictionaryDetector detects the terms of each tenant's term lists in text
+// of that tenant. Terms match whole words, ignoring case and Unicode
+// normalization form. Lists can be replaced at any time; detection uses
+// the lists current when it starts.
+type DictionaryDetector struct {
+	// lists holds each tenant's lists by name, and indexes the matcher
+	// built from them
+	lists   map[string]map[string]TermList
+	indexes map[string]*dictionaryIndex
+	// files records the term list files loaded for each tenant
+	files map[string]map[string]fileStamp
+	mutex sync.RWMutex
+	// update serializes changes, so indexes are built outside mutex
+	update sync.Mutex
+}
+
+// fileStamp identifies a version of a term list file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewDictionaryDetector creates a dictionary detector without term lists
+func NewDictionaryDetector() *DictionaryDetector {
+	return &DictionaryDetector{
+		lists:   make(map[string]map[string]TermList),
+		indexes: make(map[string]*dictionaryIndex),
+		files:   make(map[string]map[string]fileStamp),
+	}
+}
+
+// Detect finds the terms of the tenant in ctx
+func (dd *DictionaryDetector) Detect(ctx context.Context, text string) ([]DetectionResult, error) {
+	dd.mutex.RLock()
+	index := dd.indexes[TenantFromContext(ctx)]
+	dd.mutex.RUnlock()
+
+	if index == nil {
+		return nil, nil
+	}
+	return index.detect(text), nil
+}
+
+// GetType returns the default data class of dictionary matches
+func (dd *DictionaryDetector) GetType() string {
+	return DefaultDictionaryClass
+}
+
+// GetName returns the name of the detector
+func (dd *DictionaryDetector) GetName() string {
+	return "dictionary_detector"
+}
+
+// SetTermList adds a term list to a tenant, replacing any list of the
+// same name
+func (dd *DictionaryDetector) SetTermList(tenantID string, list TermList) error {
+	if list.Name == "" {
+		return fmt.Errorf("term list name is required")
+	}
+	if list.DataType == "" {
+		list.DataType = DefaultDictionaryClass
+	}
+
+	dd.update.Lock()
+	defer dd.update.Unlock()
+
+	lists := dd.tenantLists(tenantID)
+	lists[list.Name] = list
+	dd.rebuild(tenantID, lists)
+	return nil
+}
+
+// RemoveTermList removes a tenant's term list by name
+func (dd *DictionaryDetector) RemoveTermList(tenantID, name string) error {
+	dd.update.Lock()
+	defer dd.update.Unlock()
+
+	lists := dd.tenantLists(tenantID)
+	if _, ok := lists[name]; !ok {
+		return fmt.Errorf("term list %s not found for tenant %s", name, tenantID)
+	}
+	delete(lists, name)
+	dd.rebuild(tenantID, lists)
+	return nil
+}
+
+// TermLists returns a tenant's term lists sorted by name
+func (dd *DictionaryDetector) TermLists(tenantID string) []TermList {
+	dd.mutex.RLock()
+	defer dd.mutex.RUnlock()
+
+	lists := make([]TermList, 0, len(dd.lists[tenantID]))
+	for _, list := range dd.lists[tenantID] {
+		lists = append(lists, list)
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+	return lists
+}
+
+// LoadDir loads term lists from dir, which has a directory per tenant with
+// a file per list: <dir>/<tenant>/<list>.txt, one term per line. Blank
+// lines and lines starting with # are ignored. Only tenants whose files
+// changed since the last call are reloaded, so it can be called
+// periodically to pick up changes; lists whose files were removed are
+// removed too.
+func (dd *DictionaryDetector) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.txt"))
+	if err != nil {
+		return fmt.Errorf("failed to list term lists: %w", err)
+	}
+
+	found := make(map[string]map[string]fileStamp)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat term list: %w", err)
+		}
+		tenantID := filepath.Base(filepath.Dir(path))
+		if found[tenantID] == nil {
+			found[tenantID] = make(map[string]fileStamp)
+		}
+		found[tenantID][path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	dd.update.Lock()
+	defer dd.update.Unlock()
+
+	for tenantID, stamps := range found {
+		if sameStamps(stamps, dd.files[tenantID]) {
+			continue
+		}
+
+		loaded := make(map[string]TermList, len(stamps))
+		for path := range stamps {
+			list, err := readTermList(path)
+			if err != nil {
+				return err
+			}
+			loaded[list.Name] = list
+		}
+
+		lists := dd.tenantLists(tenantID)
+		for path := range dd.files[tenantID] {
+			delete(lists, listName(path))
+		}
+		for name, list := range loaded {
+			lists[name] = list
+		}
+		dd.files[tenantID] = stamps
+		dd.rebuild(tenantID, lists)
+	}
+
+	// Drop lists of tenants whose directory is gone
+	for tenantID, stamps := range dd.files {
+		if _, ok := found[tenantID]; ok {
+			continue
+		}
+		lists := dd.tenantLists(tenantID)
+		for path := range stamps {
+			delete(lists, listName(path))
+		}
+		delete(dd.files, tenantID)
+		dd.rebuild(tenantID, lists)
+	}
+
+	return nil
+}
+
+// tenantLists returns a copy of a tenant's lists to change
+func (dd *DictionaryDetector) tenantLists(tenantID string) map[string]TermList {
+	dd.mutex.RLock()
+	defer dd.mutex.RUnlock()
+
+	lists := make(map[string]TermList, len(dd.lists[tenantID]))
+	for name, list := range dd.lists[tenantID] {
+		lists[name] = list
+	}
+	return lists
+}
+
+// rebuild indexes a tenant's lists and swaps them in
+func (dd *DictionaryDetector) rebuild(tenantID string, lists map[string]TermList) {
+	var index *dictionaryIndex
+	if len(lists) > 0 {
+		index = newDictionaryIndex(lists)
+	}
+
+	dd.mutex.Lock()
+	defer dd.mutex.Unlock()
+
+	if index == nil {
+		delete(dd.lists, tenantID)
+		delete(dd.indexes, tenantID)
+		return
+	}
+	dd.lists[tenantID] = lists
+	dd.indexes[tenantID] = index
+}
+
+// readTermList reads a term list file, named after the file
+func readTermList(path string) (TermList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return TermList{}, fmt.Errorf("failed to open term list: %w", err)
+	}
+	defer file.Close()
+
+	list := TermList{Name: listName(path), DataType: DefaultDictionaryClass}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		term := strings.TrimSpace(scanner.Text())
+		if term == "" || strings.HasPrefix(term, "#") {
+			continue
+		}
+		list.Terms = append(list.Terms, term)
+	}
+	if err := scanner.Err(); err != nil {
+		return TermList{}, fmt.Errorf("failed to read term list %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// listName returns the name of the list in a term list file
+func listName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// sameStamps reports whether two sets of files are unchanged
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if other, ok := b[path]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
+
+// dictionaryIndex matches the terms of a tenant's lists. Text and terms are
+// normalized into tokens - words and single punctuation characters - joined
+// by single spaces, so a term matches whole tokens regardless of the
+// spacing between them.
+type dictionaryIndex struct {
+	// terms maps normalized terms to the index of their list in lists, and
+	// every proper token prefix of a term to -1
+	terms     map[string]int
+	lists     []TermList
+	maxTokens int
+}
+
+// newDictionaryIndex indexes the terms of lists. A term in several lists
+// is reported for the list that sorts first by name.
+func newDictionaryIndex(lists map[string]TermList) *dictionaryIndex {
+	index := &dictionaryIndex{terms: make(map[string]int)}
+	for _, list := range lists {
+		index.lists = append(index.lists, TermList{Name: list.Name, DataType: list.DataType})
+	}
+	sort.Slice(index.lists, func(i, j int) bool {
+		return index.lists[i].Name < index.lists[j].Name
+	})
+
+	for i, summary := range index.lists {
+		for _, term := range lists[summary.Name].Terms {
+			stream, tokens := tokenize(term)
+			if len(tokens) == 0 {
+				continue
+			}
+			index.maxTokens = max(index.maxTokens, len(tokens))
+
+			for j, token := range tokens {
+				key := stream[:token.end]
+				if j == len(tokens)-1 {
+					if list, ok := index.terms[key]; !ok || list < 0 {
+						index.terms[key] = i
+					}
+				} else if _, ok := index.terms[key]; !ok {
+					index.terms[key] = -1
+				}
+			}
+		}
+	}
+	return index
+}
+
+// detect returns the longest term starting at each token, leftmost first
+func (di *dictionaryIndex) detect(text string) []DetectionResult {
+	stream, tokens := tokenize(text)
+
+	var results []DetectionResult
+	for i := 0; i < len(tokens); {
+		matched, list := -1, -1
+		for j := i; j < len(tokens) && j-i < di.maxTokens; j++ {
+			found, ok := di.terms[stream[tokens[i].start:tokens[j].end]]
+			if !ok {
+				break
+			}
+			if found >= 0 {
+				matched, list = j, found
+			}
+		}
+		if matched < 0 {
+			i++
+			continue
+		}
+
+		start, end := tokens[i].textStart, tokens[matched].textEnd
+		results = append(results, DetectionResult{
+			ID:         generateID(),
+			Type:       di.lists[list].DataType,
+			Subtype:    di.lists[list].Name,
+			Confidence: dictionaryConfidence,
+			Start:      start,
+			End:        end,
+			Text:       text[start:end],
+			Context:    getContext(text, start, end, dictionaryContextWindow),
+			DetectedAt: time.Now(),
+		})
+		i = matched + 1
+	}
+	return results
+}
+
+// dictionaryToken is a word or punctuation character of normalized text.
+// start and end are its position in the normalized stream, textStart and
+// textEnd its byte span in the original text.
+type dictionaryToken struct {
+	start, end         int
+	textStart, textEnd int
+}
+
+// tokenize normalizes text to NFKC, folds its case and splits it into
+// words and punctuation characters, dropping white space. It returns the
+// tokens joined by single spaces and their positions.
+func tokenize(text string) (string, []dictionaryToken) {
+	var stream strings.Builder
+	stream.Grow(len(text))
+	tokens := make([]dictionaryToken, 0, len(text)/4)
+	folder := cases.Fold()
+	inWord := false
+
+	var iter norm.Iter
+	iter.InitString(norm.NFKC, text)
+	for !iter.Done() {
+		segStart := iter.Pos()
+		segment := iter.Next()
+		segEnd := iter.Pos()
+		if len(segment) == 0 {
+			continue
+		}
+		if len(segment) > 1 || segment[0] >= utf8.RuneSelf {
+			segment = folder.Bytes(segment)
+		} else if c := segment[0]; c >= 'A' && c <= 'Z' {
+			segment = []byte{c + 'a' - 'A'}
+		}
+
+		for len(segment) > 0 {
+			r, size := utf8.DecodeRune(segment)
+			segment = segment[size:]
+
+			word := isWordRune(r) || unicode.Is(unicode.Mn, r)
+			switch {
+			case unicode.IsSpace(r):
+				inWord = false
+				continue
+			case word && inWord:
+				// Continue the current word
+				stream.WriteRune(r)
+				last := &tokens[len(tokens)-1]
+				last.end, last.textEnd = stream.Len(), segEnd
+				continue
+			}
+
+			if stream.Len() > 0 {
+				stream.WriteByte(' ')
+			}
+			start := stream.Len()
+			stream.WriteRune(r)
+			tokens = append(tokens, dictionaryToken{start: start, end: stream.Len(), textStart: segStart, textEnd: segEnd})
+			inWord = word
+		}
+	}
+
+	return stream.String(), tokens
+}
@@ -0,0 +1,197 @@
+package detectors
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Anchors are literals that every match of a pattern contains, so text
+// without them need not be scanned. Literals are matched ignoring ASCII case.
+type Anchors struct {
+	// Prefixes start every match, so the pattern is only tried where one
+	// occurs. The pattern must not look behind its start, e.g. with \b.
+	Prefixes []string
+	// Required are literals of which at least one occurs in every match.
+	// Text without any of them is skipped.
+	Required []string
+	// Charset lists every ASCII character a match can contain, so the
+	// pattern is only tried on runs of them with at least MinDigits digits
+	// and one of the required literals. The pattern must not depend on text
+	// outside the run, e.g. with \b.
+	Charset   string
+	MinDigits int
+}
+
+// anchorHits records where a prefilter found a detector's anchors
+type anchorHits struct {
+	starts   []int
+	required bool
+}
+
+// SetAnchors prefilters the detector's pattern with literals found in every
+// match. With prefixes, the pattern is only tried where a prefix occurs.
+func (rd *RegexDetector) SetAnchors(anchors Anchors) error {
+	anchored, err := regexp.Compile(`^(?:` + rd.pattern.String() + `)`)
+	if err != nil {
+		return fmt.Errorf("failed to anchor %s: %w", rd.name, err)
+	}
+
+	rd.anchors = anchors
+	rd.anchored = anchored
+	rd.prefilter = newAhoCorasick(append(append([]string(nil), anchors.Prefixes...), anchors.Required...))
+	rd.charset = nil
+	if anchors.Charset != "" {
+		rd.charset = new([256]bool)
+		for i := 0; i < len(anchors.Charset); i++ {
+			rd.charset[anchors.Charset[i]] = true
+		}
+	}
+	return nil
+}
+
+// findAnchors runs the detector's own prefilter over text
+func (rd *RegexDetector) findAnchors(text string) anchorHits {
+	var hits anchorHits
+	rd.prefilter.findAll(text, func(literal, start int) {
+		if literal < len(rd.anchors.Prefixes) {
+			hits.starts = append(hits.starts, start)
+		} else {
+			hits.required = true
+		}
+	})
+	return hits
+}
+
+// spans returns the spans of text the pattern matches, given the anchors a
+// prefilter found, or by scanning the whole text if it has no anchors
+func (rd *RegexDetector) spans(text string, hits anchorHits) [][]int {
+	if rd.anchored == nil {
+		return rd.pattern.FindAllStringIndex(text, -1)
+	}
+	if len(rd.anchors.Required) > 0 && !hits.required {
+		return nil
+	}
+	if len(rd.anchors.Prefixes) == 0 {
+		if rd.charset != nil {
+			return rd.runSpans(text)
+		}
+		return rd.pattern.FindAllStringIndex(text, -1)
+	}
+
+	// Try the pattern at each prefix, skipping those inside an earlier
+	// match as FindAll would
+	sort.Ints(hits.starts)
+	var spans [][]int
+	end := 0
+	for i, start := range hits.starts {
+		if start < end || (i > 0 && start == hits.starts[i-1]) {
+			continue
+		}
+		if loc := rd.anchored.FindStringIndex(text[start:]); loc != nil && loc[1] > 0 {
+			spans = append(spans, []int{start, start + loc[1]})
+			end = start + loc[1]
+		}
+	}
+	return spans
+}
+
+// runSpans tries the pattern on each run of charset characters with enough
+// digits and a required literal. Runs are short, so this avoids running the pattern over prose.
+func (rd *RegexDetector) runSpans(text string) [][]int {
+	var spans [][]int
+	for i := 0; i < len(text); {
+		if !rd.charset[text[i]] {
+			i++
+			continue
+		}
+
+		start, digits := i, 0
+		for ; i < len(text) && rd.charset[text[i]]; i++ {
+			if text[i] >= '0' && text[i] <= '9' {
+				digits++
+			}
+		}
+		if digits < rd.anchors.MinDigits {
+			continue
+		}
+		if len(rd.anchors.Required) > 0 && !rd.prefilter.contains(text[start:i]) {
+			continue
+		}
+
+		for _, loc := range rd.pattern.FindAllStringIndex(text[start:i], -1) {
+			spans = append(spans, []int{start + loc[0], start + loc[1]})
+		}
+	}
+	return spans
+}
+
+// PatternEngine runs a set of regex detectors together: one Aho-Corasick
+// pass finds the anchors of every anchored detector, and their patterns are
+// only tried where an anchor occurs or on runs of their charset. Detectors
+// without anchors scan the whole text.
+type PatternEngine struct {
+	detectors []*RegexDetector
+	prefilter *ahoCorasick
+	// literals maps each prefilter literal to its detector and whether it
+	// is a prefix
+	literals []engineLiteral
+}
+
+// engineLiteral identifies the detector an anchor literal belongs to
+type engineLiteral struct {
+	detector int
+	prefix   bool
+}
+
+// NewPatternEngine compiles detectors into an engine. Anchors must be set
+// on the detectors before.
+func NewPatternEngine(detectors ...*RegexDetector) *PatternEngine {
+	engine := &PatternEngine{detectors: detectors}
+
+	var literals []string
+	for i, detector := range detectors {
+		for _, prefix := range detector.anchors.Prefixes {
+			literals = append(literals, prefix)
+			engine.literals = append(engine.literals, engineLiteral{detector: i, prefix: true})
+		}
+		for _, required := range detector.anchors.Required {
+			literals = append(literals, required)
+			engine.literals = append(engine.literals, engineLiteral{detector: i})
+		}
+	}
+	engine.prefilter = newAhoCorasick(literals)
+
+	return engine
+}
+
+// Detect runs every detector over text and returns their results in
+// detector order
+func (e *PatternEngine) Detect(ctx context.Context, text string) ([]DetectionResult, error) {
+	var results []DetectionResult
+	for _, detected := range e.detectEach(text) {
+		results = append(results, detected...)
+	}
+	return results, nil
+}
+
+// detectEach returns the results of each detector, indexed like the
+// engine's detectors
+func (e *PatternEngine) detectEach(text string) [][]DetectionResult {
+	hits := make([]anchorHits, len(e.detectors))
+	e.prefilter.findAll(text, func(literal, start int) {
+		l := e.literals[literal]
+		if l.prefix {
+			hits[l.detector].starts = append(hits[l.detector].starts, start)
+		} else {
+			hits[l.detector].required = true
+		}
+	})
+
+	results := make([][]DetectionResult, len(e.detectors))
+	for i, detector := range e.detectors {
+		results[i] = detector.results(text, detector.spans(text, hits[i]))
+	}
+	return results
+}
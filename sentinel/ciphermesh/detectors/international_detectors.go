@@ -0,0 +1,210 @@
+package detectors
+
+import "unicode"
+
+// InternationalPacks are the detector packs for national identifiers
+// outside the US, by name
+var InternationalPacks = map[string]func() ([]Detector, error){
+	"iban": IBANDetectors,
+	"uk":   UKDetectors,
+	"de":   GermanDetectors,
+	"fr":   FrenchDetectors,
+	"es":   SpanishDetectors,
+	"in":   IndianDetectors,
+	"ca":   CanadianDetectors,
+	"au":   AustralianDetectors,
+	"sg":   SingaporeDetectors,
+}
+
+// LanguagePacks lists the international packs loaded for each language in
+// ciphermesh.detectors.languages: the identifiers of countries where the
+// language is used in official documents
+var LanguagePacks = map[string][]string{
+	"en": {"iban", "uk", "ca", "au", "sg", "in"},
+	"de": {"iban", "de"},
+	"fr": {"iban", "fr", "ca"},
+	"es": {"iban", "es"},
+	"hi": {"in"},
+}
+
+// IBANDetectors returns a detector for International Bank Account Numbers,
+// printed with or without spaces between groups of four. Words and numbers
+// after the IBAN, such as a currency or amount, are backtracked over.
+func IBANDetectors() ([]Detector, error) {
+	return newDetectorPack(detectorSpec{
+		name:       "iban_detector",
+		dataType:   "pii",
+		subtype:    "iban",
+		pattern:    `[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?`,
+		confidence: 0.9,
+		window:     40,
+		boundary:   isWordRune,
+		anchors:    Anchors{Charset: upperChars + digitChars + " ", MinDigits: 2},
+		validator:  ValidateIBAN,
+		keywords:   []string{"iban", "account", "bank", "konto", "compte", "cuenta"},
+		backtrack:  " ",
+	})
+}
+
+// UKDetectors returns detectors for UK National Insurance and NHS numbers
+func UKDetectors() ([]Detector, error) {
+	return newDetectorPack(
+		detectorSpec{
+			name:       "uk_nino_detector",
+			dataType:   "pii",
+			subtype:    "uk_nino",
+			pattern:    `[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]`,
+			confidence: 0.85,
+			window:     40,
+			boundary:   isWordRune,
+			anchors:    Anchors{Charset: upperChars + digitChars + " ", MinDigits: 6},
+			validator:  ValidateNINO,
+			keywords:   []string{"national insurance", "nino", "ni number"},
+		},
+		detectorSpec{
+			name:       "uk_nhs_detector",
+			dataType:   "phi",
+			subtype:    "uk_nhs_number",
+			pattern:    `\d{3}[ -]?\d{3}[ -]?\d{4}`,
+			confidence: 0.7,
+			window:     40,
+			boundary:   unicode.IsDigit,
+			anchors:    Anchors{Charset: digitChars + " -", MinDigits: 10},
+			validator:  ValidateNHS,
+			keywords:   []string{"nhs", "nhs number", "patient"},
+		},
+	)
+}
+
+// GermanDetectors returns a detector for German tax identification numbers
+// (Steuer-ID)
+func GermanDetectors() ([]Detector, error) {
+	return newDetectorPack(detectorSpec{
+		name:       "de_steuer_id_detector",
+		dataType:   "pii",
+		subtype:    "de_steuer_id",
+		pattern:    `[1-9]\d(?: ?\d{3}){3}`,
+		confidence: 0.75,
+		window:     40,
+		boundary:   unicode.IsDigit,
+		anchors:    Anchors{Charset: digitChars + " ", MinDigits: 11},
+		validator:  ValidateSteuerID,
+		keywords:   []string{"steuer-id", "steueridentifikationsnummer", "identifikationsnummer", "idnr", "tax id"},
+	})
+}
+
+// FrenchDetectors returns a detector for French social security numbers
+// (INSEE/NIR)
+func FrenchDetectors() ([]Detector, error) {
+	return newDetectorPack(detectorSpec{
+		name:       "fr_insee_detector",
+		dataType:   "pii",
+		subtype:    "fr_insee",
+		pattern:    `[12] ?\d{2} ?\d{2} ?(?:\d{2}|2[AB]) ?\d{3} ?\d{3} ?\d{2}`,
+		confidence: 0.9,
+		window:     40,
+		boundary:   isWordRune,
+		anchors:    Anchors{Charset: digitChars + "AB ", MinDigits: 13},
+		validator:  ValidateINSEE,
+		keywords:   []string{"insee", "nir", "sécurité sociale", "securite sociale"},
+	})
+}
+
+// SpanishDetectors returns a detector for Spanish DNI and NIE numbers
+func SpanishDetectors() ([]Detector, error) {
+	return newDetectorPack(detectorSpec{
+		name:       "es_dni_detector",
+		dataType:   "pii",
+		subtype:    "es_dni",
+		pattern:    `(?:\d{8}|[XYZ]-?\d{7})-?[A-Z]`,
+		confidence: 0.9,
+		window:     40,
+		boundary:   isWordRune,
+		anchors:    Anchors{Charset: upperChars + digitChars + "-", MinDigits: 7},
+		validator:  ValidateDNI,
+		keywords:   []string{"dni", "nie", "nif", "documento"},
+	})
+}
+
+// IndianDetectors returns detectors for Aadhaar numbers and PANs
+func IndianDetectors() ([]Detector, error) {
+	return newDetectorPack(
+		detectorSpec{
+			name:       "in_aadhaar_detector",
+			dataType:   "pii",
+			subtype:    "in_aadhaar",
+			pattern:    `[2-9]\d{3}[ -]?\d{4}[ -]?\d{4}`,
+			confidence: 0.8,
+			window:     40,
+			boundary:   unicode.IsDigit,
+			anchors:    Anchors{Charset: digitChars + " -", MinDigits: 12},
+			validator:  ValidateAadhaar,
+			keywords:   []string{"aadhaar", "aadhar", "uidai", "uid"},
+		},
+		// The fourth letter of a PAN is the holder type; its check letter
+		// is not published, so PANs are matched on shape alone
+		detectorSpec{
+			name:       "in_pan_detector",
+			dataType:   "pii",
+			subtype:    "in_pan",
+			pattern:    `[A-Z]{3}[ABCFGHJLPT][A-Z]\d{4}[A-Z]`,
+			confidence: 0.85,
+			window:     40,
+			boundary:   isWordRune,
+			anchors:    Anchors{Charset: upperChars + digitChars, MinDigits: 4},
+			keywords:   []string{"pan", "permanent account number", "income tax"},
+		},
+	)
+}
+
+// CanadianDetectors returns a detector for Canadian Social Insurance Numbers
+func CanadianDetectors() ([]Detector, error) {
+	return newDetectorPack(detectorSpec{
+		name:       "ca_sin_detector",
+		dataType:   "pii",
+		subtype:    "ca_sin",
+		pattern:    `\d{3}[ -]?\d{3}[ -]?\d{3}`,
+		confidence: 0.6,
+		window:     40,
+		boundary:   unicode.IsDigit,
+		anchors:    Anchors{Charset: digitChars + " -", MinDigits: 9},
+		validator:  ValidateSIN,
+		keywords:   []string{"sin", "social insurance", "nas", "assurance sociale"},
+		// One in ten nine-digit numbers passes Luhn
+		minConfidence: 0.65,
+	})
+}
+
+// AustralianDetectors returns a detector for Australian Tax File Numbers
+func AustralianDetectors() ([]Detector, error) {
+	return newDetectorPack(detectorSpec{
+		name:       "au_tfn_detector",
+		dataType:   "pii",
+		subtype:    "au_tfn",
+		pattern:    `\d{3} ?\d{3} ?\d{2,3}`,
+		confidence: 0.6,
+		window:     40,
+		boundary:   unicode.IsDigit,
+		anchors:    Anchors{Charset: digitChars + " ", MinDigits: 8},
+		validator:  ValidateTFN,
+		keywords:   []string{"tfn", "tax file number"},
+		// One in eleven numbers passes the check
+		minConfidence: 0.65,
+	})
+}
+
+// SingaporeDetectors returns a detector for Singapore NRIC and FIN numbers
+func SingaporeDetectors() ([]Detector, error) {
+	return newDetectorPack(detectorSpec{
+		name:       "sg_nric_detector",
+		dataType:   "pii",
+		subtype:    "sg_nric",
+		pattern:    `[STFGM]\d{7}[A-Z]`,
+		confidence: 0.9,
+		window:     40,
+		boundary:   isWordRune,
+		anchors:    Anchors{Charset: upperChars + digitChars, MinDigits: 7},
+		validator:  ValidateNRIC,
+		keywords:   []string{"nric", "fin", "identity card"},
+	})
+}
@@ -0,0 +1,131 @@
+package detectors
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Largest confidence adjustments for a context keyword next to a match.
+// Keywords further away within the context window count for less.
+const (
+	contextBoost   = 0.3
+	contextPenalty = 0.3
+)
+
+// SetContextKeywords adjusts confidence by the keywords around each match:
+// positive keywords such as "card" raise it and negative ones such as
+// "order" lower it, the more the closer they are. Keywords are matched as
+// whole words, ignoring case, within the detector's context window.
+func (rd *RegexDetector) SetContextKeywords(positive, negative []string) {
+	rd.positive = lowerAll(positive)
+	rd.negative = lowerAll(negative)
+}
+
+// SetMinConfidence drops matches whose confidence, after validation and
+// context keywords, is below min
+func (rd *RegexDetector) SetMinConfidence(min float64) {
+	rd.minConfidence = min
+}
+
+// scoreContext applies the context keywords around text[start:end] to confidence
+func (rd *RegexDetector) scoreContext(text string, start, end int, confidence float64) float64 {
+	if rd.contextWindow <= 0 || (len(rd.positive) == 0 && len(rd.negative) == 0) {
+		return confidence
+	}
+
+	before := strings.ToLower(text[max(0, start-rd.contextWindow):start])
+	after := strings.ToLower(text[end:min(len(text), end+rd.contextWindow)])
+
+	if distance, ok := nearestKeyword(before, after, rd.positive); ok {
+		confidence += contextBoost * rd.proximity(distance)
+	}
+	if distance, ok := nearestKeyword(before, after, rd.negative); ok {
+		confidence -= contextPenalty * rd.proximity(distance)
+	}
+	return min(1, max(0, confidence))
+}
+
+// proximity weighs a keyword by its distance from the match: 1 when it is
+// adjacent, falling to 0.5 at the edge of the context window
+func (rd *RegexDetector) proximity(distance int) float64 {
+	return 1 - 0.5*float64(distance)/float64(rd.contextWindow)
+}
+
+// nearestKeyword returns the distance in bytes between the match and the
+// closest keyword in the text before or after it
+func nearestKeyword(before, after string, keywords []string) (int, bool) {
+	nearest, found := 0, false
+	for _, keyword := range keywords {
+		if i := lastWord(before, keyword); i >= 0 {
+			if distance := len(before) - i - len(keyword); !found || distance < nearest {
+				nearest, found = distance, true
+			}
+		}
+		if i := firstWord(after, keyword); i >= 0 {
+			if !found || i < nearest {
+				nearest, found = i, true
+			}
+		}
+	}
+	return nearest, found
+}
+
+// firstWord returns the offset of the first whole-word occurrence of word
+// in text, or -1
+func firstWord(text, word string) int {
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return -1
+		}
+		if i += offset; isWholeWord(text, i, i+len(word)) {
+			return i
+		}
+		offset = i + 1
+	}
+	return -1
+}
+
+// lastWord returns the offset of the last whole-word occurrence of word in
+// text, or -1
+func lastWord(text, word string) int {
+	for limit := len(text); limit > 0; {
+		i := strings.LastIndex(text[:limit], word)
+		if i < 0 {
+			return -1
+		}
+		if isWholeWord(text, i, i+len(word)) {
+			return i
+		}
+		limit = i + len(word) - 1
+	}
+	return -1
+}
+
+// isWholeWord reports whether text[start:end] is not part of a longer word
+func isWholeWord(text string, start, end int) bool {
+	if before, size := utf8.DecodeLastRuneInString(text[:start]); size > 0 && isWordRune(before) {
+		return false
+	}
+	if after, size := utf8.DecodeRuneInString(text[end:]); size > 0 && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// lowerAll returns the non-empty keywords in lower case
+func lowerAll(keywords []string) []string {
+	lowered := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword != "" {
+			lowered = append(lowered, strings.ToLower(keyword))
+		}
+	}
+	return lowered
+}
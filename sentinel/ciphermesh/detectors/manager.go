@@ -3,29 +3,53 @@ package detectors
 import (
 	"context"
 	"fmt"
-	"sort"
 	"sync"
 )
 
 // DetectorManager coordinates multiple detectors
 type DetectorManager struct {
 	detectors []Detector
-	mutex     sync.RWMutex
+	resolver  *resolver
+	// engine runs the regex detectors in one pass; engineIndex maps its
+	// detectors to their positions in detectors
+	engine      *PatternEngine
+	engineIndex []int
+	mutex       sync.RWMutex
 }
 
-// NewDetectorManager creates a new detector manager
+// NewDetectorManager creates a new detector manager. Overlapping
+// detections are resolved by confidence until SetResolution is called.
 func NewDetectorManager() *DetectorManager {
+	resolver, _ := newResolver(ResolveByConfidence, nil)
 	return &DetectorManager{
 		detectors: make([]Detector, 0),
+		resolver:  resolver,
 	}
 }
 
+// SetResolution sets how overlapping detections are resolved. priority
+// ranks data classes for ResolveByPriority and breaks ties for the other
+// strategies; nil uses DefaultClassPriority.
+func (dm *DetectorManager) SetResolution(strategy ResolutionStrategy, priority []string) error {
+	resolver, err := newResolver(strategy, priority)
+	if err != nil {
+		return err
+	}
+
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+
+	dm.resolver = resolver
+	return nil
+}
+
 // AddDetector adds a detector to the manager
 func (dm *DetectorManager) AddDetector(detector Detector) {
 	dm.mutex.Lock()
 	defer dm.mutex.Unlock()
 
 	dm.detectors = append(dm.detectors, detector)
+	dm.compile()
 }
 
 // RemoveDetector removes a detector from the manager by name
@@ -37,6 +61,7 @@ func (dm *DetectorManager) RemoveDetector(name string) error {
 		if detector.GetName() == name {
 			// Remove the detector by slicing
 			dm.detectors = append(dm.detectors[:i], dm.detectors[i+1:]...)
+			dm.compile()
 			return nil
 		}
 	}
@@ -56,30 +81,55 @@ func (dm *DetectorManager) GetDetectors() []Detector {
 	return detectors
 }
 
-// Detect runs all detectors on the provided text
+// Detect runs all detectors on the provided text and returns detections
+// that do not overlap, sorted by position
 func (dm *DetectorManager) Detect(ctx context.Context, text string) ([]DetectionResult, error) {
+	resolution, err := dm.DetectWithAlternatives(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return resolution.Results, nil
+}
+
+// DetectWithAlternatives runs all detectors on the provided text and
+// resolves overlapping detections, returning the ones that were suppressed
+// for auditing
+func (dm *DetectorManager) DetectWithAlternatives(ctx context.Context, text string) (*Resolution, error) {
 	dm.mutex.RLock()
 	defer dm.mutex.RUnlock()
 
-	var allResults []DetectionResult
-
-	// Run each detector concurrently
+	// Run detectors concurrently
 	type detectorResult struct {
+		index   int
 		results []DetectionResult
 		err     error
 	}
 
 	resultChan := make(chan detectorResult, len(dm.detectors))
 
-	// Launch goroutines for each detector
-	for _, detector := range dm.detectors {
-		go func(d Detector) {
+	// Regex detectors share a single pass of the pattern engine
+	if dm.engine != nil {
+		go func() {
+			for i, results := range dm.engine.detectEach(text) {
+				resultChan <- detectorResult{index: dm.engineIndex[i], results: results}
+			}
+		}()
+	}
+
+	// Launch goroutines for each other detector
+	for i, detector := range dm.detectors {
+		if _, ok := detector.(*RegexDetector); ok {
+			continue
+		}
+		go func(i int, d Detector) {
 			results, err := d.Detect(ctx, text)
-			resultChan <- detectorResult{results: results, err: err}
-		}(detector)
+			resultChan <- detectorResult{index: i, results: results, err: err}
+		}(i, detector)
 	}
 
-	// Collect results
+	// Collect results in detector order, so ties resolve the same way on
+	// every call
+	byDetector := make([][]DetectionResult, len(dm.detectors))
 	for i := 0; i < len(dm.detectors); i++ {
 		result := <-resultChan
 		if result.err != nil {
@@ -88,21 +138,16 @@ func (dm *DetectorManager) Detect(ctx context.Context, text string) ([]Detection
 			continue
 		}
 
-		allResults = append(allResults, result.results...)
+		byDetector[result.index] = result.results
 	}
 
-	// Sort results by position for consistent ordering
-	sortDetectionResults(allResults)
-
-	return allResults, nil
-}
+	var allResults []DetectionResult
+	for _, results := range byDetector {
+		allResults = append(allResults, results...)
+	}
 
-// sortDetectionResults sorts detection results by their start position
-func sortDetectionResults(results []DetectionResult) {
-	// Use standard library sort
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Start < results[j].Start
-	})
+	resolution := dm.resolver.resolve(text, allResults)
+	return &resolution, nil
 }
 
 // GetDetectorsByType returns detectors filtered by type
@@ -126,4 +171,23 @@ func (dm *DetectorManager) ClearDetectors() {
 	defer dm.mutex.Unlock()
 
 	dm.detectors = make([]Detector, 0)
+	dm.compile()
+}
+
+// compile rebuilds the pattern engine from the regex detectors. Callers
+// must hold the write lock.
+func (dm *DetectorManager) compile() {
+	var regexDetectors []*RegexDetector
+	dm.engineIndex = dm.engineIndex[:0]
+	for i, detector := range dm.detectors {
+		if rd, ok := detector.(*RegexDetector); ok {
+			regexDetectors = append(regexDetectors, rd)
+			dm.engineIndex = append(dm.engineIndex, i)
+		}
+	}
+
+	dm.engine = nil
+	if len(regexDetectors) > 0 {
+		dm.engine = NewPatternEngine(regexDetectors...)
+	}
 }
@@ -2,8 +2,66 @@ package detectors
 
 import (
 	"fmt"
+	"unicode"
 )
 
+// Character sets the numeric patterns match, used to only try them on runs
+// of these characters
+const (
+	digitChars  = "0123456789"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	letterChars = upperChars + "abcdefghijklmnopqrstuvwxyz"
+)
+
+// detectorSpec describes a regex detector of a pack
+type detectorSpec struct {
+	name       string
+	dataType   string
+	subtype    string
+	pattern    string
+	confidence float64
+	window     int
+	// boundary rejects matches next to these runes
+	boundary  func(rune) bool
+	anchors   Anchors
+	validator Validator
+	keywords  []string
+	// minConfidence, if set, drops matches without a nearby keyword
+	minConfidence float64
+	// backtrack, if set, shortens rejected matches at these separators
+	backtrack string
+}
+
+// newDetectorPack creates the detectors for specs. Matches that fail their
+// validator are dropped.
+func newDetectorPack(specs ...detectorSpec) ([]Detector, error) {
+	var detectors []Detector
+	for _, spec := range specs {
+		detector, err := NewRegexDetector(spec.name, spec.dataType, spec.subtype, spec.pattern, spec.confidence, spec.window)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", spec.name, err)
+		}
+		detector.SetBoundary(spec.boundary)
+		if err := detector.SetAnchors(spec.anchors); err != nil {
+			return nil, err
+		}
+		if spec.backtrack != "" {
+			if err := detector.SetBacktrack(spec.backtrack); err != nil {
+				return nil, err
+			}
+		}
+		if spec.validator != nil {
+			detector.AddValidator(spec.validator, 0)
+		}
+		detector.SetContextKeywords(spec.keywords, nil)
+		if spec.minConfidence > 0 {
+			detector.SetMinConfidence(spec.minConfidence)
+		}
+		detectors = append(detectors, detector)
+	}
+	return detectors, nil
+}
+
 // CommonRegexDetectors returns a set of common regex-based detectors
 func CommonRegexDetectors() ([]Detector, error) {
 	var detectors []Detector
@@ -13,13 +71,19 @@ func CommonRegexDetectors() ([]Detector, error) {
 		"ssn_detector",
 		"pii",
 		"ssn",
-		`(?<!\d)(?!000|666|9\d{2})\d{3}-(?!00)\d{2}-(?!0000)\d{4}(?!\d)`,
+		`\d{3}-\d{2}-\d{4}`,
 		0.95,
 		50,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SSN detector: %w", err)
 	}
+	ssnDetector.SetBoundary(unicode.IsDigit)
+	if err := ssnDetector.SetAnchors(Anchors{Charset: digitChars + "-", MinDigits: 9}); err != nil {
+		return nil, err
+	}
+	ssnDetector.AddValidator(ValidateSSN, 0)
+	ssnDetector.SetContextKeywords([]string{"ssn", "social security", "tax id"}, nil)
 	detectors = append(detectors, ssnDetector)
 
 	// Credit Card detector
@@ -27,13 +91,29 @@ func CommonRegexDetectors() ([]Detector, error) {
 		"credit_card_detector",
 		"pci",
 		"credit_card",
-		`(?<!\d)(?:\d[ -]*?){13,19}\d(?!\d)`,
+		`\d(?:[ -]?\d){11,18}`,
 		0.90,
 		50,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create credit card detector: %w", err)
 	}
+	// Order IDs and timestamps rarely pass Luhn; numbers that do but belong
+	// to no card brand are kept with lower confidence. Digit groups after
+	// the card number, such as an expiry date, are backtracked over.
+	ccDetector.SetBoundary(unicode.IsDigit)
+	if err := ccDetector.SetAnchors(Anchors{Charset: digitChars + " -", MinDigits: 12}); err != nil {
+		return nil, err
+	}
+	if err := ccDetector.SetBacktrack(" -"); err != nil {
+		return nil, err
+	}
+	ccDetector.AddValidator(ValidateLuhn, 0)
+	ccDetector.AddValidator(ValidateCardIIN, 0.5)
+	ccDetector.SetContextKeywords(
+		[]string{"card", "credit", "debit", "payment", "transaction"},
+		[]string{"order", "tracking", "invoice"},
+	)
 	detectors = append(detectors, ccDetector)
 
 	// Email detector
@@ -48,6 +128,10 @@ func CommonRegexDetectors() ([]Detector, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create email detector: %w", err)
 	}
+	if err := emailDetector.SetAnchors(Anchors{Required: []string{"@"}, Charset: letterChars + digitChars + "._%+-@"}); err != nil {
+		return nil, err
+	}
+	emailDetector.SetContextKeywords([]string{"email", "e-mail", "contact"}, nil)
 	detectors = append(detectors, emailDetector)
 
 	// Phone number detector
@@ -62,20 +146,33 @@ func CommonRegexDetectors() ([]Detector, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create phone detector: %w", err)
 	}
+	if err := phoneDetector.SetAnchors(Anchors{Charset: digitChars + "+(). -\t\n\f\r", MinDigits: 10}); err != nil {
+		return nil, err
+	}
+	phoneDetector.SetContextKeywords([]string{"phone", "telephone", "mobile", "cell", "call"}, nil)
 	detectors = append(detectors, phoneDetector)
 
-	// Driver's License detector (simplified)
+	// Driver's License detector. The pattern matches part numbers and model
+	// names too, so matches need a nearby keyword to be reported.
 	dlDetector, err := NewRegexDetector(
 		"drivers_license_detector",
 		"pii",
 		"drivers_license",
 		`[A-Z]\d{3,12}`,
-		0.75,
+		0.45,
 		40,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create driver's license detector: %w", err)
 	}
+	if err := dlDetector.SetAnchors(Anchors{Charset: upperChars + digitChars, MinDigits: 3}); err != nil {
+		return nil, err
+	}
+	dlDetector.SetContextKeywords(
+		[]string{"driver", "license", "licence", "dl"},
+		[]string{"model", "part", "sku", "version", "serial"},
+	)
+	dlDetector.SetMinConfidence(0.6)
 	detectors = append(detectors, dlDetector)
 
 	return detectors, nil
@@ -90,13 +187,21 @@ func USFinancialDetectors() ([]Detector, error) {
 		"bank_account_detector",
 		"pii",
 		"bank_account",
-		`(?<!\d)\d{10,17}(?!\d)`,
+		`\d{10,17}`,
 		0.85,
 		40,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bank account detector: %w", err)
 	}
+	bankDetector.SetBoundary(unicode.IsDigit)
+	if err := bankDetector.SetAnchors(Anchors{Charset: digitChars, MinDigits: 10}); err != nil {
+		return nil, err
+	}
+	bankDetector.SetContextKeywords(
+		[]string{"account", "acct", "bank", "checking", "savings"},
+		[]string{"order", "tracking", "phone"},
+	)
 	detectors = append(detectors, bankDetector)
 
 	// Routing Number detector
@@ -104,13 +209,19 @@ func USFinancialDetectors() ([]Detector, error) {
 		"routing_number_detector",
 		"pii",
 		"routing_number",
-		`(?<!\d)\d{9}(?!\d)`,
+		`\d{9}`,
 		0.90,
 		40,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create routing number detector: %w", err)
 	}
+	routingDetector.SetBoundary(unicode.IsDigit)
+	if err := routingDetector.SetAnchors(Anchors{Charset: digitChars, MinDigits: 9}); err != nil {
+		return nil, err
+	}
+	routingDetector.AddValidator(ValidateABARouting, 0)
+	routingDetector.SetContextKeywords([]string{"routing", "aba", "transit", "wire"}, nil)
 	detectors = append(detectors, routingDetector)
 
 	return detectors, nil
@@ -120,32 +231,49 @@ func USFinancialDetectors() ([]Detector, error) {
 func MedicalDetectors() ([]Detector, error) {
 	var detectors []Detector
 
-	// Medical Record Number detector
+	// Medical Record Number detector. Like driver's licenses, matches need a
+	// nearby keyword to be reported.
 	mrnDetector, err := NewRegexDetector(
 		"mrn_detector",
 		"phi",
 		"medical_record_number",
 		`[A-Z]{2,3}\d{6,10}`,
-		0.85,
+		0.5,
 		40,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create MRN detector: %w", err)
 	}
+	if err := mrnDetector.SetAnchors(Anchors{Charset: upperChars + digitChars, MinDigits: 6}); err != nil {
+		return nil, err
+	}
+	mrnDetector.SetContextKeywords(
+		[]string{"medical record", "patient", "mrn", "chart"},
+		[]string{"model", "part", "sku", "serial"},
+	)
+	mrnDetector.SetMinConfidence(0.7)
 	detectors = append(detectors, mrnDetector)
 
-	// Health Plan Beneficiary Number detector
+	// Health Plan Beneficiary Number detector, also reported only near a keyword
 	hpbDetector, err := NewRegexDetector(
 		"hpbn_detector",
 		"phi",
 		"health_plan_beneficiary",
 		`\d{8,12}`,
-		0.80,
+		0.45,
 		40,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HPBN detector: %w", err)
 	}
+	if err := hpbDetector.SetAnchors(Anchors{Charset: digitChars, MinDigits: 8}); err != nil {
+		return nil, err
+	}
+	hpbDetector.SetContextKeywords(
+		[]string{"health plan", "beneficiary", "insurance", "member", "policy"},
+		[]string{"order", "phone", "tracking", "invoice"},
+	)
+	hpbDetector.SetMinConfidence(0.65)
 	detectors = append(detectors, hpbDetector)
 
 	return detectors, nil
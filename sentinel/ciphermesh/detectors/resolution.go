@@ -0,0 +1,138 @@
+package detectors
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ResolutionStrategy chooses which of several overlapping detections is kept
+type ResolutionStrategy string
+
+// Overlap resolution strategies. Each falls back to the others to break ties.
+const (
+	// ResolveByPriority keeps the detection whose data class ranks first
+	ResolveByPriority ResolutionStrategy = "priority"
+	// ResolveByConfidence keeps the most confident detection
+	ResolveByConfidence ResolutionStrategy = "confidence"
+	// ResolveByLength keeps the detection with the longest span
+	ResolveByLength ResolutionStrategy = "longest"
+)
+
+// DefaultClassPriority ranks data classes for ResolveByPriority, most
+// sensitive first. Unlisted classes rank last.
+var DefaultClassPriority = []string{"credentials", "confidential", "pci", "phi", "pii", "generic"}
+
+// Resolution is the outcome of overlap resolution: detections that do not
+// overlap, sorted by position, and the alternatives they suppressed
+type Resolution struct {
+	Results    []DetectionResult
+	Suppressed []SuppressedResult
+}
+
+// SuppressedResult is a detection that lost to an overlapping one. Winner
+// is the index of that detection in Resolution.Results.
+type SuppressedResult struct {
+	DetectionResult
+	Winner int `json:"winner"`
+}
+
+// resolver resolves overlapping detections with a strategy
+type resolver struct {
+	strategy ResolutionStrategy
+	rank     map[string]int
+}
+
+// newResolver creates a resolver ranking data classes in priority order
+func newResolver(strategy ResolutionStrategy, priority []string) (*resolver, error) {
+	switch strategy {
+	case "":
+		strategy = ResolveByConfidence
+	case ResolveByPriority, ResolveByConfidence, ResolveByLength:
+	default:
+		return nil, fmt.Errorf("unknown resolution strategy: %s", strategy)
+	}
+
+	if priority == nil {
+		priority = DefaultClassPriority
+	}
+	rank := make(map[string]int, len(priority))
+	for i, class := range priority {
+		if _, ok := rank[class]; !ok {
+			rank[class] = i
+		}
+	}
+
+	return &resolver{strategy: strategy, rank: rank}, nil
+}
+
+// resolve groups detections whose spans overlap or touch, keeps the best of
+// each group and widens it to cover the whole group, so no part of any
+// detection is left unredacted. Ties keep the detection that comes first in
+// results.
+func (r *resolver) resolve(text string, results []DetectionResult) Resolution {
+	sorted := make([]DetectionResult, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	resolution := Resolution{Results: make([]DetectionResult, 0, len(sorted))}
+	for i := 0; i < len(sorted); {
+		// Collect the group of detections that overlap or touch
+		group, end := i, sorted[i].End
+		for i++; i < len(sorted) && sorted[i].Start <= end; i++ {
+			end = max(end, sorted[i].End)
+		}
+
+		best := group
+		for j := group + 1; j < i; j++ {
+			if r.better(sorted[j], sorted[best]) {
+				best = j
+			}
+		}
+
+		winner := len(resolution.Results)
+		kept := sorted[best]
+		kept.Start, kept.End = sorted[group].Start, end
+		kept.Text = text[kept.Start:kept.End]
+		resolution.Results = append(resolution.Results, kept)
+
+		for j := group; j < i; j++ {
+			if j != best {
+				resolution.Suppressed = append(resolution.Suppressed, SuppressedResult{DetectionResult: sorted[j], Winner: winner})
+			}
+		}
+	}
+	return resolution
+}
+
+// better reports whether a should be kept over b
+func (r *resolver) better(a, b DetectionResult) bool {
+	priority := r.classRank(b.Type) - r.classRank(a.Type)
+	confidence := a.Confidence - b.Confidence
+	length := (a.End - a.Start) - (b.End - b.Start)
+
+	var order [3]float64
+	switch r.strategy {
+	case ResolveByPriority:
+		order = [3]float64{float64(priority), confidence, float64(length)}
+	case ResolveByLength:
+		order = [3]float64{float64(length), confidence, float64(priority)}
+	default:
+		order = [3]float64{confidence, float64(length), float64(priority)}
+	}
+	for _, difference := range order {
+		if difference != 0 {
+			return difference > 0
+		}
+	}
+	return false
+}
+
+// classRank returns a data class's position in the priority order
+func (r *resolver) classRank(class string) int {
+	if rank, ok := r.rank[class]; ok {
+		return rank
+	}
+	return len(r.rank)
+}
@@ -0,0 +1,218 @@
+package detectors
+
+import (
+	"context"
+	"unicode"
+)
+
+// restrictedZIPPrefixes are the three-digit ZIP prefixes whose areas had
+// 20,000 or fewer people in the 2000 census. Safe Harbor requires ZIP codes
+// in them to be removed entirely.
+var restrictedZIPPrefixes = map[string]bool{
+	"036": true, "059": true, "063": true, "102": true, "203": true, "556": true,
+	"692": true, "790": true, "821": true, "823": true, "830": true, "831": true,
+	"878": true, "879": true, "884": true, "890": true, "893": true,
+}
+
+// zipCodeDetector reports the part of each ZIP code Safe Harbor requires to
+// be removed: the first three digits may be kept unless they are restricted
+type zipCodeDetector struct {
+	*RegexDetector
+}
+
+// Detect finds ZIP codes and trims the first three digits where allowed
+func (zd zipCodeDetector) Detect(ctx context.Context, text string) ([]DetectionResult, error) {
+	results, err := zd.RegexDetector.Detect(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		if !restrictedZIPPrefixes[results[i].Text[:3]] {
+			results[i].Start += 3
+			results[i].Text = results[i].Text[3:]
+			results[i].Context = getContext(text, results[i].Start, results[i].End, zd.contextWindow)
+		}
+	}
+	return results, nil
+}
+
+// SafeHarborDetectors returns detectors for the HIPAA Safe Harbor
+// identifiers not covered by the common, US financial and medical packs:
+// dates and ages over 89, addresses and ZIP codes, device, vehicle and
+// provider identifiers, URLs and IP addresses, and diagnosis and drug
+// codes in clinical context. Names and biometric identifiers cannot be
+// matched by pattern.
+func SafeHarborDetectors() ([]Detector, error) {
+	detectors, err := newDetectorPack(
+		// Dates related to an individual. Years alone are allowed.
+		detectorSpec{
+			name:     "date_detector",
+			dataType: "phi",
+			subtype:  "date",
+			pattern: `\d{4}-\d{2}-\d{2}|\d{1,2}[/-]\d{1,2}[/-](?:\d{4}|\d{2})|` +
+				`(?i:(?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.? \d{1,2}(?:st|nd|rd|th)?,? \d{4})|` +
+				`(?i:\d{1,2}(?:st|nd|rd|th)? (?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?,? \d{4})`,
+			confidence: 0.7,
+			window:     40,
+			boundary:   isWordRune,
+			validator:  ValidateDate,
+			keywords:   []string{"dob", "birth", "born", "admitted", "admission", "discharged", "discharge", "died", "death", "visit"},
+		},
+		// Ages over 89 must be aggregated, so only ages near a keyword count
+		detectorSpec{
+			name:          "age_over_89_detector",
+			dataType:      "phi",
+			subtype:       "age",
+			pattern:       `9\d|1[01]\d`,
+			confidence:    0.3,
+			window:        20,
+			boundary:      unicode.IsDigit,
+			anchors:       Anchors{Charset: digitChars, MinDigits: 2},
+			keywords:      []string{"age", "aged", "years old", "year old", "year-old", "years-old", "yo", "y/o", "yrs"},
+			minConfidence: 0.5,
+		},
+		detectorSpec{
+			name:       "street_address_detector",
+			dataType:   "phi",
+			subtype:    "street_address",
+			pattern:    `\d{1,5}(?: [A-Z][a-z]+){1,3} (?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl)\.?`,
+			confidence: 0.8,
+			window:     30,
+			boundary:   isWordRune,
+			keywords:   []string{"address", "lives", "resides"},
+		},
+		detectorSpec{
+			name:          "device_serial_detector",
+			dataType:      "phi",
+			subtype:       "device_identifier",
+			pattern:       `[A-Z0-9][A-Z0-9-]{4,}[A-Z0-9]`,
+			confidence:    0.3,
+			window:        30,
+			boundary:      func(r rune) bool { return isWordRune(r) || r == '-' },
+			anchors:       Anchors{Charset: upperChars + digitChars + "-", MinDigits: 1},
+			validator:     containsDigit,
+			keywords:      []string{"serial", "serial number", "s/n", "sn", "device", "implant", "pacemaker", "pump", "udi", "lot"},
+			minConfidence: 0.55,
+		},
+		detectorSpec{
+			name:       "vin_detector",
+			dataType:   "phi",
+			subtype:    "vehicle_identifier",
+			pattern:    `[A-HJ-NPR-Z0-9]{17}`,
+			confidence: 0.85,
+			window:     30,
+			boundary:   isWordRune,
+			anchors:    Anchors{Charset: upperChars + digitChars, MinDigits: 1},
+			validator:  ValidateVIN,
+			keywords:   []string{"vin", "vehicle"},
+		},
+		// Trailing punctuation is left out, as URLs usually end sentences
+		detectorSpec{
+			name:       "url_detector",
+			dataType:   "phi",
+			subtype:    "url",
+			pattern:    `(?i:https?://|www\.)[^\s<>"']*[^\s<>"'.,;:!?)\]]`,
+			confidence: 0.8,
+			window:     30,
+			anchors:    Anchors{Prefixes: []string{"http", "www."}},
+		},
+		detectorSpec{
+			name:       "ipv4_detector",
+			dataType:   "phi",
+			subtype:    "ip_address",
+			pattern:    `\d{1,3}(?:\.\d{1,3}){3}`,
+			confidence: 0.8,
+			window:     30,
+			boundary:   unicode.IsDigit,
+			anchors:    Anchors{Charset: digitChars + ".", MinDigits: 4},
+			validator:  ValidateIPv4,
+			keywords:   []string{"ip", "address", "host"},
+		},
+		detectorSpec{
+			name:       "ipv6_detector",
+			dataType:   "phi",
+			subtype:    "ip_address",
+			pattern:    `[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`,
+			confidence: 0.8,
+			window:     30,
+			boundary:   func(r rune) bool { return isWordRune(r) || r == ':' },
+			anchors:    Anchors{Required: []string{":"}, Charset: digitChars + "abcdefABCDEF:", MinDigits: 1},
+			validator:  ValidateIPv6,
+			keywords:   []string{"ip", "address", "host"},
+		},
+		// Provider identifiers: NPIs are ten digits that one in ten numbers
+		// passes, so they need a keyword
+		detectorSpec{
+			name:          "npi_detector",
+			dataType:      "phi",
+			subtype:       "npi",
+			pattern:       `[12]\d{9}`,
+			confidence:    0.6,
+			window:        40,
+			boundary:      unicode.IsDigit,
+			anchors:       Anchors{Charset: digitChars, MinDigits: 10},
+			validator:     ValidateNPI,
+			keywords:      []string{"npi", "provider", "prescriber", "physician"},
+			minConfidence: 0.65,
+		},
+		detectorSpec{
+			name:       "dea_detector",
+			dataType:   "phi",
+			subtype:    "dea_number",
+			pattern:    `[A-Z][A-Z9]\d{7}`,
+			confidence: 0.7,
+			window:     40,
+			boundary:   isWordRune,
+			anchors:    Anchors{Charset: upperChars + digitChars, MinDigits: 7},
+			validator:  ValidateDEA,
+			keywords:   []string{"dea", "prescriber", "registration"},
+		},
+		// Diagnosis and drug codes reveal conditions, but look like other
+		// codes, so they are only reported in clinical context
+		detectorSpec{
+			name:          "icd10_detector",
+			dataType:      "phi",
+			subtype:       "icd10_code",
+			pattern:       `[A-TV-Z]\d[0-9A-Z](?:\.[0-9A-Z]{1,4})?`,
+			confidence:    0.3,
+			window:        30,
+			boundary:      isWordRune,
+			anchors:       Anchors{Charset: upperChars + digitChars + ".", MinDigits: 1},
+			keywords:      []string{"icd", "icd-10", "icd10", "diagnosis", "diagnosed", "dx"},
+			minConfidence: 0.55,
+		},
+		detectorSpec{
+			name:          "ndc_detector",
+			dataType:      "phi",
+			subtype:       "ndc_code",
+			pattern:       `\d{4}-\d{4}-\d{2}|\d{5}-\d{3}-\d{2}|\d{5}-\d{4}-\d{1,2}`,
+			confidence:    0.4,
+			window:        30,
+			boundary:      func(r rune) bool { return unicode.IsDigit(r) || r == '-' },
+			anchors:       Anchors{Charset: digitChars + "-", MinDigits: 10},
+			keywords:      []string{"ndc", "drug", "medication", "rx", "prescribed", "dispensed"},
+			minConfidence: 0.6,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	zip, err := newDetectorPack(detectorSpec{
+		name:          "zip_code_detector",
+		dataType:      "phi",
+		subtype:       "zip_code",
+		pattern:       `\d{5}(?:-\d{4})?`,
+		confidence:    0.4,
+		window:        40,
+		boundary:      unicode.IsDigit,
+		anchors:       Anchors{Charset: digitChars + "-", MinDigits: 5},
+		keywords:      []string{"zip", "zip code", "zipcode", "postal code", "address", "lives", "resides"},
+		minConfidence: 0.6,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(detectors, zipCodeDetector{zip[0].(*RegexDetector)}), nil
+}
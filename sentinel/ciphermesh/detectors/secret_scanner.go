@@ -3,6 +3,7 @@ package detectors
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"math"
 	"strings"
 	"time"
@@ -14,15 +15,48 @@ type SecretScannerDetector struct {
 	name       string
 	dataType   string
 	confidence float64
+	patterns   *PatternEngine
 }
 
-// NewSecretScannerDetector creates a new secret scanner detector
+// secretPatterns are common secret formats and the literals that start them
+var secretPatterns = []struct {
+	subtype  string
+	pattern  string
+	prefixes []string
+}{
+	{"api_key", `(?i)(api[_-]?key|apikey)["']?\s*[:=]\s*["']?[a-zA-Z0-9_\-]{32,}["']?`, []string{"api"}},
+	{"password", `(?:password|passwd|pwd)["']?\s*[:=]\s*["']?[a-zA-Z0-9!@#$%^&*()_+\-=\[\]{};':"\\|,.<>\/?]{8,}["']?`, []string{"password", "passwd", "pwd"}},
+	{"secret", `(?:secret|token)["']?\s*[:=]\s*["']?[a-zA-Z0-9_\-]{32,}["']?`, []string{"secret", "token"}},
+	{"private_key", `-----BEGIN[ A-Z]* PRIVATE KEY-----`, []string{"-----BEGIN"}},
+	{"aws_key", `AKIA[0-9A-Z]{16}`, []string{"AKIA"}},
+	{"github_token", `ghp_[a-zA-Z0-9]{36}`, []string{"ghp_"}},
+	{"slack_token", `xox[baprs]-[0-9a-zA-Z]{10,48}`, []string{"xox"}},
+}
+
+// NewSecretScannerDetector creates a new secret scanner detector. Its
+// patterns are compiled once, into an engine that only tries each where
+// its prefix occurs.
 func NewSecretScannerDetector() *SecretScannerDetector {
-	return &SecretScannerDetector{
+	ssd := &SecretScannerDetector{
 		name:       "secret_scanner",
 		dataType:   "credentials",
 		confidence: 0.95,
 	}
+
+	detectors := make([]*RegexDetector, 0, len(secretPatterns))
+	for _, p := range secretPatterns {
+		detector, err := NewRegexDetector("secret_"+p.subtype, ssd.dataType, p.subtype, p.pattern, ssd.confidence, 50)
+		if err != nil {
+			panic(fmt.Sprintf("invalid secret pattern %s: %v", p.subtype, err))
+		}
+		if err := detector.SetAnchors(Anchors{Prefixes: p.prefixes}); err != nil {
+			panic(fmt.Sprintf("invalid secret pattern %s: %v", p.subtype, err))
+		}
+		detectors = append(detectors, detector)
+	}
+	ssd.patterns = NewPatternEngine(detectors...)
+
+	return ssd
 }
 
 // Detect identifies potential secrets in the provided text
@@ -88,39 +122,7 @@ func (ssd *SecretScannerDetector) detectHighEntropyStrings(text string) []Detect
 
 // detectSecretPatterns finds common secret patterns
 func (ssd *SecretScannerDetector) detectSecretPatterns(text string) []DetectionResult {
-	var results []DetectionResult
-
-	// Common secret patterns
-	patterns := []struct {
-		subtype string
-		pattern string
-	}{
-		{"api_key", `(?i)(api[_-]?key|apikey)["']?\s*[:=]\s*["']?[a-zA-Z0-9_\-]{32,}["']?`},
-		{"password", `(?:password|passwd|pwd)["']?\s*[:=]\s*["']?[a-zA-Z0-9!@#$%^&*()_+\-=\[\]{};':"\\|,.<>\/?]{8,}["']?`},
-		{"secret", `(?:secret|token)["']?\s*[:=]\s*["']?[a-zA-Z0-9_\-]{32,}["']?`},
-		{"private_key", `-----BEGIN[ A-Z]* PRIVATE KEY-----`},
-		{"aws_key", `AKIA[0-9A-Z]{16}`},
-		{"github_token", `ghp_[a-zA-Z0-9]{36}`},
-		{"slack_token", `xox[baprs]-[0-9a-zA-Z]{10,48}`},
-	}
-
-	for _, p := range patterns {
-		detector, err := NewRegexDetector(
-			"secret_"+p.subtype,
-			ssd.dataType,
-			p.subtype,
-			p.pattern,
-			ssd.confidence,
-			50,
-		)
-		if err != nil {
-			continue
-		}
-
-		patternResults, _ := detector.Detect(context.Background(), text)
-		results = append(results, patternResults...)
-	}
-
+	results, _ := ssd.patterns.Detect(context.Background(), text)
 	return results
 }
 
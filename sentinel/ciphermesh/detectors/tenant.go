@@ -0,0 +1,46 @@
+package detectors
+
+import "context"
+
+// tenantKey is the context key for the tenant a text belongs to
+type tenantKey struct{}
+
+// WithTenant returns a context for detecting in text of a tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set with WithTenant, or ""
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
+
+// tenantDetector runs a detector only on text of some tenants
+type tenantDetector struct {
+	Detector
+	tenants map[string]bool
+}
+
+// ForTenants restricts detectors to text of the given tenants, as set with
+// WithTenant
+func ForTenants(tenants []string, detectors ...Detector) []Detector {
+	allowed := make(map[string]bool, len(tenants))
+	for _, tenantID := range tenants {
+		allowed[tenantID] = true
+	}
+
+	scoped := make([]Detector, len(detectors))
+	for i, detector := range detectors {
+		scoped[i] = &tenantDetector{Detector: detector, tenants: allowed}
+	}
+	return scoped
+}
+
+// Detect runs the detector if text belongs to one of its tenants
+func (td *tenantDetector) Detect(ctx context.Context, text string) ([]DetectionResult, error) {
+	if !td.tenants[TenantFromContext(ctx)] {
+		return nil, nil
+	}
+	return td.Detector.Detect(ctx, text)
+}
@@ -0,0 +1,563 @@
+package detectors
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/sentinel-platform/sentinel/sentinel/crypto/fpe"
+)
+
+// Validator checks a regex match beyond its shape, such as a checksum, and
+// reports whether it is valid
+type Validator func(match string) bool
+
+// cardRange is a range of issuer identification numbers (IINs) assigned to
+// a card brand and the PAN lengths the brand issues
+type cardRange struct {
+	brand   string
+	low     int
+	high    int
+	lengths []int
+}
+
+// cardRanges lists IIN ranges by their leading digits. Ranges are compared
+// on as many digits as low has.
+var cardRanges = []cardRange{
+	{"visa", 4, 4, []int{13, 16, 19}},
+	{"mastercard", 51, 55, []int{16}},
+	{"mastercard", 2221, 2720, []int{16}},
+	{"amex", 34, 34, []int{15}},
+	{"amex", 37, 37, []int{15}},
+	{"discover", 6011, 6011, []int{16, 17, 18, 19}},
+	{"discover", 644, 649, []int{16, 17, 18, 19}},
+	{"discover", 65, 65, []int{16, 17, 18, 19}},
+	{"diners", 300, 305, []int{14, 15, 16, 17, 18, 19}},
+	{"diners", 36, 36, []int{14, 15, 16, 17, 18, 19}},
+	{"diners", 38, 39, []int{16, 17, 18, 19}},
+	{"jcb", 3528, 3589, []int{16, 17, 18, 19}},
+	{"unionpay", 62, 62, []int{16, 17, 18, 19}},
+	{"maestro", 5018, 5018, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{"maestro", 5020, 5020, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{"maestro", 5038, 5038, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{"maestro", 6304, 6304, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+	{"maestro", 6759, 6759, []int{12, 13, 14, 15, 16, 17, 18, 19}},
+}
+
+// ValidateLuhn reports whether a card number of 12 to 19 digits passes the
+// Luhn check. Spaces and dashes between digits are ignored.
+func ValidateLuhn(match string) bool {
+	number := digitsOf(match)
+	return len(number) >= 12 && len(number) <= 19 && fpe.LuhnCheck(number)
+}
+
+// ValidateCardIIN reports whether a card number starts with an IIN assigned
+// to a known card brand and has a length that brand issues
+func ValidateCardIIN(match string) bool {
+	return CardBrand(match) != ""
+}
+
+// CardBrand returns the brand whose IIN range a card number falls in, or ""
+// if there is none
+func CardBrand(match string) string {
+	number := digitsOf(match)
+	for _, r := range cardRanges {
+		width := len(strconv.Itoa(r.low))
+		if len(number) < width {
+			continue
+		}
+		prefix, _ := strconv.Atoi(number[:width])
+		if prefix < r.low || prefix > r.high {
+			continue
+		}
+		for _, length := range r.lengths {
+			if len(number) == length {
+				return r.brand
+			}
+		}
+	}
+	return ""
+}
+
+// ValidateABARouting reports whether a nine-digit number is a valid ABA
+// routing number: its prefix must be a Federal Reserve routing symbol and
+// its weighted 3-7-1 checksum a multiple of ten
+func ValidateABARouting(match string) bool {
+	number := digitsOf(match)
+	if len(number) != 9 {
+		return false
+	}
+
+	// 00 is the US government, 01-12 and 21-32 Federal Reserve districts,
+	// 61-72 thrifts and 80 traveller's cheques
+	prefix, _ := strconv.Atoi(number[:2])
+	if !(prefix <= 12 || (prefix >= 21 && prefix <= 32) || (prefix >= 61 && prefix <= 72) || prefix == 80) {
+		return false
+	}
+
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := range number {
+		sum += int(number[i]-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
+
+// ValidateSSN reports whether a number follows the SSA's assignment rules:
+// area 000, 666 and 900-999, group 00 and serial 0000 are never issued
+func ValidateSSN(match string) bool {
+	number := digitsOf(match)
+	if len(number) != 9 {
+		return false
+	}
+
+	area, _ := strconv.Atoi(number[:3])
+	group, _ := strconv.Atoi(number[3:5])
+	serial, _ := strconv.Atoi(number[5:])
+	return area != 0 && area != 666 && area < 900 && group != 0 && serial != 0
+}
+
+// ibanLengths is the IBAN length of each country that issues them
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16,
+	"BG": 22, "BH": 22, "BR": 29, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IS": 26, "IT": 27,
+	"JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MR": 27, "MT": 31,
+	"MU": 30, "NL": 18, "NO": 15, "PK": 24, "PL": 28, "PS": 29, "PT": 25,
+	"QA": 29, "RO": 24, "RS": 22, "SA": 24, "SE": 24, "SI": 19, "SK": 24,
+	"SM": 27, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// ValidateIBAN reports whether an IBAN has its country's length and passes
+// the ISO 7064 mod-97 check. Spaces are ignored.
+func ValidateIBAN(match string) bool {
+	iban := compact(match)
+	if len(iban) < 4 || ibanLengths[iban[:2]] != len(iban) {
+		return false
+	}
+
+	// Move the country code and check digits to the end and read letters
+	// as 10 to 35
+	remainder := 0
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// ValidateNINO reports whether a UK National Insurance number has a prefix
+// HMRC issues. NINOs have no check digit.
+func ValidateNINO(match string) bool {
+	nino := compact(match)
+	if len(nino) != 9 {
+		return false
+	}
+	switch nino[:2] {
+	case "BG", "GB", "KN", "NK", "NT", "TN", "ZZ":
+		return false
+	}
+	return true
+}
+
+// ValidateNHS reports whether a ten-digit NHS number passes the mod-11
+// check: its first nine digits, weighted 10 down to 2, determine the last
+func ValidateNHS(match string) bool {
+	number := digitsOf(match)
+	if len(number) != 10 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(number[i]-'0') * (10 - i)
+	}
+	check := 11 - sum%11
+	if check == 11 {
+		check = 0
+	}
+	return check != 10 && check == int(number[9]-'0')
+}
+
+// ValidateSteuerID reports whether an eleven-digit German tax identification
+// number is well formed: it does not start with 0, exactly one digit of the
+// first ten occurs two or three times (three not in a row), and it passes
+// the ISO 7064 MOD 11,10 check
+func ValidateSteuerID(match string) bool {
+	number := digitsOf(match)
+	if len(number) != 11 || number[0] == '0' {
+		return false
+	}
+
+	var counts [10]int
+	for i := 0; i < 10; i++ {
+		counts[number[i]-'0']++
+	}
+	repeated := -1
+	for digit, count := range counts {
+		if count <= 1 {
+			continue
+		}
+		if repeated >= 0 || count > 3 {
+			return false
+		}
+		repeated = digit
+	}
+	if repeated < 0 {
+		return false
+	}
+	if counts[repeated] == 3 {
+		run := strings.Repeat(string(rune('0'+repeated)), 3)
+		if strings.Contains(number[:10], run) {
+			return false
+		}
+	}
+
+	product := 10
+	for i := 0; i < 10; i++ {
+		sum := (int(number[i]-'0') + product) % 10
+		if sum == 0 {
+			sum = 10
+		}
+		product = sum * 2 % 11
+	}
+	check := 11 - product
+	if check == 10 {
+		check = 0
+	}
+	return check == int(number[10]-'0')
+}
+
+// ValidateINSEE reports whether a French social security number (NIR) has
+// a valid sex digit and month and a key of 97 minus the number modulo 97.
+// Corsican departments 2A and 2B count as 19 and 18.
+func ValidateINSEE(match string) bool {
+	nir := compact(match)
+	if len(nir) != 15 || (nir[0] != '1' && nir[0] != '2') {
+		return false
+	}
+
+	// Months 20 and up are used when the birth month is unknown
+	month, err := strconv.Atoi(nir[3:5])
+	if err != nil || month == 0 || (month > 12 && month < 20) {
+		return false
+	}
+
+	body := nir[:13]
+	switch nir[5:7] {
+	case "2A":
+		body = nir[:5] + "19" + nir[7:13]
+	case "2B":
+		body = nir[:5] + "18" + nir[7:13]
+	}
+	number, err := strconv.ParseInt(body, 10, 64)
+	if err != nil {
+		return false
+	}
+	key, err := strconv.Atoi(nir[13:])
+	if err != nil {
+		return false
+	}
+	return key == int(97-number%97)
+}
+
+// dniLetters are the check letters of Spanish DNI and NIE numbers, indexed
+// by the number modulo 23
+const dniLetters = "TRWAGMYFPDXBNJZSQVHLCKE"
+
+// ValidateDNI reports whether a Spanish DNI, or an NIE starting with X, Y
+// or Z, ends in the check letter for its number
+func ValidateDNI(match string) bool {
+	id := compact(match)
+	if len(id) != 9 {
+		return false
+	}
+
+	// NIE prefixes stand for a leading 0, 1 or 2
+	if prefix := strings.IndexByte("XYZ", id[0]); prefix >= 0 {
+		id = strconv.Itoa(prefix) + id[1:]
+	}
+	number, err := strconv.Atoi(id[:8])
+	if err != nil {
+		return false
+	}
+	return id[8] == dniLetters[number%23]
+}
+
+// verhoeffMultiply and verhoeffPermute are the dihedral group D5
+// multiplication table and the position permutations of the Verhoeff
+// algorithm
+var (
+	verhoeffMultiply = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermute = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// ValidateAadhaar reports whether a twelve-digit Aadhaar number does not
+// start with 0 or 1 and passes the Verhoeff check
+func ValidateAadhaar(match string) bool {
+	number := digitsOf(match)
+	if len(number) != 12 || number[0] < '2' {
+		return false
+	}
+
+	check := 0
+	for i := 0; i < len(number); i++ {
+		digit := int(number[len(number)-1-i] - '0')
+		check = verhoeffMultiply[check][verhoeffPermute[i%8][digit]]
+	}
+	return check == 0
+}
+
+// ValidateSIN reports whether a nine-digit Canadian Social Insurance Number
+// passes the Luhn check. SINs starting with 0 or 8 are never issued.
+func ValidateSIN(match string) bool {
+	number := digitsOf(match)
+	return len(number) == 9 && number[0] != '0' && number[0] != '8' && fpe.LuhnCheck(number)
+}
+
+// ValidateTFN reports whether an eight- or nine-digit Australian Tax File
+// Number has a weighted sum divisible by 11
+func ValidateTFN(match string) bool {
+	number := digitsOf(match)
+
+	var weights []int
+	switch len(number) {
+	case 8:
+		weights = []int{10, 7, 8, 4, 6, 3, 5, 1}
+	case 9:
+		weights = []int{1, 4, 3, 7, 5, 8, 6, 9, 10}
+	default:
+		return false
+	}
+
+	sum := 0
+	for i, weight := range weights {
+		sum += int(number[i]-'0') * weight
+	}
+	return sum%11 == 0
+}
+
+// NRIC and FIN check letters, indexed by the weighted sum modulo 11
+const (
+	nricLetters = "JZIHGFEDCBA"
+	finLetters  = "XWUTRQPNMLK"
+	// M series FINs, issued from 2022
+	finMLetters = "XWUTRQPNJLK"
+)
+
+// ValidateNRIC reports whether a Singapore NRIC or FIN ends in the check
+// letter for its series and digits
+func ValidateNRIC(match string) bool {
+	id := compact(match)
+	if len(id) != 9 {
+		return false
+	}
+
+	weights := [7]int{2, 7, 6, 5, 4, 3, 2}
+	sum := 0
+	for i, weight := range weights {
+		c := id[i+1]
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += int(c-'0') * weight
+	}
+
+	// Series issued from 2000 are offset by 4, M by 3
+	var letters string
+	switch id[0] {
+	case 'S':
+		letters = nricLetters
+	case 'T':
+		sum, letters = sum+4, nricLetters
+	case 'F':
+		letters = finLetters
+	case 'G':
+		sum, letters = sum+4, finLetters
+	case 'M':
+		sum, letters = sum+3, finMLetters
+	default:
+		return false
+	}
+	return id[8] == letters[sum%11]
+}
+
+// ValidateNPI reports whether a ten-digit National Provider Identifier
+// starts with 1 or 2 and passes the Luhn check with the 80840 prefix of
+// US health industry card numbers
+func ValidateNPI(match string) bool {
+	number := digitsOf(match)
+	return len(number) == 10 && (number[0] == '1' || number[0] == '2') && fpe.LuhnCheck("80840"+number)
+}
+
+// deaRegistrants are the first letters of DEA numbers, by registrant type
+const deaRegistrants = "ABCDEFGHJKLMPRSTUX"
+
+// ValidateDEA reports whether a DEA registration number has a registrant
+// type letter, a second letter or 9, and a check digit equal to the last
+// digit of the odd digits plus twice the even digits
+func ValidateDEA(match string) bool {
+	dea := compact(match)
+	if len(dea) != 9 || !strings.ContainsRune(deaRegistrants, rune(dea[0])) {
+		return false
+	}
+	if !(dea[1] >= 'A' && dea[1] <= 'Z') && dea[1] != '9' {
+		return false
+	}
+
+	digits := dea[2:]
+	if len(digitsOf(digits)) != 7 {
+		return false
+	}
+	odd := int(digits[0]-'0') + int(digits[2]-'0') + int(digits[4]-'0')
+	even := int(digits[1]-'0') + int(digits[3]-'0') + int(digits[5]-'0')
+	return (odd+2*even)%10 == int(digits[6]-'0')
+}
+
+// vinValues transliterates VIN characters for the check digit. I, O and Q
+// are never used.
+var vinValues = map[byte]int{
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+// ValidateVIN reports whether a 17-character vehicle identification number
+// has letters and digits and a valid ninth check character
+func ValidateVIN(match string) bool {
+	vin := strings.ToUpper(match)
+	if len(vin) != 17 || len(digitsOf(vin)) == len(vin) {
+		return false
+	}
+
+	weights := [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+	sum := 0
+	for i := 0; i < len(vin); i++ {
+		c := vin[i]
+		value, ok := vinValues[c]
+		if c >= '0' && c <= '9' {
+			value, ok = int(c-'0'), true
+		}
+		if !ok {
+			return false
+		}
+		sum += value * weights[i]
+	}
+
+	check := byte('0' + sum%11)
+	if sum%11 == 10 {
+		check = 'X'
+	}
+	return vin[8] == check
+}
+
+// monthNames are the months, matched on their first three letters
+var monthNames = []string{"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"}
+
+// ValidateDate reports whether a date has a real month and day. Numeric
+// dates may be year first, month first or day first; month names may be
+// abbreviated.
+func ValidateDate(match string) bool {
+	var numbers []int
+	var widths []int
+	month := 0
+	for _, field := range strings.FieldsFunc(strings.ToLower(match), func(r rune) bool {
+		return !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'z')
+	}) {
+		digits := strings.TrimRight(field, "stndrh")
+		if n, err := strconv.Atoi(digits); err == nil {
+			numbers = append(numbers, n)
+			widths = append(widths, len(digits))
+			continue
+		}
+		if field == "sept" {
+			field = "sep"
+		}
+		for i, name := range monthNames {
+			if len(field) >= 3 && strings.HasPrefix(name, field) {
+				month = i + 1
+				break
+			}
+		}
+		if month == 0 {
+			return false
+		}
+	}
+
+	day := func(n int) bool { return n >= 1 && n <= 31 }
+	switch {
+	case month > 0:
+		return len(numbers) == 2 && day(numbers[0]) && widths[1] == 4
+	case len(numbers) != 3:
+		return false
+	case widths[0] == 4:
+		return numbers[1] >= 1 && numbers[1] <= 12 && day(numbers[2])
+	default:
+		a, b := numbers[0], numbers[1]
+		return (a >= 1 && a <= 12 && day(b)) || (b >= 1 && b <= 12 && day(a))
+	}
+}
+
+// ValidateIPv4 reports whether an address has four octets of at most 255
+func ValidateIPv4(match string) bool {
+	ip := net.ParseIP(match)
+	return ip != nil && ip.To4() != nil && strings.Count(match, ".") == 3
+}
+
+// ValidateIPv6 reports whether an address is a valid IPv6 address with at
+// least four hex digits, so :: and loopback addresses are not reported
+func ValidateIPv6(match string) bool {
+	ip := net.ParseIP(match)
+	return ip != nil && strings.Contains(match, ":") && len(strings.ReplaceAll(match, ":", "")) >= 4
+}
+
+// containsDigit reports whether an identifier has a digit, unlike ordinary
+// upper-case words
+func containsDigit(match string) bool {
+	return strings.ContainsAny(match, "0123456789")
+}
+
+// compact returns s upper-cased without spaces and dashes
+func compact(s string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+}
+
+// digitsOf returns the digits in s
+func digitsOf(s string) string {
+	var digits strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	return digits.String()
+}
@@ -3,14 +3,16 @@ package redaction
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"fmt"
 	"io"
 )
 
 // RedactionAction represents a redaction action to be performed
 type RedactionAction struct {
-	Type        string `json:"type"`        // "mask", "tokenize", "fpe", "encrypt", "drop"
+	Type        string `json:"type"`        // "mask", "tokenize", "pseudonymize", "fpe", "encrypt", "drop"
 	Format      string `json:"format"`      // Format pattern for FPE or masking
 	MaskChar    string `json:"mask_char"`   // Character to use for masking
 	PreserveDomain bool `json:"preserve_domain"` // Whether to preserve domain in email masking
@@ -36,6 +38,8 @@ func (r *Redactor) Redact(text string, action RedactionAction) (string, error) {
 		return r.mask(text, action)
 	case "tokenize":
 		return r.tokenize(text, action)
+	case "pseudonymize":
+		return r.pseudonymize(text, action)
 	case "fpe":
 		return r.formatPreservingEncrypt(text, action)
 	case "encrypt":
@@ -79,6 +83,18 @@ func (r *Redactor) tokenize(text string, action RedactionAction) (string, error)
 	return token, nil
 }
 
+// pseudonymize replaces the text with a short deterministic token that
+// cannot be reversed. The same text always gets the same token under one
+// key, so redacted documents and queries still match in embedding search.
+func (r *Redactor) pseudonymize(text string, action RedactionAction) (string, error) {
+	mac := hmac.New(sha256.New, r.encryptionKey)
+	// Keep pseudonyms unrelated to the nonces embedded in tokens
+	mac.Write([]byte("pseudonym:"))
+	mac.Write([]byte(text))
+
+	return fmt.Sprintf("pseudo_%x", mac.Sum(nil)[:8]), nil
+}
+
 // formatPreservingEncrypt applies format-preserving encryption
 func (r *Redactor) formatPreservingEncrypt(text string, action RedactionAction) (string, error) {
 	// This is a simplified implementation
@@ -137,7 +153,7 @@ func (r *Redactor) generateToken(text string) (string, error) {
 	}
 	
 	// Generate a deterministic nonce based on the text
-	nonce := generateDeterministicNonce(text, gcm.NonceSize())
+	nonce := generateDeterministicNonce(r.encryptionKey, text, gcm.NonceSize())
 	
 	// Encrypt the text
 	ciphertext := gcm.Seal(nil, nonce, []byte(text), nil)
@@ -201,21 +217,13 @@ func applyFormat(text, format string) string {
 	return result
 }
 
-// generateDeterministicNonce creates a deterministic nonce for a given text
-func generateDeterministicNonce(text string, size int) []byte {
-	// In a real implementation, this would use a proper KDF
-	// For now, we'll use a simplified approach
-	nonce := make([]byte, size)
-	for i := 0; i < size && i < len(text); i++ {
-		nonce[i] = text[i]
-	}
-	
-	// Pad with zeros if needed
-	for i := len(text); i < size; i++ {
-		nonce[i] = 0
-	}
-	
-	return nonce
+// generateDeterministicNonce creates a deterministic nonce for a given text.
+// The nonce is emitted as part of the token, so it is derived with a keyed
+// MAC rather than from the plaintext itself.
+func generateDeterministicNonce(key []byte, text string, size int) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(text))
+	return mac.Sum(nil)[:size]
 }
 
 // encodeBase64 encodes bytes to base64 string
@@ -238,4 +246,4 @@ func stringIndex(s, substr string) int {
 		}
 	}
 	return -1
-}package redaction
+}
@@ -0,0 +1,63 @@
+package detector
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// HashingEmbeddingModel generates embeddings locally using feature hashing of
+// word unigrams and bigrams. It needs no external model and is deterministic,
+// which makes it suitable for matching near-duplicates of known attack prompts.
+type HashingEmbeddingModel struct {
+	dimensions int
+}
+
+// NewHashingEmbeddingModel creates a new hashing embedding model
+func NewHashingEmbeddingModel(dimensions int) *HashingEmbeddingModel {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &HashingEmbeddingModel{
+		dimensions: dimensions,
+	}
+}
+
+// Generate generates an L2-normalised embedding for the input text
+func (hem *HashingEmbeddingModel) Generate(ctx context.Context, text string) ([]float64, error) {
+	embedding := make([]float64, hem.dimensions)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		embedding[hem.bucket(word)] += 1.0
+		if i > 0 {
+			embedding[hem.bucket(words[i-1]+" "+word)] += 0.5
+		}
+	}
+
+	// Normalise so cosine similarity reduces to a dot product
+	magnitude := 0.0
+	for _, v := range embedding {
+		magnitude += v * v
+	}
+	if magnitude > 0 {
+		magnitude = math.Sqrt(magnitude)
+		for i := range embedding {
+			embedding[i] /= magnitude
+		}
+	}
+
+	return embedding, nil
+}
+
+// bucket maps a feature to an embedding dimension
+func (hem *HashingEmbeddingModel) bucket(feature string) int {
+	h := fnv.New32a()
+	h.Write([]byte(feature))
+	return int(h.Sum32() % uint32(hem.dimensions))
+}
@@ -0,0 +1,90 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Rule is a single heuristic detection rule
+type Rule struct {
+	ID          string
+	Pattern     string
+	Score       float64
+	Description string
+}
+
+// PatternRuleEngine evaluates regex-based heuristic rules
+type PatternRuleEngine struct {
+	rules    []Rule
+	compiled []*regexp.Regexp
+}
+
+// NewPatternRuleEngine creates a new rule engine from the given rules
+func NewPatternRuleEngine(rules []Rule) (*PatternRuleEngine, error) {
+	compiled := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile rule %s: %w", rule.ID, err)
+		}
+		compiled[i] = re
+	}
+
+	return &PatternRuleEngine{
+		rules:    rules,
+		compiled: compiled,
+	}, nil
+}
+
+// Evaluate evaluates rules against input text
+func (pre *PatternRuleEngine) Evaluate(ctx context.Context, text string) ([]RuleMatch, error) {
+	var matches []RuleMatch
+	for i, re := range pre.compiled {
+		if re.MatchString(text) {
+			matches = append(matches, RuleMatch{
+				RuleID:      pre.rules[i].ID,
+				Score:       pre.rules[i].Score,
+				Description: pre.rules[i].Description,
+			})
+		}
+	}
+
+	return matches, nil
+}
+
+// DefaultRules returns heuristic rules for common prompt attack patterns
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			ID:          "ignore_instructions",
+			Pattern:     `(?i)\b(ignore|disregard|forget)\s+(all\s+)?(of\s+)?(the\s+|your\s+)?(previous|prior|above|earlier)\s+(instructions|rules|prompts?|directions)`,
+			Score:       0.9,
+			Description: "Attempt to override prior instructions",
+		},
+		{
+			ID:          "system_prompt_exfiltration",
+			Pattern:     `(?i)\b(reveal|print|show|repeat|output)\s+(me\s+)?(your|the)\s+(system\s+prompt|hidden\s+instructions|initial\s+instructions)`,
+			Score:       0.85,
+			Description: "Attempt to extract the system prompt",
+		},
+		{
+			ID:          "do_anything_now",
+			Pattern:     `(?i)\bdo\s+anything\s+now\b`,
+			Score:       0.8,
+			Description: "DAN-style jailbreak",
+		},
+		{
+			ID:          "developer_mode",
+			Pattern:     `(?i)\b(developer|god|jailbreak)\s+mode\b`,
+			Score:       0.7,
+			Description: "Request to enter an unrestricted mode",
+		},
+		{
+			ID:          "no_restrictions",
+			Pattern:     `(?i)\b(without|no)\s+(any\s+)?(restrictions|filters|guidelines|limitations)\b`,
+			Score:       0.6,
+			Description: "Request to drop safety restrictions",
+		},
+	}
+}
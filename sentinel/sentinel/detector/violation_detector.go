@@ -88,6 +88,26 @@ func NewViolationDetector(
 	}
 }
 
+// NewDefaultViolationDetector creates a violation detector backed by the local
+// hashing embedding model, the default signatures and the default rules
+func NewDefaultViolationDetector(thresholds DetectionThresholds) (*ViolationDetector, error) {
+	embeddingModel := NewHashingEmbeddingModel(256)
+
+	signatureStore := NewInMemorySignatureStore(embeddingModel, 0.5)
+	for _, signature := range DefaultSignatures() {
+		if err := signatureStore.AddSignature(context.Background(), signature); err != nil {
+			return nil, fmt.Errorf("failed to add signature %s: %w", signature.ID, err)
+		}
+	}
+
+	ruleEngine, err := NewPatternRuleEngine(DefaultRules())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule engine: %w", err)
+	}
+
+	return NewViolationDetector(signatureStore, ruleEngine, embeddingModel, thresholds), nil
+}
+
 // Detect detects violations in the provided text
 func (vd *ViolationDetector) Detect(ctx context.Context, text string) (*DetectionResult, error) {
 	// Initialize result
@@ -7,8 +7,10 @@ import (
 
 // Router determines the appropriate action for a given request
 type Router struct {
-	policyEngine PolicyEngine
-	mode         string // audit, enforce, silent
+	policyEngine    PolicyEngine
+	mode            string // audit, enforce, silent
+	internalModel   string
+	internalClasses map[string]bool
 }
 
 // RouteDecision represents a routing decision
@@ -18,6 +20,7 @@ type RouteDecision struct {
 	Confidence      float64                `json:"confidence"`       // confidence in the decision
 	Recommendations []string               `json:"recommendations"`  // recommended actions
 	Metadata        map[string]interface{} `json:"metadata"`         // additional metadata
+	Model           string                 `json:"model,omitempty"`  // model to use instead of the requested one
 }
 
 // PolicyEngine interface for evaluating policies
@@ -33,6 +36,7 @@ type PolicyInput struct {
 	Model          string                 `json:"model"`
 	Prompt         string                 `json:"prompt"`
 	DetectionScore float64                `json:"detection_score"`
+	DataClasses    map[string]int         `json:"data_classes,omitempty"`
 	Reflection     *ReflectionResult      `json:"reflection,omitempty"`
 	Rewrite        *RewriteResult         `json:"rewrite,omitempty"`
 	Context        map[string]interface{} `json:"context"`
@@ -66,6 +70,18 @@ func NewRouter(policyEngine PolicyEngine, mode string) *Router {
 	}
 }
 
+// SetInternalModel sends requests containing sensitive data of one of
+// classes to an internal model instead of the requested one, or requests
+// containing any sensitive data if no classes are given. Blocked requests
+// are still refused.
+func (r *Router) SetInternalModel(model string, classes ...string) {
+	r.internalModel = model
+	r.internalClasses = make(map[string]bool, len(classes))
+	for _, class := range classes {
+		r.internalClasses[class] = true
+	}
+}
+
 // Route determines the appropriate action for a request
 func (r *Router) Route(ctx context.Context, input *RoutingInput) (*RouteDecision, error) {
 	// Evaluate policies
@@ -75,6 +91,7 @@ func (r *Router) Route(ctx context.Context, input *RoutingInput) (*RouteDecision
 		Model:          input.Model,
 		Prompt:         input.Prompt,
 		DetectionScore: input.DetectionScore,
+		DataClasses:    input.DataClasses,
 		Reflection:     input.Reflection,
 		Rewrite:        input.Rewrite,
 		Context:        input.Context,
@@ -104,10 +121,27 @@ func (r *Router) Route(ctx context.Context, input *RoutingInput) (*RouteDecision
 		// In silent mode, follow policy but don't inform user
 		// Decision remains as policy output
 	}
+
+	// Keep sensitive traffic on the internal model
+	if r.internalModel != "" && decision.Action != "block" && r.isSensitive(input.DataClasses) {
+		decision.Model = r.internalModel
+		decision.Reason += "; routed to internal model " + r.internalModel
+	}
 	
 	return decision, nil
 }
 
+// isSensitive reports whether classes has items of a class routed to the
+// internal model
+func (r *Router) isSensitive(classes map[string]int) bool {
+	for class, count := range classes {
+		if count > 0 && (len(r.internalClasses) == 0 || r.internalClasses[class]) {
+			return true
+		}
+	}
+	return false
+}
+
 // RoutingInput represents input to the router
 type RoutingInput struct {
 	TenantID       string                 `json:"tenant_id"`
@@ -115,6 +149,7 @@ type RoutingInput struct {
 	Model          string                 `json:"model"`
 	Prompt         string                 `json:"prompt"`
 	DetectionScore float64                `json:"detection_score"`
+	DataClasses    map[string]int         `json:"data_classes,omitempty"` // detected items per data class
 	Reflection     *ReflectionResult      `json:"reflection,omitempty"`
 	Rewrite        *RewriteResult         `json:"rewrite,omitempty"`
 	Context        map[string]interface{} `json:"context"`
@@ -140,6 +175,11 @@ func (r *Router) ShouldBlock(decision *RouteDecision) bool {
 	return decision.Action == "block"
 }
 
+// ShouldRouteInternally determines if the request should go to the internal model
+func (r *Router) ShouldRouteInternally(decision *RouteDecision) bool {
+	return decision.Model != ""
+}
+
 // GetRecommendedActions returns recommended actions based on the decision
 func (r *Router) GetRecommendedActions(decision *RouteDecision) []string {
 	return decision.Recommendations
@@ -148,4 +188,4 @@ func (r *Router) GetRecommendedActions(decision *RouteDecision) []string {
 // GetMetadata returns metadata from the decision
 func (r *Router) GetMetadata(decision *RouteDecision) map[string]interface{} {
 	return decision.Metadata
-}package router
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"context"
+	"fmt"
+)
+
+// ThresholdPolicyEngine is a PolicyEngine that decides purely on the violation
+// detection score. It is the default engine used when no policy store is configured.
+type ThresholdPolicyEngine struct {
+	blockThreshold   float64
+	reframeThreshold float64
+}
+
+// NewThresholdPolicyEngine creates a new threshold-based policy engine
+func NewThresholdPolicyEngine(blockThreshold, reframeThreshold float64) *ThresholdPolicyEngine {
+	return &ThresholdPolicyEngine{
+		blockThreshold:   blockThreshold,
+		reframeThreshold: reframeThreshold,
+	}
+}
+
+// Evaluate evaluates the detection score against the configured thresholds
+func (tpe *ThresholdPolicyEngine) Evaluate(ctx context.Context, input *PolicyInput) (*PolicyOutput, error) {
+	output := &PolicyOutput{
+		Confidence: input.DetectionScore,
+		Metadata: map[string]interface{}{
+			"detection_score": input.DetectionScore,
+		},
+	}
+
+	switch {
+	case input.DetectionScore >= tpe.blockThreshold:
+		output.Decision = "block"
+		output.Reason = fmt.Sprintf("detection score %.2f exceeds block threshold %.2f", input.DetectionScore, tpe.blockThreshold)
+		output.Recommendations = []string{"Remove adversarial instructions from the prompt"}
+	case input.DetectionScore >= tpe.reframeThreshold:
+		// A rewrite the user has already confirmed is allowed through
+		if input.Rewrite != nil && input.Rewrite.UserConfirmed {
+			output.Decision = "allow"
+			output.Reason = "user confirmed rewritten prompt"
+			break
+		}
+		output.Decision = "reframe"
+		output.Reason = fmt.Sprintf("detection score %.2f exceeds reframe threshold %.2f", input.DetectionScore, tpe.reframeThreshold)
+		output.Recommendations = []string{"Rephrase the prompt and confirm the rewritten version"}
+	default:
+		output.Decision = "allow"
+		output.Reason = "detection score below thresholds"
+		output.Confidence = 1 - input.DetectionScore
+	}
+
+	return output, nil
+}
@@ -66,6 +66,7 @@ func TestAdapterConfigValidation(t *testing.T) {
 
 // TestChatCompletionRequestStructure tests the chat completion request structure
 func TestChatCompletionRequestStructure(t *testing.T) {
+	temperature := float32(0.7)
 	req := &adapters.ChatCompletionRequest{
 		Model: "gpt-3.5-turbo",
 		Messages: []adapters.Message{
@@ -78,7 +79,7 @@ func TestChatCompletionRequestStructure(t *testing.T) {
 				Content: "Hello, how are you?",
 			},
 		},
-		Temperature: 0.7,
+		Temperature: &temperature,
 		MaxTokens:   150,
 		Stream:      false,
 	}
@@ -100,8 +101,8 @@ func TestChatCompletionRequestStructure(t *testing.T) {
 		t.Errorf("Expected second message role 'user', got %s", req.Messages[1].Role)
 	}
 
-	if req.Temperature != 0.7 {
-		t.Errorf("Expected temperature 0.7, got %f", req.Temperature)
+	if req.Temperature == nil || *req.Temperature != 0.7 {
+		t.Errorf("Expected temperature 0.7, got %v", req.Temperature)
 	}
 
 	if req.MaxTokens != 150 {
@@ -8,6 +8,7 @@ import (
 
 // TestChatCompletionRequest tests the ChatCompletionRequest structure
 func TestChatCompletionRequest(t *testing.T) {
+	temperature := float32(0.7)
 	req := &openai.ChatCompletionRequest{
 		Model: "gpt-3.5-turbo",
 		Messages: []openai.Message{
@@ -16,7 +17,7 @@ func TestChatCompletionRequest(t *testing.T) {
 				Content: "Hello, world!",
 			},
 		},
-		Temperature: 0.7,
+		Temperature: &temperature,
 		MaxTokens:   100,
 		Stream:      false,
 	}
@@ -0,0 +1,229 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/anthropic"
+)
+
+// newAnthropicServer starts a Messages API stand-in that records the last request
+func newAnthropicServer(t *testing.T, status int, body string, received *anthropic.MessagesRequest) *anthropic.AnthropicAdapter {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Expected /v1/messages, got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("Expected x-api-key header, got %q", r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != anthropic.DefaultVersion {
+			t.Errorf("Expected anthropic-version header, got %q", r.Header.Get("anthropic-version"))
+		}
+		if received != nil {
+			json.NewDecoder(r.Body).Decode(received)
+		}
+
+		if strings.HasPrefix(body, "event:") {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return anthropic.NewAnthropicAdapter("test-key", server.URL, 5*time.Second)
+}
+
+// TestAnthropicChatCompletion tests request conversion and response mapping
+func TestAnthropicChatCompletion(t *testing.T) {
+	var received anthropic.MessagesRequest
+	adapter := newAnthropicServer(t, http.StatusOK, `{
+		"id": "msg_01",
+		"type": "message",
+		"role": "assistant",
+		"model": "claude-3-5-sonnet-20241022",
+		"content": [{"type": "text", "text": "Hello"}, {"type": "text", "text": " there"}],
+		"stop_reason": "max_tokens",
+		"usage": {"input_tokens": 12, "output_tokens": 5}
+	}`, &received)
+
+	temperature := float32(0)
+	resp, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model: "claude-3-5-sonnet-20241022",
+		Messages: []adapters.Message{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "Hi"},
+			{Role: "system", Content: "Answer in English."},
+			{Role: "user", Content: "Who are you?"},
+		},
+		Temperature: &temperature,
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if received.System != "Be brief.\n\nAnswer in English." {
+		t.Errorf("Expected hoisted system prompt, got %q", received.System)
+	}
+	if len(received.Messages) != 1 || len(received.Messages[0].Content) != 2 {
+		t.Fatalf("Expected consecutive user messages to be merged, got %+v", received.Messages)
+	}
+	if received.MaxTokens != anthropic.DefaultMaxTokens {
+		t.Errorf("Expected default max_tokens, got %d", received.MaxTokens)
+	}
+	if received.Temperature == nil || *received.Temperature != 0 {
+		t.Errorf("Expected an explicit zero temperature, got %v", received.Temperature)
+	}
+
+	if resp.Choices[0].Message.Content != "Hello there" {
+		t.Errorf("Expected joined content, got %q", resp.Choices[0].Message.Content)
+	}
+	if resp.Choices[0].FinishReason != "length" {
+		t.Errorf("Expected finish reason length, got %q", resp.Choices[0].FinishReason)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 5 || resp.Usage.TotalTokens != 17 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestAnthropicChatCompletionError tests that API errors keep their status and type
+func TestAnthropicChatCompletionError(t *testing.T) {
+	adapter := newAnthropicServer(t, http.StatusTooManyRequests,
+		`{"type":"error","error":{"type":"rate_limit_error","message":"Slow down"}}`, nil)
+
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []adapters.Message{{Role: "user", Content: "Hi"}},
+	})
+
+	var apiErr *anthropic.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Type != "rate_limit_error" {
+		t.Errorf("Unexpected error: %+v", apiErr)
+	}
+}
+
+// TestAnthropicChatCompletionStream tests conversion of Messages API stream events
+func TestAnthropicChatCompletionStream(t *testing.T) {
+	body := "event: message_start\n" +
+		`data: {"type":"message_start","message":{"id":"msg_01","model":"claude-3-5-sonnet-20241022","usage":{"input_tokens":10,"output_tokens":1}}}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}` + "\n\n" +
+		"event: ping\n" +
+		`data: {"type":"ping"}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}` + "\n\n" +
+		"event: content_block_stop\n" +
+		`data: {"type":"content_block_stop","index":0}` + "\n\n" +
+		"event: message_delta\n" +
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}` + "\n\n" +
+		"event: message_stop\n" +
+		`data: {"type":"message_stop"}` + "\n\n"
+
+	var received anthropic.MessagesRequest
+	adapter := newAnthropicServer(t, http.StatusOK, body, &received)
+
+	stream, err := adapter.ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []adapters.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if !received.Stream {
+		t.Error("Expected stream flag in request")
+	}
+
+	var content strings.Builder
+	var last *adapters.ChatCompletionStreamResponse
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		last = chunk
+	}
+
+	if content.String() != "Hello" {
+		t.Errorf("Expected content Hello, got %q", content.String())
+	}
+	if last.Choices[0].FinishReason != "stop" {
+		t.Errorf("Expected finish reason stop, got %q", last.Choices[0].FinishReason)
+	}
+	if last.Usage == nil || last.Usage.PromptTokens != 10 || last.Usage.CompletionTokens != 4 {
+		t.Errorf("Unexpected usage on final chunk: %+v", last.Usage)
+	}
+	if last.ID != "msg_01" {
+		t.Errorf("Expected message ID on chunks, got %q", last.ID)
+	}
+}
+
+// TestAnthropicStreamError tests that a mid-stream error event is returned
+// as a classified ProviderError wrapping an APIError
+func TestAnthropicStreamError(t *testing.T) {
+	body := "event: message_start\n" +
+		`data: {"type":"message_start","message":{"id":"msg_01","model":"claude-3-5-sonnet-20241022"}}` + "\n\n" +
+		"event: error\n" +
+		`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}` + "\n\n"
+
+	adapter := newAnthropicServer(t, http.StatusOK, body, nil)
+	stream, err := adapter.ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []adapters.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Expected message_start chunk, got %v", err)
+	}
+
+	_, err = stream.Recv()
+	var apiErr *anthropic.APIError
+	if !errors.As(err, &apiErr) || apiErr.Type != "overloaded_error" {
+		t.Fatalf("Expected overloaded APIError, got %v", err)
+	}
+	var providerErr *adapters.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Provider != "anthropic" || !errors.Is(err, adapters.ErrServerError) {
+		t.Errorf("Expected an overloaded server error, got %v", err)
+	}
+
+	// Rate limits mid-stream are classified by their type
+	body = "event: error\n" +
+		`data: {"type":"error","error":{"type":"rate_limit_error","message":"Rate limited"}}` + "\n\n"
+	stream, err = newAnthropicServer(t, http.StatusOK, body, nil).ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []adapters.Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Recv(); !errors.Is(err, adapters.ErrRateLimited) {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/azure"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// azureRequest records what the Azure stand-in received
+type azureRequest struct {
+	path       string
+	apiVersion string
+	apiKey     string
+	auth       string
+}
+
+// newAzureServer starts an Azure OpenAI stand-in
+func newAzureServer(t *testing.T, status int, body string, received *azureRequest) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if received != nil {
+			*received = azureRequest{
+				path:       r.URL.Path,
+				apiVersion: r.URL.Query().Get("api-version"),
+				apiKey:     r.Header.Get("api-key"),
+				auth:       r.Header.Get("Authorization"),
+			}
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// azureChatRequest returns a simple request for model
+func azureChatRequest(model string) *adapters.ChatCompletionRequest {
+	return &adapters.ChatCompletionRequest{
+		Model:    model,
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	}
+}
+
+// TestAzureChatCompletionDeploymentRouting tests the deployment URL, auth and filter annotations
+func TestAzureChatCompletionDeploymentRouting(t *testing.T) {
+	var received azureRequest
+	endpoint := newAzureServer(t, http.StatusOK, `{
+		"id": "chatcmpl-1",
+		"object": "chat.completion",
+		"model": "gpt-4o",
+		"prompt_filter_results": [{"prompt_index": 0, "content_filter_results": {
+			"hate": {"filtered": false, "severity": "safe"},
+			"jailbreak": {"filtered": false, "detected": false}
+		}}],
+		"choices": [{"index": 0, "finish_reason": "stop",
+			"message": {"role": "assistant", "content": "Hi"},
+			"content_filter_results": {
+				"violence": {"filtered": false, "severity": "low"},
+				"custom_blocklists": [{"id": "list", "filtered": false}]
+			}}],
+		"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}
+	}`, &received)
+
+	adapter := azure.NewAzureAdapter(endpoint, "", "azure-key", map[string]string{"gpt-4o": "prod-gpt4o"}, 5*time.Second)
+
+	var flagged []detector.DetectionResult
+	adapter.SetDetectionHandler(func(ctx context.Context, model string, detections []detector.DetectionResult) {
+		flagged = detections
+	})
+
+	resp, err := adapter.ChatCompletion(context.Background(), azureChatRequest("gpt-4o"))
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if received.path != "/openai/deployments/prod-gpt4o/chat/completions" {
+		t.Errorf("Unexpected path %s", received.path)
+	}
+	if received.apiVersion != azure.DefaultAPIVersion {
+		t.Errorf("Expected api-version %s, got %s", azure.DefaultAPIVersion, received.apiVersion)
+	}
+	if received.apiKey != "azure-key" || received.auth != "" {
+		t.Errorf("Expected api-key auth, got api-key=%q auth=%q", received.apiKey, received.auth)
+	}
+
+	if resp.Choices[0].Message.Content != "Hi" || resp.Usage.TotalTokens != 4 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	if len(flagged) != 1 || flagged[0].ViolationType != "content_filter_violence" {
+		t.Fatalf("Expected one violence detection, got %+v", flagged)
+	}
+	if flagged[0].Score != 0.33 || flagged[0].Details["filtered"] != 0 {
+		t.Errorf("Unexpected detection: %+v", flagged[0])
+	}
+}
+
+// TestAzureAADAuthentication tests bearer token authentication and unmapped deployments
+func TestAzureAADAuthentication(t *testing.T) {
+	var received azureRequest
+	endpoint := newAzureServer(t, http.StatusOK,
+		`{"id":"chatcmpl-1","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi"}}]}`, &received)
+
+	adapter := azure.NewAzureAdapter(endpoint, "2024-10-21", "", nil, 5*time.Second)
+	adapter.SetTokenSource(azure.StaticToken("aad-token"))
+
+	if err := adapter.ValidateConfig(); err != nil {
+		t.Fatalf("Expected token source to satisfy validation, got %v", err)
+	}
+	if _, err := adapter.ChatCompletion(context.Background(), azureChatRequest("gpt-4o-mini")); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if received.auth != "Bearer aad-token" || received.apiKey != "" {
+		t.Errorf("Expected bearer auth, got api-key=%q auth=%q", received.apiKey, received.auth)
+	}
+	if received.path != "/openai/deployments/gpt-4o-mini/chat/completions" {
+		t.Errorf("Expected unmapped model to be used as deployment, got %s", received.path)
+	}
+	if received.apiVersion != "2024-10-21" {
+		t.Errorf("Expected configured api-version, got %s", received.apiVersion)
+	}
+}
+
+// expiringCredential issues numbered tokens that expire after a lifetime
+type expiringCredential struct {
+	lifetime time.Duration
+	issued   int
+	scopes   []string
+}
+
+func (c *expiringCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.issued++
+	c.scopes = options.Scopes
+	return azcore.AccessToken{Token: fmt.Sprintf("token-%d", c.issued), ExpiresOn: time.Now().Add(c.lifetime)}, nil
+}
+
+// TestAzureCredentialTokenSource tests that credential tokens are cached
+// and refreshed before they expire
+func TestAzureCredentialTokenSource(t *testing.T) {
+	credential := &expiringCredential{lifetime: time.Hour}
+	tokens := azure.NewCredentialTokenSource(credential, azure.CognitiveServicesScope)
+
+	for i := 0; i < 2; i++ {
+		if token, err := tokens.Token(context.Background()); err != nil || token != "token-1" {
+			t.Fatalf("Expected the cached token, got %q (%v)", token, err)
+		}
+	}
+	if credential.issued != 1 || len(credential.scopes) != 1 || credential.scopes[0] != azure.CognitiveServicesScope {
+		t.Errorf("Expected one token for the Cognitive Services scope, got %d for %v", credential.issued, credential.scopes)
+	}
+
+	// Tokens close to expiry are replaced
+	credential.lifetime = time.Minute
+	credential.issued = 0
+	tokens = azure.NewCredentialTokenSource(credential, azure.CognitiveServicesScope)
+	tokens.Token(context.Background())
+	if token, _ := tokens.Token(context.Background()); token != "token-2" {
+		t.Errorf("Expected a refreshed token, got %q", token)
+	}
+
+	if _, err := azure.NewAdapterFromSettings(map[string]interface{}{"endpoint": "https://example.openai.azure.com", "aadCredential": "password"}); err == nil {
+		t.Error("Expected an unknown aadCredential to be rejected")
+	}
+}
+
+// TestAzurePromptFiltered tests that a filtered prompt is surfaced as detections
+func TestAzurePromptFiltered(t *testing.T) {
+	endpoint := newAzureServer(t, http.StatusBadRequest, `{"error": {
+		"code": "content_filter",
+		"message": "The response was filtered",
+		"innererror": {"code": "ResponsibleAIPolicyViolation", "content_filter_result": {
+			"hate": {"filtered": true, "severity": "high"},
+			"jailbreak": {"filtered": true, "detected": true}
+		}}
+	}}`, nil)
+
+	adapter := azure.NewAzureAdapter(endpoint, "", "azure-key", nil, 5*time.Second)
+	_, err := adapter.ChatCompletion(context.Background(), azureChatRequest("gpt-4o"))
+
+	var filterErr *azure.ContentFilterError
+	if !errors.As(err, &filterErr) {
+		t.Fatalf("Expected ContentFilterError, got %v", err)
+	}
+	if filterErr.Source != "prompt" || len(filterErr.Detections) != 2 {
+		t.Fatalf("Unexpected filter error: %+v", filterErr)
+	}
+	if filterErr.ViolationType() != "content_filter_hate" {
+		t.Errorf("Expected hate violation, got %s", filterErr.ViolationType())
+	}
+	if !errors.Is(err, adapters.ErrContentFiltered) {
+		t.Errorf("Expected ErrContentFiltered, got %v", err)
+	}
+}
+
+// TestAzureCompletionFiltered tests that a filtered completion is not returned
+func TestAzureCompletionFiltered(t *testing.T) {
+	endpoint := newAzureServer(t, http.StatusOK, `{"id": "chatcmpl-1", "choices": [{
+		"index": 0, "finish_reason": "content_filter",
+		"message": {"role": "assistant", "content": "Partial"},
+		"content_filter_results": {"sexual": {"filtered": true, "severity": "medium"}}
+	}]}`, nil)
+
+	adapter := azure.NewAzureAdapter(endpoint, "", "azure-key", nil, 5*time.Second)
+	_, err := adapter.ChatCompletion(context.Background(), azureChatRequest("gpt-4o"))
+
+	var filterErr *azure.ContentFilterError
+	if !errors.As(err, &filterErr) || filterErr.Source != "completion" {
+		t.Fatalf("Expected completion ContentFilterError, got %v", err)
+	}
+}
+
+// TestAzureChatCompletionStream tests that filter-only chunks are skipped and filtering ends the stream
+func TestAzureChatCompletionStream(t *testing.T) {
+	body := `data: {"id":"","choices":[],"prompt_filter_results":[{"prompt_index":0,"content_filter_results":{"hate":{"filtered":false,"severity":"safe"}}}]}` + "\n\n" +
+		`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}` + "\n\n" +
+		`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}` + "\n\n" +
+		"data: [DONE]\n\n"
+
+	endpoint := newAzureServer(t, http.StatusOK, body, nil)
+	adapter := azure.NewAzureAdapter(endpoint, "", "azure-key", nil, 5*time.Second)
+
+	stream, err := adapter.ChatCompletionStream(context.Background(), azureChatRequest("gpt-4o"))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+	}
+
+	if content.String() != "Hello" {
+		t.Errorf("Expected content Hello, got %q", content.String())
+	}
+
+	filteredBody := `data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" +
+		`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{},"finish_reason":"content_filter","content_filter_results":{"violence":{"filtered":true,"severity":"high"}}}]}` + "\n\n"
+
+	endpoint = newAzureServer(t, http.StatusOK, filteredBody, nil)
+	adapter = azure.NewAzureAdapter(endpoint, "", "azure-key", nil, 5*time.Second)
+
+	stream, err = adapter.ChatCompletionStream(context.Background(), azureChatRequest("gpt-4o"))
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	stream.Recv()
+	var filterErr *azure.ContentFilterError
+	if _, err := stream.Recv(); !errors.As(err, &filterErr) {
+		t.Fatalf("Expected ContentFilterError mid-stream, got %v", err)
+	}
+}
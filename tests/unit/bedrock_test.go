@@ -0,0 +1,301 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/bedrock"
+)
+
+// bedrockRequest records what the Bedrock stand-in received
+type bedrockRequest struct {
+	path      string
+	body      string
+	signedOK  bool
+	signature string
+}
+
+// newBedrockServer starts a Bedrock runtime stand-in that checks the SigV4
+// signature by re-signing the request with the test credentials
+func newBedrockServer(t *testing.T, status int, header http.Header, body []byte, received *bedrockRequest) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestBody, _ := io.ReadAll(r.Body)
+		if received != nil {
+			*received = bedrockRequest{
+				path:      r.URL.EscapedPath(),
+				body:      string(requestBody),
+				signature: r.Header.Get("Authorization"),
+				signedOK:  verifySigV4(r, requestBody),
+			}
+		}
+
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// verifySigV4 re-signs the signed headers of r and compares signatures
+func verifySigV4(r *http.Request, body []byte) bool {
+	signingTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+
+	clone, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.EscapedPath(), bytes.NewReader(body))
+	_, signed, _ := strings.Cut(r.Header.Get("Authorization"), "SignedHeaders=")
+	signed, _, _ = strings.Cut(signed, ",")
+	for _, name := range strings.Split(signed, ";") {
+		if name != "host" && name != "content-length" {
+			clone.Header.Set(name, r.Header.Get(name))
+		}
+	}
+
+	credentials, _ := bedrock.StaticCredentials("AKIDTEST", "secret", "").Retrieve(context.Background())
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(context.Background(), credentials, clone, hex.EncodeToString(hash[:]), "bedrock", "us-east-1", signingTime); err != nil {
+		return false
+	}
+	return clone.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+// newTestBedrockAdapter creates an adapter pointed at a stand-in
+func newTestBedrockAdapter(endpoint string, models map[string]string) *bedrock.BedrockAdapter {
+	return bedrock.NewBedrockAdapter("us-east-1", endpoint, bedrock.StaticCredentials("AKIDTEST", "secret", ""), models, 5*time.Second)
+}
+
+// TestBedrockConverse tests SigV4 signing, model mapping and response conversion
+func TestBedrockConverse(t *testing.T) {
+	var received bedrockRequest
+	endpoint := newBedrockServer(t, http.StatusOK, http.Header{"X-Amzn-Requestid": {"req-1"}}, []byte(`{
+		"output": {"message": {"role": "assistant", "content": [{"text": "Hi"}, {"text": " there"}]}},
+		"stopReason": "max_tokens",
+		"usage": {"inputTokens": 9, "outputTokens": 2, "totalTokens": 11}
+	}`), &received)
+
+	adapter := newTestBedrockAdapter(endpoint, map[string]string{"claude-3-haiku": "anthropic.claude-3-haiku-20240307-v1:0"})
+	temperature := float32(0)
+	resp, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model: "claude-3-haiku",
+		Messages: []adapters.Message{
+			{Role: "system", Content: "Be brief"},
+			{Role: "user", Content: "Hello"},
+			{Role: "user", Content: "Anyone there?"},
+		},
+		Temperature: &temperature,
+		MaxTokens:   2,
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if received.path != "/model/anthropic.claude-3-haiku-20240307-v1%3A0/converse" {
+		t.Errorf("Unexpected path %s", received.path)
+	}
+	if !strings.HasPrefix(received.signature, "AWS4-HMAC-SHA256 Credential=AKIDTEST/") ||
+		!strings.Contains(received.signature, "/us-east-1/bedrock/aws4_request") {
+		t.Errorf("Unexpected Authorization header %q", received.signature)
+	}
+	if !received.signedOK {
+		t.Error("Expected a valid SigV4 signature")
+	}
+
+	want := `{"messages":[{"role":"user","content":[{"text":"Hello"},{"text":"Anyone there?"}]}],"system":[{"text":"Be brief"}],"inferenceConfig":{"maxTokens":2,"temperature":0}}`
+	if received.body != want {
+		t.Errorf("Unexpected request body:\n%s\nwant:\n%s", received.body, want)
+	}
+
+	if resp.ID != "bedrock-req-1" || resp.Model != "claude-3-haiku" {
+		t.Errorf("Unexpected response metadata: %+v", resp)
+	}
+	if resp.Choices[0].Message.Content != "Hi there" || resp.Choices[0].FinishReason != "length" {
+		t.Errorf("Unexpected choice: %+v", resp.Choices[0])
+	}
+	if resp.Usage.TotalTokens != 11 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestBedrockSystemPromptFolding tests families without system prompt support
+func TestBedrockSystemPromptFolding(t *testing.T) {
+	var received bedrockRequest
+	endpoint := newBedrockServer(t, http.StatusOK, nil,
+		[]byte(`{"output":{"message":{"role":"assistant","content":[{"text":"Hi"}]}},"stopReason":"end_turn"}`), &received)
+
+	adapter := newTestBedrockAdapter(endpoint, nil)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model: "us.mistral.mistral-7b-instruct-v0:2",
+		Messages: []adapters.Message{
+			{Role: "system", Content: "Be brief"},
+			{Role: "user", Content: "Hello"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	want := `{"messages":[{"role":"user","content":[{"text":"Be brief"},{"text":"Hello"}]}]}`
+	if received.body != want {
+		t.Errorf("Unexpected request body:\n%s\nwant:\n%s", received.body, want)
+	}
+
+	info, err := adapter.GetModelInfo(context.Background(), "us.mistral.mistral-7b-instruct-v0:2")
+	if err != nil || info.OwnedBy != "mistral" {
+		t.Errorf("Expected mistral family, got %+v (%v)", info, err)
+	}
+}
+
+// TestBedrockError tests error type parsing
+func TestBedrockError(t *testing.T) {
+	endpoint := newBedrockServer(t, http.StatusTooManyRequests,
+		http.Header{"X-Amzn-Errortype": {"ThrottlingException:http://internal.amazon.com/coral/com.amazon.bedrock/"}},
+		[]byte(`{"message":"Too many requests"}`), nil)
+
+	adapter := newTestBedrockAdapter(endpoint, nil)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "meta.llama3-8b-instruct-v1:0",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var apiErr *bedrock.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Type != "ThrottlingException" || apiErr.Message != "Too many requests" {
+		t.Errorf("Unexpected error: %+v", apiErr)
+	}
+}
+
+// bedrockEvent encodes a ConverseStream event
+func bedrockEvent(eventType, payload string) []byte {
+	return bedrock.EncodeEventMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   eventType,
+		":content-type": "application/json",
+	}, []byte(payload))
+}
+
+// TestBedrockConverseStream tests decoding an event stream into chunks
+func TestBedrockConverseStream(t *testing.T) {
+	var body []byte
+	body = append(body, bedrockEvent("messageStart", `{"role":"assistant"}`)...)
+	body = append(body, bedrockEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hel"}}`)...)
+	body = append(body, bedrockEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"lo"}}`)...)
+	body = append(body, bedrockEvent("contentBlockStop", `{"contentBlockIndex":0}`)...)
+	body = append(body, bedrockEvent("messageStop", `{"stopReason":"end_turn"}`)...)
+	body = append(body, bedrockEvent("metadata", `{"usage":{"inputTokens":3,"outputTokens":2,"totalTokens":5},"metrics":{"latencyMs":10}}`)...)
+
+	var received bedrockRequest
+	endpoint := newBedrockServer(t, http.StatusOK, http.Header{"Content-Type": {"application/vnd.amazon.eventstream"}}, body, &received)
+	adapter := newTestBedrockAdapter(endpoint, nil)
+
+	stream, err := adapter.ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "anthropic.claude-3-haiku-20240307-v1:0",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if !strings.HasSuffix(received.path, "/converse-stream") || !received.signedOK {
+		t.Errorf("Unexpected stream request: %+v", received)
+	}
+
+	var content strings.Builder
+	var last *adapters.ChatCompletionStreamResponse
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		last = chunk
+	}
+
+	if content.String() != "Hello" {
+		t.Errorf("Expected content Hello, got %q", content.String())
+	}
+	if last.Choices[0].FinishReason != "stop" || last.Usage == nil || last.Usage.TotalTokens != 5 {
+		t.Errorf("Unexpected final chunk: %+v", last)
+	}
+}
+
+// TestBedrockStreamException tests exceptions and corrupt frames mid-stream
+func TestBedrockStreamException(t *testing.T) {
+	body := bedrockEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hel"}}`)
+	body = append(body, bedrock.EncodeEventMessage(map[string]string{
+		":message-type":   "exception",
+		":exception-type": "modelStreamErrorException",
+	}, []byte(`{"message":"Model failed"}`))...)
+
+	endpoint := newBedrockServer(t, http.StatusOK, nil, body, nil)
+	adapter := newTestBedrockAdapter(endpoint, nil)
+
+	stream, err := adapter.ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "anthropic.claude-3-haiku-20240307-v1:0",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	var apiErr *bedrock.APIError
+	_, err = stream.Recv()
+	if !errors.As(err, &apiErr) || apiErr.Type != "modelStreamErrorException" {
+		t.Fatalf("Expected stream exception, got %v", err)
+	}
+	if !errors.Is(err, adapters.ErrServerError) {
+		t.Errorf("Expected a server error, got %v", err)
+	}
+
+	// Throttling mid-stream is classified like a throttled request
+	throttled := bedrock.EncodeEventMessage(map[string]string{
+		":message-type":   "exception",
+		":exception-type": "throttlingException",
+	}, []byte(`{"message":"Too many tokens"}`))
+	stream, err = newTestBedrockAdapter(newBedrockServer(t, http.StatusOK, nil, throttled, nil), nil).ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "anthropic.claude-3-haiku-20240307-v1:0",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+	var providerErr *adapters.ProviderError
+	if _, err := stream.Recv(); !errors.Is(err, adapters.ErrRateLimited) || !errors.As(err, &providerErr) || providerErr.Provider != "bedrock" {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+
+	corrupt := bedrockEvent("contentBlockDelta", `{"delta":{"text":"x"}}`)
+	corrupt[len(corrupt)-1] ^= 0xff
+	if _, err := bedrock.NewEventStreamReader(bytes.NewReader(corrupt)).ReadMessage(); !errors.Is(err, bedrock.ErrChecksum) {
+		t.Errorf("Expected checksum error, got %v", err)
+	}
+}
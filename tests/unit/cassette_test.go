@@ -0,0 +1,240 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/cassette"
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/redaction"
+)
+
+// readStream concatenates the content deltas of a stream until it ends
+func readStream(t *testing.T, stream adapters.ChatCompletionStream) (string, error) {
+	t.Helper()
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return content.String(), nil
+		}
+		if err != nil {
+			return content.String(), err
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+		}
+	}
+}
+
+// TestCassetteRecordAndReplay tests that recorded chat, stream and embedding
+// interactions replay without the recorded adapter
+func TestCassetteRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+	chatReq := &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	}
+	streamReq := &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Stream this"}},
+		Stream:   true,
+	}
+	embedReq := &adapters.EmbeddingRequest{Model: "text-embedding-3-small", Input: adapters.EmbeddingInput{"abc"}}
+
+	recorder, err := cassette.New(path, cassette.ModeRecord, &StreamingMockLLMAdapter{ChunkSize: 4})
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	if _, err := recorder.ChatCompletion(context.Background(), chatReq); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	stream, err := recorder.ChatCompletionStream(context.Background(), streamReq)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	if _, err := readStream(t, stream); err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if _, err := recorder.Embed(context.Background(), embedReq); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	recorded, err := cassette.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	if len(recorded.Interactions) != 3 || len(recorded.Interactions[1].Chunks) != 6 {
+		t.Fatalf("Unexpected cassette: %+v", recorded.Interactions)
+	}
+	if strings.Contains(string(recorded.Interactions[1].Request), `"stream"`) {
+		t.Errorf("Expected stream to be normalised away, got %s", recorded.Interactions[1].Request)
+	}
+
+	player, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("Failed to create player: %v", err)
+	}
+
+	resp, err := player.ChatCompletion(context.Background(), chatReq)
+	if err != nil || resp.Choices[0].Message.Content != "You said: Hello" {
+		t.Errorf("Unexpected replayed response: %+v (%v)", resp, err)
+	}
+
+	stream, err = player.ChatCompletionStream(context.Background(), streamReq)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	if content, err := readStream(t, stream); err != nil || content != "You said: Stream this" {
+		t.Errorf("Unexpected replayed stream %q (%v)", content, err)
+	}
+
+	embedding, err := player.Embed(context.Background(), embedReq)
+	if err != nil || embedding.Data[0].Embedding[0] != 3 {
+		t.Errorf("Unexpected replayed embedding: %+v (%v)", embedding, err)
+	}
+
+	// Repeated requests reuse the last match; unknown ones fail
+	if _, err := player.ChatCompletion(context.Background(), chatReq); err != nil {
+		t.Errorf("Expected a repeated request to replay, got %v", err)
+	}
+	_, err = player.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Something new"}},
+	})
+	if !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("Expected ErrNoInteraction, got %v", err)
+	}
+}
+
+// TestCassetteReplaysProviderErrors tests that provider errors keep their
+// kind and retry details through a cassette file
+func TestCassetteReplaysProviderErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.json")
+	req := &adapters.ChatCompletionRequest{Model: "gpt-4o", Messages: []adapters.Message{{Role: "user", Content: "Hi"}}}
+
+	upstream := cassette.NewScripted(cassette.Fail(&adapters.ProviderError{
+		Provider:   "openai",
+		Kind:       adapters.ErrRateLimited,
+		StatusCode: 429,
+		Message:    "Rate limit reached",
+		RetryAfter: 3 * time.Second,
+	}))
+	recorder, err := cassette.New(path, cassette.ModeRecord, upstream)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	if _, err := recorder.ChatCompletion(context.Background(), req); !errors.Is(err, adapters.ErrRateLimited) {
+		t.Fatalf("Expected the recorded error to be returned, got %v", err)
+	}
+
+	player, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("Failed to create player: %v", err)
+	}
+	_, err = player.ChatCompletion(context.Background(), req)
+
+	var providerErr *adapters.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Kind != adapters.ErrRateLimited {
+		t.Fatalf("Expected a rate limited ProviderError, got %v", err)
+	}
+	if providerErr.RetryAfter != 3*time.Second || providerErr.Provider != "openai" {
+		t.Errorf("Unexpected replayed error: %+v", providerErr)
+	}
+}
+
+// TestCassetteReplaysWithoutKind tests that hand-written interactions
+// without a kind are matched by their reply
+func TestCassetteReplaysWithoutKind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handwritten.json")
+	cassetteFile := `{"interactions": [
+		{"request": {"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}]},
+		 "response": {"id": "chatcmpl-1", "object": "chat.completion", "choices": [{"index": 0, "message": {"role": "assistant", "content": "Hello"}, "finish_reason": "stop"}]}},
+		{"request": {"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}]},
+		 "chunks": [{"id": "chatcmpl-2", "choices": [{"index": 0, "delta": {"role": "assistant", "content": "Hey"}}]}]}
+	]}`
+	if err := os.WriteFile(path, []byte(cassetteFile), 0o644); err != nil {
+		t.Fatalf("Failed to write cassette: %v", err)
+	}
+
+	player, err := cassette.New(path, cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("Failed to create player: %v", err)
+	}
+	req := &adapters.ChatCompletionRequest{Model: "gpt-4o", Messages: []adapters.Message{{Role: "user", Content: "Hi"}}}
+
+	resp, err := player.ChatCompletion(context.Background(), req)
+	if err != nil || resp.Choices[0].Message.Content != "Hello" {
+		t.Fatalf("Expected the chat reply, got %+v (%v)", resp, err)
+	}
+	stream, err := player.ChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	if content, err := readStream(t, stream); err != nil || content != "Hey" {
+		t.Errorf("Expected the streamed reply, got %q (%v)", content, err)
+	}
+}
+
+// TestCassetteReplayRequiresFile tests that replay mode needs an existing cassette
+func TestCassetteReplayRequiresFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	if _, err := cassette.New(path, cassette.ModeReplay, nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing cassette error, got %v", err)
+	}
+	if _, err := cassette.New(path, "rewind", nil); err == nil {
+		t.Error("Expected an unknown mode to be rejected")
+	}
+}
+
+// TestGatewayWithScriptedAdversarialStream tests redaction and rehydration
+// end to end against a scripted provider that splits a token across chunks
+func TestGatewayWithScriptedAdversarialStream(t *testing.T) {
+	redactor := redaction.NewRedactor([]byte("0123456789abcdef0123456789abcdef"))
+	token, err := redactor.Redact("jane.doe@example.com", redaction.RedactionAction{Type: "tokenize"})
+	if err != nil {
+		t.Fatalf("Failed to tokenize: %v", err)
+	}
+
+	adapter := cassette.NewScripted(
+		cassette.StreamReply("I'll write to ", token[:5], token[5:len(token)-2], token[len(token)-2:], " today."),
+		cassette.Reply("Done: "+token),
+	)
+	gw := newTestGateway(t, adapter, "enforce")
+
+	req := &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Please email jane.doe@example.com"}},
+		Stream:   true,
+	}
+	stream, err := gw.ChatCompletionStream(context.Background(), "tenant-a", req)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	if content, err := readStream(t, stream); err != nil || content != "I'll write to jane.doe@example.com today." {
+		t.Errorf("Expected the split token to be rehydrated, got %q (%v)", content, err)
+	}
+
+	req.Stream = false
+	resp, err := gw.ChatCompletion(context.Background(), "tenant-a", req)
+	if err != nil || resp.Choices[0].Message.Content != "Done: jane.doe@example.com" {
+		t.Errorf("Unexpected response: %+v (%v)", resp, err)
+	}
+
+	for _, upstream := range adapter.Requests() {
+		if strings.Contains(string(upstream), "jane.doe") || !strings.Contains(string(upstream), token) {
+			t.Errorf("Expected only the token to reach the provider, got %s", upstream)
+		}
+	}
+	if _, err := adapter.ChatCompletion(context.Background(), req); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("Expected the exhausted script to fail, got %v", err)
+	}
+}
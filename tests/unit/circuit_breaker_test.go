@@ -0,0 +1,249 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/circuitbreaker"
+	"github.com/sentinel-platform/sentinel/proxy"
+	"github.com/sentinel-platform/sentinel/sentinel/admin"
+)
+
+// recordingObserver records circuit breaker state changes
+type recordingObserver struct {
+	transitions []string
+	mutex       sync.Mutex
+}
+
+func (o *recordingObserver) RecordCircuitStateChange(ctx context.Context, name, from, to string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.transitions = append(o.transitions, from+"->"+to)
+}
+
+// testBreakerSettings returns settings that trip after two failed calls
+func testBreakerSettings() circuitbreaker.Settings {
+	return circuitbreaker.Settings{
+		Window:             time.Minute,
+		MinRequests:        2,
+		ErrorRateThreshold: 0.5,
+		OpenTimeout:        50 * time.Millisecond,
+		HalfOpenRequests:   1,
+	}
+}
+
+// TestCircuitBreakerTripsOnErrorRate tests that a breaker opens and rejects calls
+func TestCircuitBreakerTripsOnErrorRate(t *testing.T) {
+	breaker := circuitbreaker.NewBreaker("upstream/gpt-4o", testBreakerSettings(), nil)
+
+	for i := 0; i < 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Expected call %d to be allowed, got %v", i, err)
+		}
+		breaker.Record(false, time.Millisecond)
+	}
+
+	if breaker.State() != circuitbreaker.StateOpen {
+		t.Fatalf("Expected breaker to be open, got %s", breaker.State())
+	}
+
+	var openErr *circuitbreaker.OpenError
+	if err := breaker.Allow(); !errors.As(err, &openErr) {
+		t.Fatalf("Expected OpenError, got %v", err)
+	}
+	if openErr.RetryAfter <= 0 {
+		t.Errorf("Expected positive retry after, got %v", openErr.RetryAfter)
+	}
+}
+
+// TestCircuitBreakerRecovers tests the half-open trial and recovery
+func TestCircuitBreakerRecovers(t *testing.T) {
+	observer := &recordingObserver{}
+	breaker := circuitbreaker.NewBreaker("upstream/gpt-4o", testBreakerSettings(), observer)
+
+	for i := 0; i < 2; i++ {
+		breaker.Allow()
+		breaker.Record(false, time.Millisecond)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Expected trial call after open timeout, got %v", err)
+	}
+	if breaker.State() != circuitbreaker.StateHalfOpen {
+		t.Fatalf("Expected breaker to be half open, got %s", breaker.State())
+	}
+	if err := breaker.Allow(); err == nil {
+		t.Error("Expected a second concurrent trial call to be rejected")
+	}
+
+	breaker.Record(true, time.Millisecond)
+	if breaker.State() != circuitbreaker.StateClosed {
+		t.Fatalf("Expected breaker to be closed, got %s", breaker.State())
+	}
+
+	expected := []string{"closed->open", "open->half_open", "half_open->closed"}
+	if strings.Join(observer.transitions, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected transitions %v, got %v", expected, observer.transitions)
+	}
+}
+
+// TestCircuitBreakerReopensOnTrialFailure tests that a failed trial reopens the breaker
+func TestCircuitBreakerReopensOnTrialFailure(t *testing.T) {
+	breaker := circuitbreaker.NewBreaker("upstream/gpt-4o", testBreakerSettings(), nil)
+
+	for i := 0; i < 2; i++ {
+		breaker.Allow()
+		breaker.Record(false, time.Millisecond)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Expected trial call after open timeout, got %v", err)
+	}
+	breaker.Record(false, time.Millisecond)
+
+	if breaker.State() != circuitbreaker.StateOpen {
+		t.Fatalf("Expected breaker to reopen, got %s", breaker.State())
+	}
+}
+
+// TestCircuitBreakerLatencyThreshold tests that slow calls count as failures
+func TestCircuitBreakerLatencyThreshold(t *testing.T) {
+	settings := testBreakerSettings()
+	settings.LatencyThreshold = 100 * time.Millisecond
+	breaker := circuitbreaker.NewBreaker("upstream/gpt-4o", settings, nil)
+
+	for i := 0; i < 2; i++ {
+		breaker.Allow()
+		breaker.Record(true, time.Second)
+	}
+
+	if breaker.State() != circuitbreaker.StateOpen {
+		t.Fatalf("Expected slow calls to open the breaker, got %s", breaker.State())
+	}
+}
+
+// TestCircuitBreakerZeroSettings tests that zero settings take defaults
+// instead of opening on successful traffic
+func TestCircuitBreakerZeroSettings(t *testing.T) {
+	breaker := circuitbreaker.NewBreaker("upstream/gpt-4o", circuitbreaker.Settings{}, nil)
+
+	for i := 0; i < 20; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Expected call %d to be allowed, got %v", i, err)
+		}
+		breaker.Record(true, time.Millisecond)
+	}
+	if breaker.State() != circuitbreaker.StateClosed {
+		t.Fatalf("Expected successful calls to keep the breaker closed, got %s", breaker.State())
+	}
+
+	for i := 0; i < 20; i++ {
+		breaker.Allow()
+		breaker.Record(false, time.Millisecond)
+	}
+	if breaker.State() != circuitbreaker.StateOpen {
+		t.Errorf("Expected failed calls to open the breaker, got %s", breaker.State())
+	}
+}
+
+// TestCircuitBreakerManagerPerModel tests that breakers are tracked per upstream and model
+func TestCircuitBreakerManagerPerModel(t *testing.T) {
+	manager := circuitbreaker.NewManager(testBreakerSettings(), nil)
+
+	if manager.Get("api.openai.com", "gpt-4o") != manager.Get("api.openai.com", "gpt-4o") {
+		t.Error("Expected the same breaker for the same upstream and model")
+	}
+	if manager.Get("api.openai.com", "gpt-4o") == manager.Get("api.openai.com", "gpt-4o-mini") {
+		t.Error("Expected different breakers for different models")
+	}
+
+	states := manager.States()
+	if states["api.openai.com/gpt-4o"] != "closed" {
+		t.Errorf("Expected closed state, got %q", states["api.openai.com/gpt-4o"])
+	}
+}
+
+// TestReverseProxyCircuitOpen tests that the proxy fails fast once the breaker opens
+func TestReverseProxyCircuitOpen(t *testing.T) {
+	failing := int32(http.StatusInternalServerError)
+	var hits int32
+
+	pool, _ := proxy.NewUpstreamPool(proxy.StrategyRoundRobin, 10, time.Minute)
+	pool.AddUpstream(countingUpstream(t, &failing, &hits), 1)
+
+	rp := newPoolProxy(pool)
+	rp.SetCircuitBreakers(circuitbreaker.NewManager(testBreakerSettings(), nil))
+
+	for i := 0; i < 2; i++ {
+		if code := sendThroughPool(rp); code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, got %d", code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o"}`))
+	req.Header.Set("X-Tenant", "tenant-a")
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header")
+	}
+	if hits != 2 {
+		t.Errorf("Expected the open breaker to skip the upstream, got %d hits", hits)
+	}
+
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode error body: %v", err)
+	}
+	if body.Error.Code != "circuit_open" {
+		t.Errorf("Expected code circuit_open, got %q", body.Error.Code)
+	}
+}
+
+// TestCircuitBreakerReportsToObservability tests that a trip is recorded and
+// logged by the ObservabilityManager the gateway passes as observer
+func TestCircuitBreakerReportsToObservability(t *testing.T) {
+	observability, err := admin.NewObservabilityManager()
+	if err != nil {
+		t.Fatalf("NewObservabilityManager failed: %v", err)
+	}
+	t.Cleanup(func() { observability.Shutdown(context.Background()) })
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	breaker := circuitbreaker.NewManager(testBreakerSettings(), observability).Get("openai", "gpt-4o")
+	for i := 0; i < 2; i++ {
+		breaker.Allow()
+		breaker.Record(false, time.Millisecond)
+	}
+
+	for _, want := range []string{"[WARN] Circuit breaker state changed", "breaker: openai/gpt-4o", "from: closed", "to: open"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected %q in the observability log, got:\n%s", want, logs.String())
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package unit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/detectors"
+)
+
+// detectorPacks are the built-in packs loaded by the gateway
+var detectorPacks = []struct {
+	name string
+	load func() ([]detectors.Detector, error)
+}{
+	{"common", detectors.CommonRegexDetectors},
+	{"us_financial", detectors.USFinancialDetectors},
+	{"medical", detectors.MedicalDetectors},
+	{"iban", detectors.IBANDetectors},
+	{"uk", detectors.UKDetectors},
+	{"de", detectors.GermanDetectors},
+	{"fr", detectors.FrenchDetectors},
+	{"es", detectors.SpanishDetectors},
+	{"in", detectors.IndianDetectors},
+	{"ca", detectors.CanadianDetectors},
+	{"au", detectors.AustralianDetectors},
+	{"sg", detectors.SingaporeDetectors},
+	{"safe_harbor", detectors.SafeHarborDetectors},
+}
+
+// goldenCorpus lists, by detector name, text each detector must find and
+// text it must not
+var goldenCorpus = map[string]struct {
+	detects map[string]string
+	ignores []string
+}{
+	"ssn_detector": {
+		detects: map[string]string{"SSN on file: 123-45-6789.": "123-45-6789"},
+		ignores: []string{"666-12-3456", "912-34-5678", "123-00-4567", "123-45-0000", "ref 9123-45-67890"},
+	},
+	"credit_card_detector": {
+		detects: map[string]string{
+			"Charge 4111 1111 1111 1111 today": "4111 1111 1111 1111",
+			"Amex 3782-822463-10005 on file":   "3782-822463-10005",
+			// Trailing digit groups are backtracked over
+			"card 4111 1111 1111 1111 123":    "4111 1111 1111 1111",
+			"charge 4111-1111-1111-1111 2024": "4111-1111-1111-1111",
+			"card 4111111111111111 12 25":     "4111111111111111",
+		},
+		ignores: []string{"order 20240615123045", "card 4111111111111112", "id 41111111111111110000"},
+	},
+	"email_detector": {
+		detects: map[string]string{"Contact jane.doe@example.com now": "jane.doe@example.com"},
+		ignores: []string{"jane at example dot com"},
+	},
+	"phone_detector": {
+		detects: map[string]string{"Call (555) 123-4567 today": "(555) 123-4567"},
+		ignores: []string{"call extension 1234"},
+	},
+	"drivers_license_detector": {
+		detects: map[string]string{"License D1234567 issued": "D1234567"},
+		ignores: []string{"license pending", "Part D1234567 shipped", "Ask about D1234567"},
+	},
+	"bank_account_detector": {
+		detects: map[string]string{"Account 000123456789 closed": "000123456789"},
+		ignores: []string{"amount 123456789", "trace 1234567890123456789012"},
+	},
+	"routing_number_detector": {
+		detects: map[string]string{"ABA 021000021 for wires": "021000021"},
+		ignores: []string{"id 123456789", "ref 0210000210"},
+	},
+	"mrn_detector": {
+		detects: map[string]string{"Patient MRN AB1234567": "AB1234567"},
+		ignores: []string{"Patient MRN pending", "Ask about AB1234567"},
+	},
+	"hpbn_detector": {
+		detects: map[string]string{"Member 12345678 enrolled": "12345678"},
+		ignores: []string{"Member 1234567 enrolled", "Order 12345678 shipped", "Value 12345678"},
+	},
+	"iban_detector": {
+		detects: map[string]string{
+			"Pay to DE89 3704 0044 0532 0130 00 by Friday": "DE89 3704 0044 0532 0130 00",
+			"IBAN GB82WEST12345698765432.":                 "GB82WEST12345698765432",
+			// Trailing words and numbers are backtracked over
+			"IBAN ES91 2100 0418 4502 0005 1332 EUR 500": "ES91 2100 0418 4502 0005 1332",
+			"IBAN BE68 5390 0754 7034 SWIFT GEBABEBB":    "BE68 5390 0754 7034",
+			"IBAN BE68 5390 0754 7034 2024":              "BE68 5390 0754 7034",
+		},
+		ignores: []string{"Pay to DE89 3704 0044 0532 0130 01", "Ref XX12345678901234"},
+	},
+	"uk_nino_detector": {
+		detects: map[string]string{"NI number AB 12 34 56 C on file": "AB 12 34 56 C"},
+		ignores: []string{"code GB123456A", "part XAB123456C"},
+	},
+	"uk_nhs_detector": {
+		detects: map[string]string{"NHS number 943 476 5919 registered": "943 476 5919"},
+		ignores: []string{"NHS number 943 476 5918"},
+	},
+	"de_steuer_id_detector": {
+		detects: map[string]string{"Steuer-ID 86 095 742 719 liegt vor": "86 095 742 719"},
+		ignores: []string{"Steuer-ID 86095742718", "Kunde 12345678901"},
+	},
+	"fr_insee_detector": {
+		detects: map[string]string{"NIR 2 85 05 78 006 048 77 enregistré": "2 85 05 78 006 048 77"},
+		ignores: []string{"NIR 2 85 05 78 006 048 76"},
+	},
+	"es_dni_detector": {
+		detects: map[string]string{
+			"DNI 12345678Z vigente":   "12345678Z",
+			"NIE X-1234567-L vigente": "X-1234567-L",
+		},
+		ignores: []string{"DNI 12345678A", "ref AB12345678Z"},
+	},
+	"in_aadhaar_detector": {
+		detects: map[string]string{"Aadhaar 2341 2341 2346 linked": "2341 2341 2346"},
+		ignores: []string{"Aadhaar 2341 2341 2345", "order 1234 1234 1234"},
+	},
+	"in_pan_detector": {
+		detects: map[string]string{"PAN ABCPE1234F for income tax": "ABCPE1234F"},
+		ignores: []string{"code ABCXE1234F", "ref XABCPE1234F"},
+	},
+	"ca_sin_detector": {
+		detects: map[string]string{"SIN 130 692 544 on record": "130 692 544"},
+		ignores: []string{"SIN 130 692 545", "Invoice 130692544 paid"},
+	},
+	"au_tfn_detector": {
+		detects: map[string]string{"Tax file number 123 456 782 lodged": "123 456 782"},
+		ignores: []string{"Tax file number 123 456 781", "Invoice 123456782 paid"},
+	},
+	"sg_nric_detector": {
+		detects: map[string]string{"NRIC S1234567D verified": "S1234567D"},
+		ignores: []string{"NRIC S1234567A", "ref XS1234567D"},
+	},
+	"date_detector": {
+		detects: map[string]string{
+			"DOB 03/15/1948, seen today":       "03/15/1948",
+			"Admitted 2024-02-11 via ER":       "2024-02-11",
+			"Born March 15th, 1948 in Ohio":    "March 15th, 1948",
+			"Discharged on 4 Sept. 2023 at 10": "4 Sept. 2023",
+		},
+		ignores: []string{"Born in 1948", "Version 1.2.3", "DOB 13/45/1948", "Maybe 5 2024"},
+	},
+	"age_over_89_detector": {
+		detects: map[string]string{
+			"Patient is a 92 year-old male": "92",
+			"Age: 101, frail":               "101",
+		},
+		ignores: []string{"Age: 45", "Score 95 on the test", "Age 1995"},
+	},
+	"street_address_detector": {
+		detects: map[string]string{"Lives at 742 Evergreen Terrace Dr. since": "742 Evergreen Terrace Dr."},
+		ignores: []string{"Ward 7 West Wing", "Room 12 is ready"},
+	},
+	"zip_code_detector": {
+		detects: map[string]string{
+			"Address: Springfield, IL 62704": "04",
+			"ZIP 03601-1234 on file":         "03601-1234",
+		},
+		ignores: []string{"Invoice 62704 paid", "ZIP 627041"},
+	},
+	"device_serial_detector": {
+		detects: map[string]string{"Pacemaker serial number: PM-4471-XZ92 implanted": "PM-4471-XZ92"},
+		ignores: []string{"Serial number: PENDING", "Order PM-4471-XZ92 shipped"},
+	},
+	"vin_detector": {
+		detects: map[string]string{"Vehicle VIN 1M8GDM9AXKP042788 registered": "1M8GDM9AXKP042788"},
+		ignores: []string{"VIN 1M8GDM9AXKP042789", "VIN 12345678901234567"},
+	},
+	"url_detector": {
+		detects: map[string]string{
+			"Portal: https://portal.example.org/patients/8842?view=full.": "https://portal.example.org/patients/8842?view=full",
+			"(see www.example.org/jdoe)":                                  "www.example.org/jdoe",
+		},
+		ignores: []string{"http is a protocol", "visit example dot org"},
+	},
+	"ipv4_detector": {
+		detects: map[string]string{"Logged in from 192.168.10.42.": "192.168.10.42"},
+		ignores: []string{"Version 1.2.300.4", "ref 1921.168.10.42"},
+	},
+	"ipv6_detector": {
+		detects: map[string]string{"Host 2001:db8:85a3::8a2e:370:7334 connected": "2001:db8:85a3::8a2e:370:7334"},
+		ignores: []string{"at 12:30:45 today", "use the :: operator", "loopback ::1"},
+	},
+	"npi_detector": {
+		detects: map[string]string{"Prescriber NPI 1234567893 signed": "1234567893"},
+		ignores: []string{"NPI 1234567890", "Order 1234567893 shipped"},
+	},
+	"dea_detector": {
+		detects: map[string]string{"DEA AB1234563 on the script": "AB1234563"},
+		ignores: []string{"DEA AB1234564", "ref XAB1234563"},
+	},
+	"icd10_detector": {
+		detects: map[string]string{
+			"Diagnosis: E11.9 uncontrolled": "E11.9",
+			"dx J45 since childhood":        "J45",
+		},
+		ignores: []string{"Flight E11 departs", "Diagnosis: U07.1", "dx pending"},
+	},
+	"ndc_detector": {
+		detects: map[string]string{"Dispensed NDC 0002-8215-01 today": "0002-8215-01"},
+		ignores: []string{"Call 0002-8215-01", "NDC 0002-8215-011"},
+	},
+}
+
+// TestRegexDetectorContextKeywords tests that nearby keywords raise or lower
+// confidence and that matches below the minimum are dropped
+func TestRegexDetectorContextKeywords(t *testing.T) {
+	detector, err := detectors.NewRegexDetector("dl", "pii", "drivers_license", `[A-Z]\d{7}`, 0.5, 30)
+	if err != nil {
+		t.Fatalf("Failed to create detector: %v", err)
+	}
+	detector.SetContextKeywords([]string{"license"}, []string{"part"})
+
+	confidence := func(text string) float64 {
+		t.Helper()
+		results, err := detector.Detect(context.Background(), text)
+		if err != nil || len(results) != 1 {
+			t.Fatalf("Expected one detection in %q, got %+v (%v)", text, results, err)
+		}
+		return results[0].Confidence
+	}
+
+	near := confidence("License: D1234567")
+	far := confidence("License issued in 2019 is D1234567")
+	plain := confidence("Code D1234567")
+	negative := confidence("Part D1234567")
+	if !(near > far && far > plain && plain == 0.5 && negative < plain) {
+		t.Errorf("Unexpected confidences: near %v, far %v, plain %v, negative %v", near, far, plain, negative)
+	}
+	// Keywords must be whole words
+	if got := confidence("Licensed D1234567"); got != 0.5 {
+		t.Errorf("Expected a partial keyword to be ignored, got %v", got)
+	}
+
+	detector.SetMinConfidence(0.6)
+	if results, _ := detector.Detect(context.Background(), "Code D1234567 was replaced in the spring of last year by license E7654321"); len(results) != 1 || results[0].Text != "E7654321" {
+		t.Errorf("Expected only the match near a keyword, got %+v", results)
+	}
+}
+
+// TestDetectorPacksDetectGoldenCorpus tests that every built-in pack compiles
+// under RE2 and that each detector finds its golden corpus and nothing else
+func TestDetectorPacksDetectGoldenCorpus(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, pack := range detectorPacks {
+		packDetectors, err := pack.load()
+		if err != nil {
+			t.Fatalf("Pack %s failed to load: %v", pack.name, err)
+		}
+
+		for _, detector := range packDetectors {
+			name := detector.GetName()
+			seen[name] = true
+
+			corpus, ok := goldenCorpus[name]
+			if !ok {
+				t.Errorf("Detector %s in pack %s has no golden corpus", name, pack.name)
+				continue
+			}
+
+			for text, want := range corpus.detects {
+				results, err := detector.Detect(context.Background(), text)
+				if err != nil {
+					t.Fatalf("%s: Detect failed: %v", name, err)
+				}
+				if len(results) != 1 || results[0].Text != want {
+					t.Errorf("%s: expected %q in %q, got %+v", name, want, text, results)
+				}
+			}
+			for _, text := range corpus.ignores {
+				results, err := detector.Detect(context.Background(), text)
+				if err != nil {
+					t.Fatalf("%s: Detect failed: %v", name, err)
+				}
+				if len(results) != 0 {
+					t.Errorf("%s: expected no detections in %q, got %+v", name, text, results)
+				}
+			}
+		}
+	}
+
+	for name := range goldenCorpus {
+		if !seen[name] {
+			t.Errorf("Golden corpus entry %s matches no detector", name)
+		}
+	}
+}
+
+// TestForTenantsLimitsDetectors tests that a pack limited with ForTenants
+// only detects in text of its tenants
+func TestForTenantsLimitsDetectors(t *testing.T) {
+	safeHarbor, err := detectors.SafeHarborDetectors()
+	if err != nil {
+		t.Fatalf("SafeHarborDetectors failed: %v", err)
+	}
+	manager := detectors.NewDetectorManager()
+	for _, detector := range detectors.ForTenants([]string{"clinic"}, safeHarbor...) {
+		manager.AddDetector(detector)
+	}
+
+	text := "Prescriber NPI 1234567893, DOB 03/15/1948"
+	tests := []struct {
+		tenant string
+		want   []string
+	}{
+		{"clinic", []string{"1234567893", "03/15/1948"}},
+		{"retail", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		results, err := manager.Detect(detectors.WithTenant(context.Background(), tt.tenant), text)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		if got := spansOf(results); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tenant %q: expected %v, got %v", tt.tenant, tt.want, got)
+		}
+	}
+}
+
+// TestZipCodeDetectorContext tests that trimmed ZIP codes keep the context
+// window around the reported digits
+func TestZipCodeDetectorContext(t *testing.T) {
+	safeHarbor, err := detectors.SafeHarborDetectors()
+	if err != nil {
+		t.Fatalf("SafeHarborDetectors failed: %v", err)
+	}
+	zip := safeHarbor[len(safeHarbor)-1]
+
+	// The ZIP detector keeps 40 bytes of context on either side
+	text := "Referred from the downtown clinic, ZIP code 62704"
+	results, err := zip.Detect(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Text != "04" {
+		t.Fatalf("Expected the trimmed ZIP code, got %+v", results)
+	}
+	if want := text[results[0].Start-40:]; results[0].Context != want {
+		t.Errorf("Expected context %q, got %q", want, results[0].Context)
+	}
+}
@@ -0,0 +1,145 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/detectors"
+)
+
+// TestDictionaryDetectorMatchesTenantTerms tests case-insensitive,
+// normalized whole-word matching of each tenant's own terms
+func TestDictionaryDetectorMatchesTenantTerms(t *testing.T) {
+	dictionary := detectors.NewDictionaryDetector()
+	if err := dictionary.SetTermList("acme", detectors.TermList{
+		Name:  "codenames",
+		Terms: []string{"Project Falcon", "Falcon", "Café Noir", "db01.corp.internal"},
+	}); err != nil {
+		t.Fatalf("SetTermList failed: %v", err)
+	}
+	if err := dictionary.SetTermList("acme", detectors.TermList{Name: "customers", DataType: "pii", Terms: []string{"ＡＣＭＥ Holdings"}}); err != nil {
+		t.Fatalf("SetTermList failed: %v", err)
+	}
+
+	ctx := detectors.WithTenant(context.Background(), "acme")
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Ship PROJECT   falcon by Friday", []string{"PROJECT   falcon"}},
+		{"falcon alone, not falconry or SuperFalcon", []string{"falcon"}},
+		{"Meet at café noir tonight", []string{"café noir"}},
+		{"ssh DB01.corp.internal now", []string{"DB01.corp.internal"}},
+		{"signed with Acme Holdings", []string{"Acme Holdings"}},
+		{"nothing to see", nil},
+	}
+	for _, tt := range tests {
+		results, err := dictionary.Detect(ctx, tt.text)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		var got []string
+		for _, result := range results {
+			// Spans index the original text
+			if tt.text[result.Start:result.End] != result.Text {
+				t.Errorf("Span %d-%d does not match %q", result.Start, result.End, result.Text)
+			}
+			// Results carry the same metadata as regex detections
+			if result.ID == "" || result.DetectedAt.IsZero() || !strings.Contains(result.Context, result.Text) {
+				t.Errorf("Expected ID, context and detection time, got %+v", result)
+			}
+			got = append(got, result.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("In %q expected %v, got %v", tt.text, tt.want, got)
+		}
+	}
+
+	results, _ := dictionary.Detect(ctx, "Acme Holdings")
+	if len(results) != 1 || results[0].Type != "pii" || results[0].Subtype != "customers" {
+		t.Errorf("Expected the list's data class, got %+v", results)
+	}
+
+	// Other tenants' terms are not detected
+	if results, _ := dictionary.Detect(detectors.WithTenant(context.Background(), "globex"), "Project Falcon"); len(results) != 0 {
+		t.Errorf("Expected no detections for another tenant, got %+v", results)
+	}
+}
+
+// TestDictionaryDetectorHotReload tests that term lists loaded from files
+// and changed at runtime apply without rebuilding the manager
+func TestDictionaryDetectorHotReload(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "acme"), 0o755); err != nil {
+		t.Fatalf("Failed to create tenant directory: %v", err)
+	}
+	path := filepath.Join(dir, "acme", "hosts.txt")
+	if err := os.WriteFile(path, []byte("# internal hosts\nvault01\n\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write term list: %v", err)
+	}
+
+	dictionary := detectors.NewDictionaryDetector()
+	if err := dictionary.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	manager := detectors.NewDetectorManager()
+	manager.AddDetector(dictionary)
+
+	ctx := detectors.WithTenant(context.Background(), "acme")
+	detect := func(text string) []string {
+		t.Helper()
+		results, err := manager.Detect(ctx, text)
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		return spansOf(results)
+	}
+
+	if got := detect("login to vault01 and vault02"); !reflect.DeepEqual(got, []string{"vault01"}) {
+		t.Errorf("Expected vault01, got %v", got)
+	}
+
+	// Changed files are reloaded
+	if err := os.WriteFile(path, []byte("vault01\nvault02\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write term list: %v", err)
+	}
+	if err := dictionary.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	if got := detect("login to vault01 and vault02"); !reflect.DeepEqual(got, []string{"vault01", "vault02"}) {
+		t.Errorf("Expected both hosts, got %v", got)
+	}
+
+	// Lists from the admin API sit alongside file lists
+	if err := dictionary.SetTermList("acme", detectors.TermList{Name: "people", Terms: []string{"E-10442"}}); err != nil {
+		t.Fatalf("SetTermList failed: %v", err)
+	}
+	if got := detect("ticket from E-10442 about vault02"); !reflect.DeepEqual(got, []string{"E-10442", "vault02"}) {
+		t.Errorf("Expected the employee and host, got %v", got)
+	}
+
+	// Removed files remove their lists only
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove term list: %v", err)
+	}
+	if err := dictionary.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+	if got := detect("ticket from E-10442 about vault02"); !reflect.DeepEqual(got, []string{"E-10442"}) {
+		t.Errorf("Expected only the employee, got %v", got)
+	}
+
+	if err := dictionary.RemoveTermList("acme", "people"); err != nil {
+		t.Fatalf("RemoveTermList failed: %v", err)
+	}
+	if got := detect("ticket from E-10442"); len(got) != 0 {
+		t.Errorf("Expected no detections, got %v", got)
+	}
+	if err := dictionary.RemoveTermList("acme", "people"); err == nil {
+		t.Error("Expected removing a missing list to fail")
+	}
+}
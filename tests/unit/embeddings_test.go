@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/gemini"
+	"github.com/sentinel-platform/sentinel/adapters/ollama"
+	"github.com/sentinel-platform/sentinel/adapters/openai"
+)
+
+// TestEmbeddingRequestDecoding tests string and array input and unknown fields
+func TestEmbeddingRequestDecoding(t *testing.T) {
+	var req adapters.EmbeddingRequest
+	if err := json.Unmarshal([]byte(`{"model":"text-embedding-3-small","input":"Hello","input_type":"query"}`), &req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(req.Input) != 1 || req.Input[0] != "Hello" || string(req.Extra["input_type"]) != `"query"` {
+		t.Errorf("Unexpected request: %+v", req)
+	}
+
+	encoded, _ := json.Marshal(&req)
+	if string(encoded) != `{"model":"text-embedding-3-small","input":["Hello"],"input_type":"query"}` {
+		t.Errorf("Unexpected encoding: %s", encoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"model":"m","input":["a","b"]}`), &req); err != nil || len(req.Input) != 2 {
+		t.Errorf("Expected two inputs, got %v (%v)", req.Input, err)
+	}
+	if err := json.Unmarshal([]byte(`{"model":"m","input":[[1,2,3]]}`), &req); err == nil {
+		t.Error("Expected pre-tokenized input to be rejected")
+	}
+}
+
+// TestGatewayEmbedRedactsDeterministically tests that the same value is
+// replaced the same way in every input and every request
+func TestGatewayEmbedRedactsDeterministically(t *testing.T) {
+	adapter := &MockLLMAdapter{}
+	gw := newTestGateway(t, adapter, "enforce")
+
+	req := &adapters.EmbeddingRequest{
+		Model: "text-embedding-3-small",
+		Input: adapters.EmbeddingInput{"Invoice for jane.doe@example.com", "Where is the invoice for jane.doe@example.com?"},
+	}
+
+	resp, err := gw.Embed(context.Background(), "tenant-a", req)
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("Expected 2 embeddings, got %d", len(resp.Data))
+	}
+
+	upstream := adapter.LastEmbedRequest.Input
+	document, query := upstream[0], upstream[1]
+	if strings.Contains(document, "jane.doe") || strings.Contains(query, "jane.doe") {
+		t.Fatalf("Expected email to be redacted upstream, got %q", upstream)
+	}
+
+	pseudonym := strings.TrimPrefix(document, "Invoice for ")
+	if !strings.HasPrefix(pseudonym, "pseudo_") || query != "Where is the invoice for "+pseudonym+"?" {
+		t.Errorf("Expected the same pseudonym in both inputs, got %q", upstream)
+	}
+	if req.Input[0] != "Invoice for jane.doe@example.com" {
+		t.Errorf("Expected caller's request to be left untouched, got %q", req.Input[0])
+	}
+
+	if _, err := gw.Embed(context.Background(), "tenant-a", req); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if adapter.LastEmbedRequest.Input[0] != document {
+		t.Errorf("Expected a stable pseudonym across requests, got %q and %q", document, adapter.LastEmbedRequest.Input[0])
+	}
+}
+
+// TestOpenAIEmbed tests the embeddings request and response
+func TestOpenAIEmbed(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("Expected /embeddings, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, `{"object":"list","model":"text-embedding-3-small",
+			"data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],
+			"usage":{"prompt_tokens":3,"total_tokens":3}}`)
+	}))
+	defer server.Close()
+
+	adapter := openai.NewOpenAIAdapter("test-key", server.URL, 5*time.Second)
+	resp, err := adapter.Embed(context.Background(), &adapters.EmbeddingRequest{
+		Model:          "text-embedding-3-small",
+		Input:          adapters.EmbeddingInput{"Hello"},
+		EncodingFormat: "base64",
+		Dimensions:     2,
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if _, ok := body["encoding_format"]; ok || body["dimensions"] != float64(2) {
+		t.Errorf("Unexpected request body: %v", body)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Embedding[1] != 0.2 || resp.Usage.PromptTokens != 3 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+// TestOllamaEmbed tests /api/embed
+func TestOllamaEmbed(t *testing.T) {
+	baseURL := newOllamaServer(t, map[string]string{
+		"/api/embed": `{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":8}`,
+	}, nil)
+
+	adapter := ollama.NewOllamaAdapter(baseURL, 5*time.Second)
+	resp, err := adapter.Embed(context.Background(), &adapters.EmbeddingRequest{
+		Model: "nomic-embed-text",
+		Input: adapters.EmbeddingInput{"Hello", "World"},
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if len(resp.Data) != 2 || resp.Data[1].Index != 1 || resp.Data[1].Embedding[0] != 0.3 {
+		t.Errorf("Unexpected embeddings: %+v", resp.Data)
+	}
+	if resp.Usage.PromptTokens != 8 || resp.Usage.TotalTokens != 8 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestBedrockTitanEmbed tests InvokeModel embeddings, one input per call
+func TestBedrockTitanEmbed(t *testing.T) {
+	var received bedrockRequest
+	endpoint := newBedrockServer(t, http.StatusOK, http.Header{"X-Amzn-Bedrock-Input-Token-Count": {"4"}},
+		[]byte(`{"embedding":[0.5,0.25],"inputTextTokenCount":4}`), &received)
+
+	adapter := newTestBedrockAdapter(endpoint, nil)
+	resp, err := adapter.Embed(context.Background(), &adapters.EmbeddingRequest{
+		Model:      "amazon.titan-embed-text-v2:0",
+		Input:      adapters.EmbeddingInput{"Hello", "World"},
+		Dimensions: 256,
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if received.path != "/model/amazon.titan-embed-text-v2%3A0/invoke" || !received.signedOK {
+		t.Errorf("Unexpected request to %s (signed: %v)", received.path, received.signedOK)
+	}
+	if received.body != `{"dimensions":256,"inputText":"World"}` {
+		t.Errorf("Unexpected request body %s", received.body)
+	}
+	if len(resp.Data) != 2 || resp.Data[1].Index != 1 || resp.Usage.PromptTokens != 8 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	_, err = adapter.Embed(context.Background(), &adapters.EmbeddingRequest{
+		Model: "anthropic.claude-3-haiku-20240307-v1:0",
+		Input: adapters.EmbeddingInput{"Hello"},
+	})
+	if !errors.Is(err, adapters.ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported for a chat model, got %v", err)
+	}
+}
+
+// TestGeminiEmbed tests batchEmbedContents
+func TestGeminiEmbed(t *testing.T) {
+	var received geminiRequest
+	baseURL := newGeminiServer(t, http.StatusOK, `{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`, &received)
+
+	adapter := gemini.NewGeminiAdapter("gemini-key", baseURL, 5*time.Second)
+	resp, err := adapter.Embed(context.Background(), &adapters.EmbeddingRequest{
+		Model: "text-embedding-004",
+		Input: adapters.EmbeddingInput{"Hello", "World"},
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if received.path != "/models/text-embedding-004:batchEmbedContents" {
+		t.Errorf("Unexpected path %s", received.path)
+	}
+	want := `{"requests":[{"model":"models/text-embedding-004","content":{"parts":[{"text":"Hello"}]}},` +
+		`{"model":"models/text-embedding-004","content":{"parts":[{"text":"World"}]}}]}`
+	if received.body != want {
+		t.Errorf("Unexpected request body:\n%s\nwant:\n%s", received.body, want)
+	}
+	if len(resp.Data) != 2 || resp.Data[1].Embedding[1] != 0.4 {
+		t.Errorf("Unexpected embeddings: %+v", resp.Data)
+	}
+}
+
+// TestAnthropicEmbedUnsupported tests that Anthropic reports embeddings as unsupported
+func TestAnthropicEmbedUnsupported(t *testing.T) {
+	adapter := newAnthropicServer(t, http.StatusOK, `{}`, nil)
+	_, err := adapter.Embed(context.Background(), &adapters.EmbeddingRequest{Model: "claude-3-haiku-20240307", Input: adapters.EmbeddingInput{"Hello"}})
+	if !errors.Is(err, adapters.ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}
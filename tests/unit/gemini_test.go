@@ -0,0 +1,229 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/gemini"
+	"github.com/sentinel-platform/sentinel/sentinel/sentinel/detector"
+)
+
+// geminiRequest records what the Gemini stand-in received
+type geminiRequest struct {
+	path   string
+	query  string
+	apiKey string
+	body   string
+}
+
+// newGeminiServer starts a Gemini API stand-in
+func newGeminiServer(t *testing.T, status int, body string, received *geminiRequest) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if received != nil {
+			requestBody, _ := io.ReadAll(r.Body)
+			*received = geminiRequest{
+				path:   r.URL.Path,
+				query:  r.URL.RawQuery,
+				apiKey: r.Header.Get("x-goog-api-key"),
+				body:   string(requestBody),
+			}
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// TestGeminiChatCompletion tests role translation, usage and safety annotations
+func TestGeminiChatCompletion(t *testing.T) {
+	var received geminiRequest
+	baseURL := newGeminiServer(t, http.StatusOK, `{
+		"candidates": [{"index": 0, "finishReason": "MAX_TOKENS",
+			"content": {"role": "model", "parts": [{"text": "Hi"}, {"text": " there"}]},
+			"safetyRatings": [
+				{"category": "HARM_CATEGORY_HARASSMENT", "probability": "NEGLIGIBLE"},
+				{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "LOW"}
+			]}],
+		"usageMetadata": {"promptTokenCount": 8, "candidatesTokenCount": 2, "thoughtsTokenCount": 3, "totalTokenCount": 13},
+		"modelVersion": "gemini-1.5-flash-002",
+		"responseId": "resp-1"
+	}`, &received)
+
+	adapter := gemini.NewGeminiAdapter("gemini-key", baseURL, 5*time.Second)
+
+	var flagged []detector.DetectionResult
+	adapter.SetDetectionHandler(func(ctx context.Context, model string, detections []detector.DetectionResult) {
+		flagged = detections
+	})
+
+	// An explicit zero temperature is forwarded
+	temperature := float32(0)
+	resp, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model: "gemini-1.5-flash",
+		Messages: []adapters.Message{
+			{Role: "system", Content: "Be brief"},
+			{Role: "user", Content: "Hello"},
+			{Role: "assistant", Content: "Hi"},
+			{Role: "user", Content: "Again"},
+		},
+		Temperature: &temperature,
+		MaxTokens:   5,
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if received.path != "/models/gemini-1.5-flash:generateContent" || received.apiKey != "gemini-key" {
+		t.Errorf("Unexpected request %s with key %q", received.path, received.apiKey)
+	}
+	want := `{"contents":[{"role":"user","parts":[{"text":"Hello"}]},{"role":"model","parts":[{"text":"Hi"}]},{"role":"user","parts":[{"text":"Again"}]}],` +
+		`"systemInstruction":{"parts":[{"text":"Be brief"}]},"generationConfig":{"temperature":0,"maxOutputTokens":5}}`
+	if received.body != want {
+		t.Errorf("Unexpected request body:\n%s\nwant:\n%s", received.body, want)
+	}
+
+	if resp.ID != "resp-1" || resp.Model != "gemini-1.5-flash-002" {
+		t.Errorf("Unexpected response metadata: %+v", resp)
+	}
+	if resp.Choices[0].Message.Content != "Hi there" || resp.Choices[0].FinishReason != "length" {
+		t.Errorf("Unexpected choice: %+v", resp.Choices[0])
+	}
+	if resp.Usage.PromptTokens != 8 || resp.Usage.CompletionTokens != 5 || resp.Usage.TotalTokens != 13 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+
+	if len(flagged) != 1 || flagged[0].ViolationType != "safety_dangerous_content" || flagged[0].Score != 0.33 {
+		t.Errorf("Expected one dangerous content detection, got %+v", flagged)
+	}
+}
+
+// TestGeminiSafetyBlocks tests that blocked prompts and candidates are errors
+func TestGeminiSafetyBlocks(t *testing.T) {
+	baseURL := newGeminiServer(t, http.StatusOK, `{"promptFeedback": {"blockReason": "SAFETY", "safetyRatings": [
+		{"category": "HARM_CATEGORY_HATE_SPEECH", "probability": "HIGH", "blocked": true}
+	]}}`, nil)
+
+	adapter := gemini.NewGeminiAdapter("gemini-key", baseURL, 5*time.Second)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "gemini-1.5-flash",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var safetyErr *gemini.SafetyError
+	if !errors.As(err, &safetyErr) || safetyErr.Source != "prompt" {
+		t.Fatalf("Expected prompt SafetyError, got %v", err)
+	}
+	if safetyErr.ViolationType() != "safety_hate_speech" {
+		t.Errorf("Expected hate speech violation, got %s", safetyErr.ViolationType())
+	}
+
+	baseURL = newGeminiServer(t, http.StatusOK, `{"candidates": [{"index": 0, "finishReason": "SAFETY",
+		"content": {"role": "model", "parts": [{"text": "Par"}]},
+		"safetyRatings": [{"category": "HARM_CATEGORY_SEXUALLY_EXPLICIT", "probability": "MEDIUM", "blocked": true}]}]}`, nil)
+
+	adapter = gemini.NewGeminiAdapter("gemini-key", baseURL, 5*time.Second)
+	_, err = adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "gemini-1.5-flash",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+	if !errors.As(err, &safetyErr) || safetyErr.Source != "completion" {
+		t.Fatalf("Expected completion SafetyError, got %v", err)
+	}
+}
+
+// TestGeminiError tests API error parsing
+func TestGeminiError(t *testing.T) {
+	baseURL := newGeminiServer(t, http.StatusBadRequest,
+		`{"error": {"code": 400, "message": "API key not valid", "status": "INVALID_ARGUMENT"}}`, nil)
+
+	adapter := gemini.NewGeminiAdapter("bad-key", baseURL, 5*time.Second)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "gemini-1.5-flash",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var apiErr *gemini.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != "INVALID_ARGUMENT" || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected INVALID_ARGUMENT APIError, got %v", err)
+	}
+}
+
+// TestGeminiChatCompletionStream tests streamGenerateContent over server-sent events
+func TestGeminiChatCompletionStream(t *testing.T) {
+	var received geminiRequest
+	body := `data: {"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"Hel"}]}}],"usageMetadata":{"promptTokenCount":3},"responseId":"resp-2"}` + "\r\n\r\n" +
+		`data: {"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":2,"totalTokenCount":5}}` + "\r\n\r\n"
+
+	baseURL := newGeminiServer(t, http.StatusOK, body, &received)
+	adapter := gemini.NewGeminiAdapter("gemini-key", baseURL, 5*time.Second)
+
+	stream, err := adapter.ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "gemini-1.5-flash",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if received.path != "/models/gemini-1.5-flash:streamGenerateContent" || received.query != "alt=sse" {
+		t.Errorf("Unexpected stream request %s?%s", received.path, received.query)
+	}
+
+	var content strings.Builder
+	var chunks []*adapters.ChatCompletionStreamResponse
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		chunks = append(chunks, chunk)
+	}
+
+	if content.String() != "Hello" || len(chunks) != 2 {
+		t.Fatalf("Expected Hello in 2 chunks, got %q in %d", content.String(), len(chunks))
+	}
+	if chunks[0].Usage != nil || chunks[0].ID != "resp-2" || chunks[1].ID != "resp-2" {
+		t.Errorf("Unexpected first chunk: %+v", chunks[0])
+	}
+	if chunks[1].Choices[0].FinishReason != "stop" || chunks[1].Usage == nil || chunks[1].Usage.TotalTokens != 5 {
+		t.Errorf("Unexpected final chunk: %+v", chunks[1])
+	}
+}
+
+// TestGeminiListModels tests paging through models that support generateContent
+func TestGeminiListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pageToken") == "" {
+			io.WriteString(w, `{"models":[{"name":"models/gemini-1.5-flash","supportedGenerationMethods":["generateContent","countTokens"]},
+				{"name":"models/text-embedding-004","supportedGenerationMethods":["embedContent"]}],"nextPageToken":"page-2"}`)
+			return
+		}
+		io.WriteString(w, `{"models":[{"name":"models/gemini-1.5-pro","supportedGenerationMethods":["generateContent"]}]}`)
+	}))
+	defer server.Close()
+
+	adapter := gemini.NewGeminiAdapter("gemini-key", server.URL, 5*time.Second)
+	models, err := adapter.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 2 || models[0].ID != "gemini-1.5-flash" || models[1].ID != "gemini-1.5-pro" {
+		t.Errorf("Unexpected models: %+v", models)
+	}
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/anthropic"
+	"github.com/sentinel-platform/sentinel/adapters/gemini"
+	"github.com/sentinel-platform/sentinel/adapters/ollama"
+	"github.com/sentinel-platform/sentinel/adapters/openai"
+)
+
+// TestKindForStatus tests provider error classification by status code
+func TestKindForStatus(t *testing.T) {
+	cases := map[int]error{
+		http.StatusBadRequest:          adapters.ErrInvalidRequest,
+		http.StatusUnauthorized:        adapters.ErrAuthentication,
+		http.StatusForbidden:           adapters.ErrAuthentication,
+		http.StatusNotFound:            adapters.ErrInvalidRequest,
+		http.StatusRequestTimeout:      adapters.ErrServerError,
+		http.StatusTooManyRequests:     adapters.ErrRateLimited,
+		http.StatusInternalServerError: adapters.ErrServerError,
+		529:                            adapters.ErrServerError,
+	}
+	for status, want := range cases {
+		if got := adapters.KindForStatus(status); got != want {
+			t.Errorf("Status %d: expected %v, got %v", status, want, got)
+		}
+	}
+}
+
+// TestParseRetryAfter tests seconds, HTTP dates and retry-after-ms
+func TestParseRetryAfter(t *testing.T) {
+	if got := adapters.ParseRetryAfter(http.Header{"Retry-After": {"20"}}); got != 20*time.Second {
+		t.Errorf("Expected 20s, got %v", got)
+	}
+	if got := adapters.ParseRetryAfter(http.Header{"Retry-After": {"20"}, "Retry-After-Ms": {"1500"}}); got != 1500*time.Millisecond {
+		t.Errorf("Expected retry-after-ms to take precedence, got %v", got)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := adapters.ParseRetryAfter(http.Header{"Retry-After": {date}}); got < 55*time.Second || got > time.Minute {
+		t.Errorf("Expected about a minute, got %v", got)
+	}
+	if got := adapters.ParseRetryAfter(http.Header{}); got != 0 {
+		t.Errorf("Expected no wait, got %v", got)
+	}
+}
+
+// TestOpenAIRateLimitError tests that a 429 carries its payload and headers
+func TestOpenAIRateLimitError(t *testing.T) {
+	payload := `{"error":{"message":"Rate limit reached for gpt-4o","type":"requests","code":"rate_limit_exceeded"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "0")
+		w.Header().Set("X-Ratelimit-Reset-Requests", "6.5s")
+		w.Header().Set("X-Request-Id", "req_1")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, payload)
+	}))
+	defer server.Close()
+
+	adapter := openai.NewOpenAIAdapter("test-key", server.URL, 5*time.Second)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var providerErr *adapters.ProviderError
+	if !errors.As(err, &providerErr) || !errors.Is(err, adapters.ErrRateLimited) {
+		t.Fatalf("Expected a rate limited ProviderError, got %v", err)
+	}
+	if providerErr.Provider != "openai" || providerErr.Code != "rate_limit_exceeded" || providerErr.Message != "Rate limit reached for gpt-4o" {
+		t.Errorf("Unexpected error fields: %+v", providerErr)
+	}
+	if providerErr.RetryAfter != 7*time.Second || !providerErr.Retryable() || string(providerErr.Body) != payload {
+		t.Errorf("Unexpected retry details: %+v", providerErr)
+	}
+	if providerErr.RateLimit.Get("X-Ratelimit-Remaining-Requests") != "0" || providerErr.RateLimit.Get("X-Request-Id") != "" {
+		t.Errorf("Unexpected rate limit headers: %v", providerErr.RateLimit)
+	}
+
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected the OpenAI APIError to be wrapped, got %v", err)
+	}
+}
+
+// TestAnthropicOverloadedError tests that a 529 is a retryable server error
+func TestAnthropicOverloadedError(t *testing.T) {
+	adapter := newAnthropicServer(t, 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, nil)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var providerErr *adapters.ProviderError
+	if !errors.As(err, &providerErr) || !errors.Is(err, adapters.ErrServerError) || !providerErr.Retryable() {
+		t.Fatalf("Expected a retryable server error, got %v", err)
+	}
+	if providerErr.Code != "overloaded_error" {
+		t.Errorf("Expected overloaded_error, got %s", providerErr.Code)
+	}
+
+	var apiErr *anthropic.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 529 {
+		t.Errorf("Expected the Anthropic APIError to be wrapped, got %v", err)
+	}
+}
+
+// TestGeminiRetryInfo tests that Gemini's RetryInfo detail sets RetryAfter
+func TestGeminiRetryInfo(t *testing.T) {
+	baseURL := newGeminiServer(t, http.StatusTooManyRequests, `{"error":{"code":429,"status":"RESOURCE_EXHAUSTED",
+		"message":"Quota exceeded",
+		"details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"37s"}]}}`, nil)
+
+	adapter := gemini.NewGeminiAdapter("gemini-key", baseURL, 5*time.Second)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "gemini-1.5-flash",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var providerErr *adapters.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Kind != adapters.ErrRateLimited {
+		t.Fatalf("Expected a rate limited ProviderError, got %v", err)
+	}
+	if providerErr.RetryAfter != 37*time.Second || providerErr.Code != "RESOURCE_EXHAUSTED" {
+		t.Errorf("Unexpected error fields: %+v", providerErr)
+	}
+}
+
+// TestOllamaInvalidRequestError tests that an unknown model is an invalid request
+func TestOllamaInvalidRequestError(t *testing.T) {
+	adapter := ollama.NewOllamaAdapter(newOllamaServer(t, nil, nil), 5*time.Second)
+	_, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "missing",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var providerErr *adapters.ProviderError
+	if !errors.As(err, &providerErr) || !errors.Is(err, adapters.ErrInvalidRequest) || providerErr.Retryable() {
+		t.Fatalf("Expected a non-retryable invalid request error, got %v", err)
+	}
+	if providerErr.Message != "model not found" {
+		t.Errorf("Expected Ollama's message, got %q", providerErr.Message)
+	}
+}
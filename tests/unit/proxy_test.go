@@ -2,8 +2,12 @@ package unit
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,6 +35,19 @@ func (m *MockSentinelProcessor) ProcessResponse(ctx context.Context, resp *http.
 	return nil
 }
 
+// ResponseFuncProcessor applies a function to every upstream response
+type ResponseFuncProcessor struct {
+	fn func(resp *http.Response) error
+}
+
+func (m *ResponseFuncProcessor) ProcessRequest(ctx context.Context, req *http.Request) error {
+	return nil
+}
+
+func (m *ResponseFuncProcessor) ProcessResponse(ctx context.Context, resp *http.Response) error {
+	return m.fn(resp)
+}
+
 type MockRateLimiter struct{}
 
 func (m *MockRateLimiter) Allow(tenantID string) bool {
@@ -94,3 +111,81 @@ func TestReverseProxyTargetURLModification(t *testing.T) {
 		t.Errorf("Expected modified target URL %s, got %s", newTargetURL.String(), proxy.GetTargetURL().String())
 	}
 }
+
+// serveThroughProxy sends a request through a proxy whose Sentinel processor runs fn
+func serveThroughProxy(t *testing.T, upstreamBody string, fn func(resp *http.Response) error) *http.Response {
+	t.Helper()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, upstreamBody)
+	}))
+	t.Cleanup(upstream.Close)
+
+	targetURL, err := url.Parse(upstream.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse target URL: %v", err)
+	}
+
+	rp := proxy.NewReverseProxy(targetURL, &MockCipherMeshProcessor{}, &ResponseFuncProcessor{fn: fn}, &MockRateLimiter{}, 5*time.Second)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+	req.Header.Set("X-Tenant", "tenant-a")
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+
+	return rec.Result()
+}
+
+// TestReverseProxyProcessesResponse tests that processors can rewrite upstream bodies
+func TestReverseProxyProcessesResponse(t *testing.T) {
+	resp := serveThroughProxy(t, `{"content":"hello"}`, func(resp *http.Response) error {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(strings.NewReader(strings.ToUpper(string(body))))
+		return nil
+	})
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if string(body) != `{"CONTENT":"HELLO"}` {
+		t.Errorf("Expected processed body, got %s", body)
+	}
+	if resp.ContentLength != int64(len(body)) {
+		t.Errorf("Expected content length %d, got %d", len(body), resp.ContentLength)
+	}
+}
+
+// TestReverseProxyPolicyBlock tests that a policy block returns 403 without upstream bytes
+func TestReverseProxyPolicyBlock(t *testing.T) {
+	resp := serveThroughProxy(t, `{"content":"secret-value"}`, func(resp *http.Response) error {
+		return fmt.Errorf("%w: secret detected", proxy.ErrPolicyBlocked)
+	})
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", resp.StatusCode)
+	}
+	if strings.Contains(string(body), "secret-value") {
+		t.Errorf("Blocked response leaked upstream body: %s", body)
+	}
+}
+
+// TestReverseProxyProcessorFailure tests that processor errors return a clean 502
+func TestReverseProxyProcessorFailure(t *testing.T) {
+	resp := serveThroughProxy(t, `{"content":"secret-value"}`, func(resp *http.Response) error {
+		return fmt.Errorf("detector unavailable")
+	})
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", resp.StatusCode)
+	}
+	if strings.Contains(string(body), "secret-value") {
+		t.Errorf("Failed response leaked upstream body: %s", body)
+	}
+}
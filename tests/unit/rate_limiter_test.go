@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/proxy"
+)
+
+// TestTokenBucketBurst tests that a tenant can burst up to the limit and is then throttled
+func TestTokenBucketBurst(t *testing.T) {
+	limiter := proxy.NewTokenBucketRateLimiter(proxy.RateLimit{RequestsPerMinute: 60, BurstLimit: 3}, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("tenant-a") {
+			t.Fatalf("Request %d should be allowed within burst", i+1)
+		}
+	}
+
+	if limiter.Allow("tenant-a") {
+		t.Error("Request beyond burst should be throttled")
+	}
+
+	retryAfter := limiter.GetRetryAfter("tenant-a")
+	if retryAfter <= 900*time.Millisecond || retryAfter > time.Second {
+		t.Errorf("Expected retry after close to 1s, got %v", retryAfter)
+	}
+
+	// Other tenants have their own buckets
+	if !limiter.Allow("tenant-b") {
+		t.Error("Another tenant should not be throttled")
+	}
+}
+
+// TestTokenBucketRefill tests that tokens refill over time
+func TestTokenBucketRefill(t *testing.T) {
+	limiter := proxy.NewTokenBucketRateLimiter(proxy.RateLimit{RequestsPerMinute: 6000, BurstLimit: 1}, time.Minute)
+
+	if !limiter.Allow("tenant-a") {
+		t.Fatal("First request should be allowed")
+	}
+	if limiter.Allow("tenant-a") {
+		t.Fatal("Second request should be throttled")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if !limiter.Allow("tenant-a") {
+		t.Error("Request should be allowed after refill")
+	}
+}
+
+// TestTokenBucketTenantOverride tests per-tenant overrides
+func TestTokenBucketTenantOverride(t *testing.T) {
+	limiter := proxy.NewTokenBucketRateLimiter(proxy.RateLimit{RequestsPerMinute: 60, BurstLimit: 1}, time.Minute)
+	limiter.SetTenantLimit("premium", proxy.RateLimit{RequestsPerMinute: 600, BurstLimit: 5})
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if limiter.Allow("premium") {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("Expected 5 requests allowed for premium tenant, got %d", allowed)
+	}
+
+	unlimited := proxy.NewTokenBucketRateLimiter(proxy.RateLimit{}, time.Minute)
+	for i := 0; i < 100; i++ {
+		if !unlimited.Allow("tenant-a") {
+			t.Fatal("A zero rate should mean unlimited")
+		}
+	}
+}
+
+// TestTokenBucketEvictsIdleTenants tests that idle tenants are forgotten
+func TestTokenBucketEvictsIdleTenants(t *testing.T) {
+	limiter := proxy.NewTokenBucketRateLimiter(proxy.RateLimit{RequestsPerMinute: 6000, BurstLimit: 1}, 10*time.Millisecond)
+
+	limiter.Allow("tenant-a")
+	limiter.Allow("tenant-b")
+	if limiter.TenantCount() != 2 {
+		t.Fatalf("Expected 2 tracked tenants, got %d", limiter.TenantCount())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	if evicted := limiter.EvictIdle(); evicted != 2 {
+		t.Errorf("Expected 2 evicted tenants, got %d", evicted)
+	}
+	if limiter.TenantCount() != 0 {
+		t.Errorf("Expected no tracked tenants, got %d", limiter.TenantCount())
+	}
+}
+
+// TestTokenBucketConcurrency tests that the burst is never exceeded under concurrency
+func TestTokenBucketConcurrency(t *testing.T) {
+	limiter := proxy.NewTokenBucketRateLimiter(proxy.RateLimit{RequestsPerMinute: 1, BurstLimit: 50}, time.Minute)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	allowed := 0
+
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow("tenant-a") {
+				mutex.Lock()
+				allowed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 50 {
+		t.Errorf("Expected exactly 50 allowed requests, got %d", allowed)
+	}
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/openai"
+)
+
+// newMockRegistry creates a registry whose "mock" type builds a new MockLLMAdapter
+func newMockRegistry() *adapters.Registry {
+	registry := adapters.NewRegistry()
+	registry.Register("mock", func(settings map[string]interface{}) (adapters.LLMAdapter, error) {
+		return &MockLLMAdapter{}, nil
+	})
+	return registry
+}
+
+// TestRegistryResolvesByModel tests exact, prefix and fallback model routing
+func TestRegistryResolvesByModel(t *testing.T) {
+	registry := newMockRegistry()
+
+	fallback, _ := registry.AddAdapter(&adapters.AdapterConfig{Type: "mock"})
+	gpt, _ := registry.AddAdapter(&adapters.AdapterConfig{Type: "mock", Models: []string{"gpt-*"}})
+	gpt4o, _ := registry.AddAdapter(&adapters.AdapterConfig{Type: "mock", Models: []string{"gpt-4o"}})
+
+	tests := []struct {
+		model    string
+		expected adapters.LLMAdapter
+	}{
+		{"gpt-4o", gpt4o},
+		{"gpt-4o-mini", gpt},
+		{"gpt-3.5-turbo", gpt},
+		{"claude-3-5-sonnet", fallback},
+	}
+
+	for _, tt := range tests {
+		adapter, err := registry.Resolve(tt.model)
+		if err != nil {
+			t.Fatalf("Resolve(%s) failed: %v", tt.model, err)
+		}
+		if adapter != tt.expected {
+			t.Errorf("Resolve(%s) returned the wrong adapter", tt.model)
+		}
+	}
+
+	if len(registry.Adapters()) != 3 {
+		t.Errorf("Expected 3 adapters, got %d", len(registry.Adapters()))
+	}
+}
+
+// TestRegistryForwardsRequests tests that the registry acts as an LLMAdapter
+func TestRegistryForwardsRequests(t *testing.T) {
+	registry := newMockRegistry()
+	adapter, _ := registry.AddAdapter(&adapters.AdapterConfig{Type: "mock", Models: []string{"gpt-4o"}})
+
+	req := &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello"}},
+	}
+	if _, err := registry.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if adapter.(*MockLLMAdapter).LastRequest != req {
+		t.Error("Expected request to reach the routed adapter")
+	}
+
+	req.Model = "claude-3-5-sonnet"
+	if _, err := registry.ChatCompletion(context.Background(), req); !errors.Is(err, adapters.ErrNoAdapter) {
+		t.Errorf("Expected ErrNoAdapter, got %v", err)
+	}
+}
+
+// TestRegistryUnknownType tests that unregistered adapter types are rejected
+func TestRegistryUnknownType(t *testing.T) {
+	registry := adapters.NewRegistry()
+	if _, err := registry.CreateAdapter(&adapters.AdapterConfig{Type: "unknown"}); err == nil {
+		t.Error("Expected error for unknown adapter type")
+	}
+}
+
+// TestOpenAIAdapterFromSettings tests building the OpenAI adapter from lowercased config keys
+func TestOpenAIAdapterFromSettings(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-test")
+
+	registry := adapters.NewRegistry()
+	registry.Register("openai", openai.NewAdapterFromSettings)
+
+	adapter, err := registry.CreateAdapter(&adapters.AdapterConfig{
+		Type: "openai",
+		Settings: map[string]interface{}{
+			"apikeyenv": "TEST_OPENAI_KEY",
+			"baseurl":   "https://example.com/v1",
+			"timeout":   "5s",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateAdapter failed: %v", err)
+	}
+	if err := adapter.ValidateConfig(); err != nil {
+		t.Errorf("Expected API key from environment, got %v", err)
+	}
+
+	_, err = registry.CreateAdapter(&adapters.AdapterConfig{
+		Type:     "openai",
+		Settings: map[string]interface{}{"timeout": "soon"},
+	})
+	if err == nil {
+		t.Error("Expected error for invalid timeout")
+	}
+}
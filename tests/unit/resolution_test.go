@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/detectors"
+)
+
+// spanDetector reports fixed spans of the text it is given
+type spanDetector struct {
+	name       string
+	dataType   string
+	confidence float64
+	spans      [][2]int
+}
+
+func (d *spanDetector) Detect(ctx context.Context, text string) ([]detectors.DetectionResult, error) {
+	var results []detectors.DetectionResult
+	for _, span := range d.spans {
+		results = append(results, detectors.DetectionResult{
+			Type:       d.dataType,
+			Subtype:    d.name,
+			Confidence: d.confidence,
+			Start:      span[0],
+			End:        span[1],
+			Text:       text[span[0]:span[1]],
+		})
+	}
+	return results, nil
+}
+
+func (d *spanDetector) GetType() string {
+	return d.dataType
+}
+
+func (d *spanDetector) GetName() string {
+	return d.name
+}
+
+// TestDetectorManagerResolvesOverlaps tests each resolution strategy on the
+// same overlapping detections
+func TestDetectorManagerResolvesOverlaps(t *testing.T) {
+	text := "Pay 4111 1111 1111 1111 now, mail a@b.co"
+	manager := detectors.NewDetectorManager()
+	manager.AddDetector(&spanDetector{name: "bank_account", dataType: "pii", confidence: 0.95, spans: [][2]int{{4, 18}}})
+	manager.AddDetector(&spanDetector{name: "credit_card", dataType: "pci", confidence: 0.9, spans: [][2]int{{4, 23}}})
+	manager.AddDetector(&spanDetector{name: "hpbn", dataType: "phi", confidence: 0.6, spans: [][2]int{{9, 23}}})
+	manager.AddDetector(&spanDetector{name: "email", dataType: "pii", confidence: 0.85, spans: [][2]int{{34, 40}}})
+
+	tests := []struct {
+		strategy detectors.ResolutionStrategy
+		want     string
+	}{
+		{detectors.ResolveByConfidence, "bank_account"},
+		{detectors.ResolveByPriority, "credit_card"},
+		{detectors.ResolveByLength, "credit_card"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			if err := manager.SetResolution(tt.strategy, nil); err != nil {
+				t.Fatalf("SetResolution failed: %v", err)
+			}
+
+			resolution, err := manager.DetectWithAlternatives(context.Background(), text)
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+			if len(resolution.Results) != 2 || len(resolution.Suppressed) != 2 {
+				t.Fatalf("Expected 2 results and 2 suppressed, got %+v", resolution)
+			}
+
+			// The winner covers every span it suppressed
+			card := resolution.Results[0]
+			if card.Subtype != tt.want || card.Start != 4 || card.End != 23 || card.Text != "4111 1111 1111 1111" {
+				t.Errorf("Unexpected winner: %+v", card)
+			}
+			if email := resolution.Results[1]; email.Text != "a@b.co" {
+				t.Errorf("Unexpected second result: %+v", email)
+			}
+			for _, suppressed := range resolution.Suppressed {
+				if suppressed.Winner != 0 || suppressed.Subtype == tt.want {
+					t.Errorf("Unexpected suppressed result: %+v", suppressed)
+				}
+			}
+		})
+	}
+
+	if err := manager.SetResolution("first", nil); err == nil {
+		t.Error("Expected an unknown strategy to be rejected")
+	}
+}
+
+// TestDetectorManagerMergesAdjacentSpans tests that touching and nested
+// detections are merged into one offset-stable span
+func TestDetectorManagerMergesAdjacentSpans(t *testing.T) {
+	text := "id ABC123456789 ok"
+	manager := detectors.NewDetectorManager()
+	manager.AddDetector(&spanDetector{name: "prefix", dataType: "generic", confidence: 0.5, spans: [][2]int{{3, 6}}})
+	manager.AddDetector(&spanDetector{name: "digits", dataType: "phi", confidence: 0.8, spans: [][2]int{{6, 15}}})
+	manager.AddDetector(&spanDetector{name: "nested", dataType: "pii", confidence: 0.7, spans: [][2]int{{8, 12}}})
+
+	results, err := manager.Detect(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Subtype != "digits" || results[0].Text != "ABC123456789" {
+		t.Errorf("Expected one merged span, got %+v", results)
+	}
+}
@@ -0,0 +1,215 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/openai"
+)
+
+// newSSEAdapter creates an OpenAI adapter backed by a server that writes body as an event stream
+func newSSEAdapter(t *testing.T, body string) *openai.OpenAIAdapter {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return openai.NewOpenAIAdapter("test-key", server.URL, 5*time.Second)
+}
+
+// TestChatCompletionStreamRecv tests SSE parsing of chunks, comments, multi-line data and [DONE]
+func TestChatCompletionStreamRecv(t *testing.T) {
+	body := ": keep-alive\n\n" +
+		"data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Hel\"}}]}\n\n" +
+		"data: {\"id\":\"chatcmpl-1\",\n" +
+		"data: \"choices\":[{\"index\":0,\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\r\n\r\n" +
+		"data: [DONE]\n\n"
+
+	adapter := newSSEAdapter(t, body)
+	stream, err := adapter.ChatCompletionStream(context.Background(), &openai.ChatCompletionRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var finishReason string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		finishReason = chunk.Choices[0].FinishReason
+	}
+
+	if content.String() != "Hello" {
+		t.Errorf("Expected content Hello, got %q", content.String())
+	}
+	if finishReason != "stop" {
+		t.Errorf("Expected finish reason stop, got %q", finishReason)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the stream finished, got %v", err)
+	}
+}
+
+// TestChatCompletionStreamError tests that a mid-stream error event is
+// returned as a classified ProviderError wrapping an APIError
+func TestChatCompletionStreamError(t *testing.T) {
+	body := "data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"event: error\n" +
+		"data: {\"error\":{\"message\":\"The server had an error\",\"type\":\"server_error\"}}\n\n"
+
+	adapter := newSSEAdapter(t, body)
+	stream, err := adapter.ChatCompletionStream(context.Background(), &openai.ChatCompletionRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Expected first chunk, got %v", err)
+	}
+
+	_, err = stream.Recv()
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.Type != "server_error" || apiErr.Message != "The server had an error" {
+		t.Errorf("Unexpected error contents: %+v", apiErr)
+	}
+	var providerErr *adapters.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Provider != "openai" || !errors.Is(err, adapters.ErrServerError) {
+		t.Errorf("Expected a server error, got %v", err)
+	}
+
+	// Rate limits mid-stream are classified by their code
+	adapter = newSSEAdapter(t, "data: {\"error\":{\"message\":\"Rate limit reached\",\"type\":\"requests\",\"code\":\"rate_limit_exceeded\"}}\n\n")
+	stream, err = adapter.ChatCompletionStream(context.Background(), &openai.ChatCompletionRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Recv(); !errors.Is(err, adapters.ErrRateLimited) || !errors.As(err, &providerErr) || providerErr.Code != "rate_limit_exceeded" {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+}
+
+// TestChatCompletionStreamTruncated tests that a stream ending without [DONE] returns io.EOF
+func TestChatCompletionStreamTruncated(t *testing.T) {
+	body := "data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}"
+
+	adapter := newSSEAdapter(t, body)
+	stream, err := adapter.ChatCompletionStream(context.Background(), &openai.ChatCompletionRequest{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Expected final chunk, got %v", err)
+	}
+	if chunk.Choices[0].Delta.Content != "Hi" {
+		t.Errorf("Expected content Hi, got %q", chunk.Choices[0].Delta.Content)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+// StreamingMockLLMAdapter echoes the last message as a stream of small chunks
+type StreamingMockLLMAdapter struct {
+	MockLLMAdapter
+	ChunkSize int
+}
+
+func (m *StreamingMockLLMAdapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	m.LastRequest = req
+	return &sliceStream{
+		text: "You said: " + req.Messages[len(req.Messages)-1].Content,
+		size: m.ChunkSize,
+	}, nil
+}
+
+// sliceStream emits text in fixed-size deltas
+type sliceStream struct {
+	text string
+	size int
+}
+
+func (s *sliceStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if s.text == "" {
+		return nil, io.EOF
+	}
+
+	n := s.size
+	if n > len(s.text) {
+		n = len(s.text)
+	}
+	delta := s.text[:n]
+	s.text = s.text[n:]
+
+	return &adapters.ChatCompletionStreamResponse{
+		ID:      "chatcmpl-test",
+		Choices: []adapters.StreamChoice{{Delta: adapters.Message{Content: delta}}},
+	}, nil
+}
+
+func (s *sliceStream) Close() error {
+	return nil
+}
+
+// TestGatewayStreamRehydratesSplitTokens tests that tokens split across chunks are restored
+func TestGatewayStreamRehydratesSplitTokens(t *testing.T) {
+	adapter := &StreamingMockLLMAdapter{ChunkSize: 3}
+	gw := newTestGateway(t, adapter, "enforce")
+
+	req := &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Email jane.doe@example.com today"}},
+		Stream:   true,
+	}
+
+	stream, err := gw.ChatCompletionStream(context.Background(), "tenant-a", req)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+		}
+	}
+
+	if strings.Contains(adapter.LastRequest.Messages[0].Content, "jane.doe@example.com") {
+		t.Errorf("Expected email to be redacted upstream, got %s", adapter.LastRequest.Messages[0].Content)
+	}
+	if content.String() != "You said: Email jane.doe@example.com today" {
+		t.Errorf("Expected rehydrated stream, got %q", content.String())
+	}
+}
@@ -0,0 +1,229 @@
+package unit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/tokenizer"
+)
+
+// tiktokenFile encodes tokens in tiktoken format, ranked in order
+func tiktokenFile(tokens ...string) io.Reader {
+	var file strings.Builder
+	for rank, token := range tokens {
+		fmt.Fprintf(&file, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	return strings.NewReader(file.String())
+}
+
+// TestEncodingMergesByRank tests that BPE merges the lowest ranked pair first
+// within each pre-tokenized piece
+func TestEncodingMergesByRank(t *testing.T) {
+	encoding, err := tokenizer.LoadEncoding(tokenizer.EncodingCl100k, tiktokenFile("a", "b", "c", " ", "ab", "abc", " a", "  ", " b", "\n"))
+	if err != nil {
+		t.Fatalf("Failed to load encoding: %v", err)
+	}
+
+	tests := []struct {
+		text string
+		want []int
+	}{
+		// " abc" merges ab before " a" as it ranks lower
+		{"abc abc", []int{5, 3, 5}},
+		// A run of spaces leaves its last space to the following word
+		{"a   b", []int{0, 7, 8}},
+		{"a  \nb", []int{0, 7, 9, 1}},
+	}
+	for _, tt := range tests {
+		if got := encoding.Encode(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := tokenizer.LoadEncoding("p99k_base", tiktokenFile("a")); err == nil {
+		t.Error("Expected an unknown encoding to be rejected")
+	}
+	if _, err := tokenizer.LoadEncoding(tokenizer.EncodingCl100k, strings.NewReader("YQ==\n")); err == nil {
+		t.Error("Expected a line without a rank to be rejected")
+	}
+}
+
+// TestTokenizerCountsByModel tests that loaded encodings count their models
+// exactly and other models fall back to approximate counters
+func TestTokenizerCountsByModel(t *testing.T) {
+	tok, err := tokenizer.New(tokenizer.Settings{})
+	if err != nil {
+		t.Fatalf("Failed to create tokenizer: %v", err)
+	}
+
+	if got := tok.CountText("claude-3-5-sonnet", "Hello, world!"); got != 4 {
+		t.Errorf("Expected 4 approximate tokens, got %d", got)
+	}
+	if got := tok.CountText("llama3", "こんにちは"); got != 5 {
+		t.Errorf("Expected a token per non-ASCII letter, got %d", got)
+	}
+
+	encoding, err := tokenizer.LoadEncoding(tokenizer.EncodingO200k, tiktokenFile("H", "i", "Hi"))
+	if err != nil {
+		t.Fatalf("Failed to load encoding: %v", err)
+	}
+	tok.AddEncoding(encoding)
+	if got := tok.CountText("gpt-4o-mini", "HiHi"); got != 2 {
+		t.Errorf("Expected the loaded encoding to count 2 tokens, got %d", got)
+	}
+	if got := tok.Model("anthropic.claude-3-haiku-20240307-v1:0"); got.Encoding != tokenizer.FamilyAnthropic || got.MaxOutputTokens != 4096 {
+		t.Errorf("Expected the Bedrock model to match claude-3-haiku, got %+v", got)
+	}
+	for _, model := range []string{"gpt-4.5-preview", "gpt-4-vision-preview"} {
+		if got := tok.Model(model); got.ContextWindow != 128000 {
+			t.Errorf("Expected %s to have a 128k context window, got %+v", model, got)
+		}
+	}
+
+	if _, err := tokenizer.New(tokenizer.Settings{Models: map[string]tokenizer.ModelSettings{"x": {Encoding: "morse"}}}); err == nil {
+		t.Error("Expected an unknown model encoding to be rejected")
+	}
+}
+
+// TestTokenizerCheckLimits tests output and context window limits
+func TestTokenizerCheckLimits(t *testing.T) {
+	tok, err := tokenizer.New(tokenizer.Settings{
+		EnforceLimits: true,
+		Models: map[string]tokenizer.ModelSettings{
+			"tiny-*": {Encoding: tokenizer.EncodingCl100k, ContextWindow: 50},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create tokenizer: %v", err)
+	}
+
+	var limitErr *tokenizer.LimitError
+	_, err = tok.Check(&adapters.ChatCompletionRequest{
+		Model:     "gpt-4o",
+		Messages:  []adapters.Message{{Role: "user", Content: "Hi"}},
+		MaxTokens: 20000,
+	})
+	if !errors.As(err, &limitErr) || limitErr.Limit != "max_output_tokens" || limitErr.Allowed != 16384 {
+		t.Errorf("Expected a max_output_tokens error, got %v", err)
+	}
+
+	// Approximate counts are not checked against the context window
+	req := &adapters.ChatCompletionRequest{
+		Model:     "tiny-chat",
+		Messages:  []adapters.Message{{Role: "user", Content: strings.Repeat("word ", 40)}},
+		MaxTokens: 10,
+	}
+	if _, err := tok.Check(req); err != nil {
+		t.Errorf("Expected an approximate count not to be enforced, got %v", err)
+	}
+
+	encoding, err := tokenizer.LoadEncoding(tokenizer.EncodingCl100k, tiktokenFile("w", "o", "r", "d", " ", "word", " word"))
+	if err != nil {
+		t.Fatalf("Failed to load encoding: %v", err)
+	}
+	tok.AddEncoding(encoding)
+	promptTokens, err := tok.Check(req)
+	if !errors.As(err, &limitErr) || limitErr.Limit != "context_window" || limitErr.PromptTokens != promptTokens {
+		t.Errorf("Expected a context_window error, got %v", err)
+	}
+
+	lenient, _ := tokenizer.New(tokenizer.Settings{})
+	if _, err := lenient.Check(req); err != nil {
+		t.Errorf("Expected limits not to be enforced, got %v", err)
+	}
+}
+
+// TestGatewayEstimatesUsage tests that missing usage is filled in and that
+// requests over their model's limits are not forwarded
+func TestGatewayEstimatesUsage(t *testing.T) {
+	tok, err := tokenizer.New(tokenizer.Settings{EnforceLimits: true})
+	if err != nil {
+		t.Fatalf("Failed to create tokenizer: %v", err)
+	}
+	adapter := &MockLLMAdapter{}
+	gw := newTestGateway(t, adapter, "enforce")
+	gw.SetTokenizer(tok)
+
+	resp, err := gw.ChatCompletion(context.Background(), "tenant-a", &adapters.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet",
+		Messages: []adapters.Message{{Role: "user", Content: "Hello there"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	usage := resp.Usage
+	if usage.PromptTokens == 0 || usage.CompletionTokens == 0 || usage.TotalTokens != usage.PromptTokens+usage.CompletionTokens {
+		t.Errorf("Expected usage to be estimated, got %+v", usage)
+	}
+
+	adapter.LastRequest = nil
+	_, err = gw.ChatCompletion(context.Background(), "tenant-a", &adapters.ChatCompletionRequest{
+		Model:     "gpt-4o",
+		Messages:  []adapters.Message{{Role: "user", Content: "Hello there"}},
+		MaxTokens: 100000,
+	})
+	var limitErr *tokenizer.LimitError
+	if !errors.As(err, &limitErr) {
+		t.Errorf("Expected a LimitError, got %v", err)
+	}
+	if adapter.LastRequest != nil {
+		t.Error("Expected the request not to be forwarded")
+	}
+
+	embedding, err := gw.Embed(context.Background(), "tenant-a", &adapters.EmbeddingRequest{
+		Model: "text-embedding-3-small",
+		Input: adapters.EmbeddingInput{"Hello there"},
+	})
+	if err != nil || embedding.Usage.PromptTokens != 2 || embedding.Usage.TotalTokens != 2 {
+		t.Errorf("Expected embedding usage to be estimated, got %+v (%v)", embedding, err)
+	}
+}
+
+// TestGatewayStreamIncludesUsage tests that streams end with a usage chunk
+// when the client asks for one
+func TestGatewayStreamIncludesUsage(t *testing.T) {
+	tok, err := tokenizer.New(tokenizer.Settings{})
+	if err != nil {
+		t.Fatalf("Failed to create tokenizer: %v", err)
+	}
+	gw := newTestGateway(t, &StreamingMockLLMAdapter{ChunkSize: 4}, "enforce")
+	gw.SetTokenizer(tok)
+
+	stream, err := gw.ChatCompletionStream(context.Background(), "tenant-a", &adapters.ChatCompletionRequest{
+		Model:    "mistral-large",
+		Messages: []adapters.Message{{Role: "user", Content: "Count me"}},
+		Stream:   true,
+		Extra:    adapters.Extra{"stream_options": json.RawMessage(`{"include_usage":true}`)},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var last *adapters.ChatCompletionStreamResponse
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		last = chunk
+	}
+
+	if last == nil || last.Usage == nil || len(last.Choices) != 0 {
+		t.Fatalf("Expected a final usage chunk, got %+v", last)
+	}
+	if last.Usage.CompletionTokens == 0 || last.Usage.TotalTokens != last.Usage.PromptTokens+last.Usage.CompletionTokens {
+		t.Errorf("Unexpected usage: %+v", last.Usage)
+	}
+}
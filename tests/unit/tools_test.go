@@ -0,0 +1,512 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+	"github.com/sentinel-platform/sentinel/adapters/anthropic"
+	"github.com/sentinel-platform/sentinel/adapters/gemini"
+	"github.com/sentinel-platform/sentinel/adapters/ollama"
+)
+
+// tokenPattern matches the tokens the redactor substitutes for sensitive values
+var tokenPattern = regexp.MustCompile(`token_[A-Za-z0-9+/=_-]+`)
+
+// weatherTool is a tool definition shared by the translation tests
+var weatherTool = adapters.Tool{
+	Type: "function",
+	Function: adapters.ToolFunction{
+		Name:        "get_weather",
+		Description: "Get the weather",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+	},
+}
+
+// toolConversation is a conversation in which the assistant called a tool
+func toolConversation() []adapters.Message {
+	return []adapters.Message{
+		{Role: "user", Parts: []adapters.ContentPart{
+			{Type: "text", Text: "What is the weather here?"},
+			{Type: "image_url", ImageURL: &adapters.ImageURL{URL: "data:image/png;base64,iVBORw0KGgo="}},
+		}},
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{
+			ID:       "call_abc",
+			Type:     "function",
+			Function: adapters.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+		}}},
+		{Role: "tool", ToolCallID: "call_abc", Content: "Sunny"},
+	}
+}
+
+// jsonEqual reports whether two JSON documents are equivalent
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var left, right interface{}
+	if err := json.Unmarshal(a, &left); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &right); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// TestChatCompletionRequestRoundTrip tests that tools, parts, null content,
+// a zero temperature and unknown fields survive decoding and re-encoding
+func TestChatCompletionRequestRoundTrip(t *testing.T) {
+	body := []byte(`{
+		"model": "gpt-4o",
+		"temperature": 0,
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "Describe this"},
+				{"type": "image_url", "image_url": {"url": "https://example.com/cat.png", "detail": "low"}, "cache_control": {"type": "ephemeral"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"cat\"}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "A cat"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}, "strict": true}}],
+		"tool_choice": {"type": "function", "function": {"name": "lookup"}},
+		"response_format": {"type": "json_schema", "json_schema": {"name": "answer", "schema": {"type": "object"}}},
+		"seed": 42,
+		"logit_bias": {"50256": -100}
+	}`)
+
+	var req adapters.ChatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if len(req.Messages[0].Parts) != 2 || req.Messages[0].Text() != "Describe this" {
+		t.Errorf("Unexpected parts: %+v", req.Messages[0].Parts)
+	}
+	if req.Messages[1].Content != "" || req.Messages[1].ToolCalls[0].Function.Arguments != `{"q":"cat"}` {
+		t.Errorf("Unexpected assistant message: %+v", req.Messages[1])
+	}
+	if req.ToolChoice == nil || req.ToolChoice.Function != "lookup" {
+		t.Errorf("Unexpected tool choice: %+v", req.ToolChoice)
+	}
+	if req.ResponseFormat == nil || req.ResponseFormat.JSONSchema.Name != "answer" {
+		t.Errorf("Unexpected response format: %+v", req.ResponseFormat)
+	}
+	if string(req.Extra["seed"]) != "42" || req.Messages[0].Parts[1].Extra["cache_control"] == nil {
+		t.Errorf("Expected unknown fields to be kept, got %v and %v", req.Extra, req.Messages[0].Parts[1].Extra)
+	}
+
+	encoded, err := json.Marshal(&req)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !jsonEqual(t, body, encoded) {
+		t.Errorf("Round trip changed the request:\n%s", encoded)
+	}
+
+	choice, _ := json.Marshal(adapters.ToolChoice{Mode: "required"})
+	if string(choice) != `"required"` {
+		t.Errorf("Expected a mode string, got %s", choice)
+	}
+}
+
+// TestContentPartRequiresPayload tests that image and audio parts without
+// their payload are rejected when decoding
+func TestContentPartRequiresPayload(t *testing.T) {
+	for _, body := range []string{
+		`{"model": "gpt-4o", "messages": [{"role": "user", "content": [{"type": "image_url"}]}]}`,
+		`{"model": "gpt-4o", "messages": [{"role": "user", "content": [{"type": "input_audio"}]}]}`,
+	} {
+		var req adapters.ChatCompletionRequest
+		if err := json.Unmarshal([]byte(body), &req); err == nil {
+			t.Errorf("Expected %s to be rejected", body)
+		}
+	}
+}
+
+// TestGatewayRedactsToolCalls tests that text parts and tool arguments are
+// tokenized upstream and that tool arguments are rehydrated as valid JSON
+func TestGatewayRedactsToolCalls(t *testing.T) {
+	adapter := &toolCallAdapter{}
+	gw := newTestGateway(t, adapter, "enforce")
+
+	req := &adapters.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []adapters.Message{
+			{Role: "user", Parts: []adapters.ContentPart{{Type: "text", Text: "Email jane.doe@example.com"}}},
+			{Role: "assistant", ToolCalls: []adapters.ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: adapters.ToolCallFunction{Name: "send_email", Arguments: `{"to":"jane.doe@example.com"}`},
+			}}},
+		},
+		Tools: []adapters.Tool{weatherTool},
+	}
+
+	resp, err := gw.ChatCompletion(context.Background(), "tenant-a", req)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	upstream := adapter.lastRequest
+	if strings.Contains(upstream.Messages[0].Parts[0].Text, "jane.doe") || strings.Contains(upstream.Messages[1].ToolCalls[0].Function.Arguments, "jane.doe") {
+		t.Errorf("Expected email to be redacted upstream, got %+v", upstream.Messages)
+	}
+	if len(upstream.Tools) != 1 {
+		t.Errorf("Expected tools to be forwarded, got %+v", upstream.Tools)
+	}
+
+	arguments := resp.Choices[0].Message.ToolCalls[0].Function.Arguments
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(arguments), &decoded); err != nil || decoded["to"] != "jane.doe@example.com" {
+		t.Errorf("Expected rehydrated JSON arguments, got %s", arguments)
+	}
+}
+
+// TestGatewayRehydratesStreamedToolArguments tests tokens split across tool
+// argument deltas
+func TestGatewayRehydratesStreamedToolArguments(t *testing.T) {
+	adapter := &toolCallAdapter{}
+	gw := newTestGateway(t, adapter, "enforce")
+
+	stream, err := gw.ChatCompletionStream(context.Background(), "tenant-a", &adapters.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []adapters.Message{{Role: "user", Content: "Email jane.doe@example.com"}},
+		Stream:   true,
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var arguments strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		for _, call := range chunk.Choices[0].Delta.ToolCalls {
+			if call.Index == nil || *call.Index != 0 {
+				t.Errorf("Expected tool call index 0, got %+v", call)
+			}
+			arguments.WriteString(call.Function.Arguments)
+		}
+	}
+
+	if arguments.String() != `{"to":"jane.doe@example.com"}` {
+		t.Errorf("Expected rehydrated arguments, got %s", arguments.String())
+	}
+}
+
+// toolCallAdapter answers every request with a call to send_email whose
+// arguments carry the first token of the last message
+type toolCallAdapter struct {
+	MockLLMAdapter
+	lastRequest *adapters.ChatCompletionRequest
+}
+
+func (a *toolCallAdapter) arguments(req *adapters.ChatCompletionRequest) string {
+	a.lastRequest = req
+	last := req.Messages[len(req.Messages)-1]
+	for _, text := range []string{last.Text(), req.Messages[0].Text()} {
+		if token := tokenPattern.FindString(text); token != "" {
+			return `{"to":"` + token + `"}`
+		}
+	}
+	return `{}`
+}
+
+func (a *toolCallAdapter) ChatCompletion(ctx context.Context, req *adapters.ChatCompletionRequest) (*adapters.ChatCompletionResponse, error) {
+	return &adapters.ChatCompletionResponse{
+		ID:    "chatcmpl-tools",
+		Model: req.Model,
+		Choices: []adapters.Choice{{
+			Message: adapters.Message{Role: "assistant", ToolCalls: []adapters.ToolCall{{
+				ID:       "call_2",
+				Type:     "function",
+				Function: adapters.ToolCallFunction{Name: "send_email", Arguments: a.arguments(req)},
+			}}},
+			FinishReason: "tool_calls",
+		}},
+	}, nil
+}
+
+func (a *toolCallAdapter) ChatCompletionStream(ctx context.Context, req *adapters.ChatCompletionRequest) (adapters.ChatCompletionStream, error) {
+	arguments := a.arguments(req)
+	index := 0
+
+	// Split the arguments inside the token
+	split := strings.Index(arguments, "token_") + 8
+	chunks := []adapters.ToolCall{
+		{Index: &index, ID: "call_2", Type: "function", Function: adapters.ToolCallFunction{Name: "send_email", Arguments: arguments[:split]}},
+		{Index: &index, Function: adapters.ToolCallFunction{Arguments: arguments[split:]}},
+	}
+
+	stream := &scriptedStream{}
+	for i, call := range chunks {
+		chunk := &adapters.ChatCompletionStreamResponse{
+			ID:      "chatcmpl-tools",
+			Choices: []adapters.StreamChoice{{Delta: adapters.Message{ToolCalls: []adapters.ToolCall{call}}}},
+		}
+		if i == len(chunks)-1 {
+			chunk.Choices[0].FinishReason = "tool_calls"
+		}
+		stream.chunks = append(stream.chunks, chunk)
+	}
+	return stream, nil
+}
+
+// scriptedStream returns fixed chunks
+type scriptedStream struct {
+	chunks []*adapters.ChatCompletionStreamResponse
+}
+
+func (s *scriptedStream) Recv() (*adapters.ChatCompletionStreamResponse, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *scriptedStream) Close() error {
+	return nil
+}
+
+// TestAnthropicToolUse tests tool, image and tool result translation
+func TestAnthropicToolUse(t *testing.T) {
+	var received anthropic.MessagesRequest
+	adapter := newAnthropicServer(t, http.StatusOK, `{
+		"id": "msg_2", "model": "claude-3-5-sonnet-20241022", "stop_reason": "tool_use",
+		"content": [{"type": "text", "text": "Checking"}, {"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}],
+		"usage": {"input_tokens": 20, "output_tokens": 10}
+	}`, &received)
+
+	resp, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:          "claude-3-5-sonnet-20241022",
+		Messages:       toolConversation(),
+		Tools:          []adapters.Tool{weatherTool},
+		ToolChoice:     &adapters.ToolChoice{Mode: "required"},
+		ResponseFormat: &adapters.ResponseFormat{Type: "json_object"},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if len(received.Tools) != 1 || received.Tools[0].Name != "get_weather" || received.ToolChoice.Type != "any" {
+		t.Errorf("Unexpected tools: %+v %+v", received.Tools, received.ToolChoice)
+	}
+	if !strings.Contains(received.System, "JSON") {
+		t.Errorf("Expected a JSON instruction in the system prompt, got %q", received.System)
+	}
+	if len(received.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %+v", received.Messages)
+	}
+	if image := received.Messages[0].Content[1]; image.Type != "image" || image.Source.MediaType != "image/png" || image.Source.Data != "iVBORw0KGgo=" {
+		t.Errorf("Unexpected image block: %+v", image)
+	}
+	if call := received.Messages[1].Content[0]; call.Type != "tool_use" || call.ID != "call_abc" || string(call.Input) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool_use block: %+v", call)
+	}
+	if result := received.Messages[2]; result.Role != "user" || result.Content[0].Type != "tool_result" || result.Content[0].ToolUseID != "call_abc" {
+		t.Errorf("Unexpected tool result: %+v", result)
+	}
+
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || choice.Message.Content != "Checking" || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("Unexpected choice: %+v", choice)
+	}
+	if call := choice.Message.ToolCalls[0]; call.ID != "toolu_1" || call.Function.Arguments != `{"city": "Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+// TestAnthropicToolUseStream tests streamed tool_use blocks
+func TestAnthropicToolUseStream(t *testing.T) {
+	body := "event: message_start\n" +
+		`data: {"type":"message_start","message":{"id":"msg_3","model":"claude-3-5-sonnet-20241022","usage":{"input_tokens":5}}}` + "\n\n" +
+		"event: content_block_start\n" +
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_2","name":"get_weather","input":{}}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}` + "\n\n" +
+		"event: content_block_delta\n" +
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}` + "\n\n" +
+		"event: message_delta\n" +
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}` + "\n\n" +
+		"event: message_stop\n" +
+		`data: {"type":"message_stop"}` + "\n\n"
+
+	adapter := newAnthropicServer(t, http.StatusOK, body, nil)
+	stream, err := adapter.ChatCompletionStream(context.Background(), &adapters.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []adapters.Message{{Role: "user", Content: "Weather in Paris?"}},
+		Tools:    []adapters.Tool{weatherTool},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var arguments strings.Builder
+	var name, finishReason string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		for _, call := range chunk.Choices[0].Delta.ToolCalls {
+			if call.Index == nil || *call.Index != 0 {
+				t.Errorf("Expected tool call index 0, got %+v", call)
+			}
+			if call.Function.Name != "" {
+				name = call.Function.Name
+			}
+			arguments.WriteString(call.Function.Arguments)
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+
+	if name != "get_weather" || arguments.String() != `{"city":"Paris"}` || finishReason != "tool_calls" {
+		t.Errorf("Unexpected streamed tool call %s(%s), finish reason %s", name, arguments.String(), finishReason)
+	}
+}
+
+// TestBedrockToolUse tests tool configuration and toolUse translation
+func TestBedrockToolUse(t *testing.T) {
+	var received bedrockRequest
+	endpoint := newBedrockServer(t, http.StatusOK, nil, []byte(`{
+		"output": {"message": {"role": "assistant", "content": [{"toolUse": {"toolUseId": "tooluse_1", "name": "get_weather", "input": {"city": "Paris"}}}]}},
+		"stopReason": "tool_use",
+		"usage": {"inputTokens": 9, "outputTokens": 2, "totalTokens": 11}
+	}`), &received)
+
+	adapter := newTestBedrockAdapter(endpoint, nil)
+	resp, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:      "anthropic.claude-3-haiku-20240307-v1:0",
+		Messages:   toolConversation(),
+		Tools:      []adapters.Tool{weatherTool},
+		ToolChoice: &adapters.ToolChoice{Function: "get_weather"},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	want := `{"messages":[` +
+		`{"role":"user","content":[{"text":"What is the weather here?"},{"image":{"format":"png","source":{"bytes":"iVBORw0KGgo="}}}]},` +
+		`{"role":"assistant","content":[{"toolUse":{"toolUseId":"call_abc","name":"get_weather","input":{"city":"Paris"}}}]},` +
+		`{"role":"user","content":[{"toolResult":{"toolUseId":"call_abc","content":[{"text":"Sunny"}]}}]}],` +
+		`"toolConfig":{"tools":[{"toolSpec":{"name":"get_weather","description":"Get the weather","inputSchema":{"json":{"type":"object","properties":{"city":{"type":"string"}}}}}}],` +
+		`"toolChoice":{"tool":{"name":"get_weather"}}}}`
+	if received.body != want {
+		t.Errorf("Unexpected request body:\n%s\nwant:\n%s", received.body, want)
+	}
+
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("Unexpected choice: %+v", choice)
+	}
+	if call := choice.Message.ToolCalls[0]; call.ID != "tooluse_1" || call.Function.Name != "get_weather" || call.Function.Arguments != `{"city": "Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+// TestGeminiFunctionCalling tests function declarations, function responses
+// and JSON mode
+func TestGeminiFunctionCalling(t *testing.T) {
+	var received geminiRequest
+	baseURL := newGeminiServer(t, http.StatusOK, `{
+		"candidates": [{"index": 0, "finishReason": "STOP",
+			"content": {"role": "model", "parts": [{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}}]}}]
+	}`, &received)
+
+	adapter := gemini.NewGeminiAdapter("gemini-key", baseURL, 5*time.Second)
+	resp, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:      "gemini-1.5-flash",
+		Messages:   toolConversation(),
+		Tools:      []adapters.Tool{weatherTool},
+		ToolChoice: &adapters.ToolChoice{Mode: "auto"},
+		ResponseFormat: &adapters.ResponseFormat{Type: "json_schema", JSONSchema: &adapters.JSONSchema{
+			Name:   "weather",
+			Schema: json.RawMessage(`{"type":"object"}`),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	want := `{"contents":[` +
+		`{"role":"user","parts":[{"text":"What is the weather here?"},{"inlineData":{"mimeType":"image/png","data":"iVBORw0KGgo="}}]},` +
+		`{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"}}}]},` +
+		`{"role":"user","parts":[{"functionResponse":{"name":"get_weather","response":{"content":"Sunny"}}}]}],` +
+		`"tools":[{"functionDeclarations":[{"name":"get_weather","description":"Get the weather","parametersJsonSchema":{"type":"object","properties":{"city":{"type":"string"}}}}]}],` +
+		`"toolConfig":{"functionCallingConfig":{"mode":"AUTO"}},` +
+		`"generationConfig":{"responseMimeType":"application/json","responseJsonSchema":{"type":"object"}}}`
+	if received.body != want {
+		t.Errorf("Unexpected request body:\n%s\nwant:\n%s", received.body, want)
+	}
+
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("Unexpected choice: %+v", choice)
+	}
+	if call := choice.Message.ToolCalls[0]; call.ID != "call_0" || call.Function.Arguments != `{"city": "Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+// TestOllamaToolCalling tests tools, images, JSON mode and tool call responses
+func TestOllamaToolCalling(t *testing.T) {
+	var received ollama.ChatRequest
+	baseURL := newOllamaServer(t, map[string]string{
+		"/api/chat": `{"model":"llama3.1","created_at":"2024-07-22T20:33:28Z",` +
+			`"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},` +
+			`"done":true,"done_reason":"stop","prompt_eval_count":10,"eval_count":5}`,
+	}, &received)
+
+	adapter := ollama.NewOllamaAdapter(baseURL, 5*time.Second)
+	resp, err := adapter.ChatCompletion(context.Background(), &adapters.ChatCompletionRequest{
+		Model:          "llama3.1",
+		Messages:       toolConversation(),
+		Tools:          []adapters.Tool{weatherTool},
+		ResponseFormat: &adapters.ResponseFormat{Type: "json_object"},
+	})
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	if string(received.Format) != `"json"` || len(received.Tools) != 1 {
+		t.Errorf("Unexpected format %s or tools %+v", received.Format, received.Tools)
+	}
+	if images := received.Messages[0].Images; len(images) != 1 || images[0] != "iVBORw0KGgo=" {
+		t.Errorf("Unexpected images: %v", images)
+	}
+	if calls := received.Messages[1].ToolCalls; len(calls) != 1 || string(calls[0].Function.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool calls: %+v", calls)
+	}
+	if result := received.Messages[2]; result.Role != "tool" || result.ToolName != "get_weather" || result.Content != "Sunny" {
+		t.Errorf("Unexpected tool result: %+v", result)
+	}
+
+	choice := resp.Choices[0]
+	if choice.FinishReason != "tool_calls" || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("Unexpected choice: %+v", choice)
+	}
+	if call := choice.Message.ToolCalls[0]; call.ID != "call_0" || call.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
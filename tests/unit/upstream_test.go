@@ -0,0 +1,246 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sentinel-platform/sentinel/proxy"
+)
+
+// countingUpstream starts a test server that counts requests and replies with status
+func countingUpstream(t *testing.T, status *int32, hits *int32) *url.URL {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		io.ReadAll(r.Body)
+		w.WriteHeader(int(atomic.LoadInt32(status)))
+		io.WriteString(w, r.Host)
+	}))
+	t.Cleanup(server.Close)
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse upstream URL: %v", err)
+	}
+	return target
+}
+
+// sendThroughPool sends a POST through a load balanced proxy and returns the status
+func sendThroughPool(rp *proxy.ReverseProxy) int {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4o"}`))
+	req.Header.Set("X-Tenant", "tenant-a")
+	rec := httptest.NewRecorder()
+	rp.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// newPoolProxy creates a load balanced proxy with pass-through processors
+func newPoolProxy(pool *proxy.UpstreamPool) *proxy.ReverseProxy {
+	return proxy.NewLoadBalancedReverseProxy(pool, &MockCipherMeshProcessor{}, &MockSentinelProcessor{}, &MockRateLimiter{}, 5*time.Second)
+}
+
+// TestUpstreamPoolWeightedRoundRobin tests that traffic follows upstream weights
+func TestUpstreamPoolWeightedRoundRobin(t *testing.T) {
+	okA, okB := int32(http.StatusOK), int32(http.StatusOK)
+	var hitsA, hitsB int32
+
+	pool, err := proxy.NewUpstreamPool(proxy.StrategyRoundRobin, 1, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create pool: %v", err)
+	}
+	pool.AddUpstream(countingUpstream(t, &okA, &hitsA), 3)
+	pool.AddUpstream(countingUpstream(t, &okB, &hitsB), 1)
+
+	rp := newPoolProxy(pool)
+	for i := 0; i < 8; i++ {
+		if code := sendThroughPool(rp); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+	}
+
+	if hitsA != 6 || hitsB != 2 {
+		t.Errorf("Expected 6/2 split, got %d/%d", hitsA, hitsB)
+	}
+}
+
+// TestUpstreamPoolFailoverOn5xx tests failover and passive health marking
+func TestUpstreamPoolFailoverOn5xx(t *testing.T) {
+	failing, ok := int32(http.StatusServiceUnavailable), int32(http.StatusOK)
+	var hitsFailing, hitsOK int32
+
+	pool, _ := proxy.NewUpstreamPool(proxy.StrategyRoundRobin, 1, time.Minute)
+	pool.AddUpstream(countingUpstream(t, &failing, &hitsFailing), 1)
+	pool.AddUpstream(countingUpstream(t, &ok, &hitsOK), 1)
+
+	rp := newPoolProxy(pool)
+	for i := 0; i < 4; i++ {
+		if code := sendThroughPool(rp); code != http.StatusOK {
+			t.Fatalf("Expected failover to succeed, got %d", code)
+		}
+	}
+
+	if hitsFailing != 1 {
+		t.Errorf("Expected failing upstream to be tried once before being marked unhealthy, got %d", hitsFailing)
+	}
+
+	statuses := pool.Upstreams()
+	if statuses[0].Healthy || !statuses[1].Healthy {
+		t.Errorf("Unexpected health states: %+v", statuses)
+	}
+}
+
+// TestUpstreamPoolFailoverOn429 tests that a rate limited upstream is avoided
+// for its Retry-After without being marked unhealthy
+func TestUpstreamPoolFailoverOn429(t *testing.T) {
+	ok := int32(http.StatusOK)
+	var hitsLimited, hitsOK int32
+
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hitsLimited, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+	limitedURL, _ := url.Parse(limited.URL)
+
+	pool, _ := proxy.NewUpstreamPool(proxy.StrategyRoundRobin, 1, time.Minute)
+	pool.AddUpstream(limitedURL, 1)
+	pool.AddUpstream(countingUpstream(t, &ok, &hitsOK), 1)
+
+	rp := newPoolProxy(pool)
+	for i := 0; i < 4; i++ {
+		if code := sendThroughPool(rp); code != http.StatusOK {
+			t.Fatalf("Expected failover to succeed, got %d", code)
+		}
+	}
+
+	if hitsLimited != 1 || hitsOK != 4 {
+		t.Errorf("Expected the rate limited upstream to be skipped after one request, got %d/%d", hitsLimited, hitsOK)
+	}
+
+	statuses := pool.Upstreams()
+	if !statuses[0].Healthy || !statuses[0].Throttled {
+		t.Errorf("Expected a healthy, throttled upstream, got %+v", statuses[0])
+	}
+}
+
+// TestUpstreamPoolNoFailoverOn4xx tests that client errors are returned
+// without trying another upstream
+func TestUpstreamPoolNoFailoverOn4xx(t *testing.T) {
+	badRequest, ok := int32(http.StatusBadRequest), int32(http.StatusOK)
+	var hitsBad, hitsOK int32
+
+	pool, _ := proxy.NewUpstreamPool(proxy.StrategyRoundRobin, 1, time.Minute)
+	pool.AddUpstream(countingUpstream(t, &badRequest, &hitsBad), 1)
+	pool.AddUpstream(countingUpstream(t, &ok, &hitsOK), 1)
+
+	rp := newPoolProxy(pool)
+	if code := sendThroughPool(rp); code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 to be passed through, got %d", code)
+	}
+	if hitsBad != 1 || hitsOK != 0 {
+		t.Errorf("Expected a single attempt, got %d/%d", hitsBad, hitsOK)
+	}
+	if statuses := pool.Upstreams(); !statuses[0].Healthy {
+		t.Errorf("Expected a 400 not to mark the upstream unhealthy, got %+v", statuses[0])
+	}
+}
+
+// TestUpstreamPoolFailoverOnConnectionError tests failover when an upstream is down
+func TestUpstreamPoolFailoverOnConnectionError(t *testing.T) {
+	ok := int32(http.StatusOK)
+	var hits int32
+
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL, _ := url.Parse(dead.URL)
+	dead.Close()
+
+	pool, _ := proxy.NewUpstreamPool(proxy.StrategyLeastOutstanding, 1, time.Minute)
+	pool.AddUpstream(deadURL, 1)
+	pool.AddUpstream(countingUpstream(t, &ok, &hits), 1)
+
+	rp := newPoolProxy(pool)
+	if code := sendThroughPool(rp); code != http.StatusOK {
+		t.Fatalf("Expected failover to succeed, got %d", code)
+	}
+	if hits != 1 {
+		t.Errorf("Expected the live upstream to serve the request, got %d hits", hits)
+	}
+}
+
+// TestUpstreamPoolAllFailing tests that exhausting every upstream returns 502
+func TestUpstreamPoolAllFailing(t *testing.T) {
+	failing := int32(http.StatusInternalServerError)
+	var hits int32
+
+	pool, _ := proxy.NewUpstreamPool(proxy.StrategyRoundRobin, 3, time.Minute)
+	pool.AddUpstream(countingUpstream(t, &failing, &hits), 1)
+
+	rp := newPoolProxy(pool)
+	if code := sendThroughPool(rp); code != http.StatusInternalServerError {
+		t.Errorf("Expected the last upstream status to be passed through, got %d", code)
+	}
+}
+
+// TestUpstreamPoolActiveHealthCheck tests that active checks mark upstreams up and down
+func TestUpstreamPoolActiveHealthCheck(t *testing.T) {
+	status := int32(http.StatusServiceUnavailable)
+	var hits int32
+
+	pool, _ := proxy.NewUpstreamPool(proxy.StrategyRoundRobin, 1, time.Minute)
+	pool.AddUpstream(countingUpstream(t, &status, &hits), 1)
+
+	client := &http.Client{Timeout: time.Second}
+	pool.CheckHealth(context.Background(), client, "/health")
+	if pool.Upstreams()[0].Healthy {
+		t.Error("Expected upstream to be marked unhealthy")
+	}
+
+	atomic.StoreInt32(&status, http.StatusOK)
+	pool.CheckHealth(context.Background(), client, "/health")
+	if !pool.Upstreams()[0].Healthy {
+		t.Error("Expected upstream to be marked healthy again")
+	}
+}
+
+// TestReverseProxyConcurrentTargetSwap tests that swapping targets while serving is race-free
+func TestReverseProxyConcurrentTargetSwap(t *testing.T) {
+	okA, okB := int32(http.StatusOK), int32(http.StatusOK)
+	var hitsA, hitsB int32
+	targetA := countingUpstream(t, &okA, &hitsA)
+	targetB := countingUpstream(t, &okB, &hitsB)
+
+	rp := proxy.NewReverseProxy(targetA, &MockCipherMeshProcessor{}, &MockSentinelProcessor{}, &MockRateLimiter{}, 5*time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sendThroughPool(rp)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				rp.SetTargetURL(targetA)
+			} else {
+				rp.SetTargetURL(targetB)
+			}
+			_ = rp.GetTargetURL()
+		}(i)
+	}
+	wg.Wait()
+
+	if hitsA+hitsB != 20 {
+		t.Errorf("Expected 20 requests to be served, got %d", hitsA+hitsB)
+	}
+}
@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sentinel-platform/sentinel/sentinel/ciphermesh/detectors"
+)
+
+// TestValidators tests the built-in checksum and range validators
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator detectors.Validator
+		match     string
+		want      bool
+	}{
+		{"luhn visa", detectors.ValidateLuhn, "4111 1111 1111 1111", true},
+		{"luhn amex", detectors.ValidateLuhn, "3782-822463-10005", true},
+		{"luhn typo", detectors.ValidateLuhn, "4111111111111112", false},
+		{"luhn order id", detectors.ValidateLuhn, "20240615123045", false},
+		{"iin mastercard 2-series", detectors.ValidateCardIIN, "2223003122003222", true},
+		{"iin amex length", detectors.ValidateCardIIN, "3782822463100050", false},
+		{"iin unassigned", detectors.ValidateCardIIN, "1234567812345670", false},
+		{"aba valid", detectors.ValidateABARouting, "021000021", true},
+		{"aba checksum", detectors.ValidateABARouting, "123456789", false},
+		{"aba prefix", detectors.ValidateABARouting, "500000005", false},
+		{"ssn valid", detectors.ValidateSSN, "123-45-6789", true},
+		{"ssn area 666", detectors.ValidateSSN, "666-12-3456", false},
+		{"ssn area 9xx", detectors.ValidateSSN, "912-34-5678", false},
+		{"ssn group", detectors.ValidateSSN, "123-00-4567", false},
+		{"ssn serial", detectors.ValidateSSN, "123-45-0000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.validator(tt.match); got != tt.want {
+				t.Errorf("validator(%q) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+
+	if brand := detectors.CardBrand("5555555555554444"); brand != "mastercard" {
+		t.Errorf("Expected mastercard, got %q", brand)
+	}
+}
+
+// TestNationalIDValidators tests the checksums of the international packs
+func TestNationalIDValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator detectors.Validator
+		match     string
+		want      bool
+	}{
+		{"iban de", detectors.ValidateIBAN, "DE89370400440532013000", true},
+		{"iban gb spaced", detectors.ValidateIBAN, "GB82 WEST 1234 5698 7654 32", true},
+		{"iban fr letters", detectors.ValidateIBAN, "FR1420041010050500013M02606", true},
+		{"iban checksum", detectors.ValidateIBAN, "DE89370400440532013001", false},
+		{"iban length", detectors.ValidateIBAN, "DE8937040044053201300", false},
+		{"nino valid", detectors.ValidateNINO, "AB 12 34 56 C", true},
+		{"nino prefix", detectors.ValidateNINO, "GB123456A", false},
+		{"nhs valid", detectors.ValidateNHS, "943 476 5919", true},
+		{"nhs checksum", detectors.ValidateNHS, "943 476 5918", false},
+		{"steuer-id valid", detectors.ValidateSteuerID, "86095742719", true},
+		{"steuer-id checksum", detectors.ValidateSteuerID, "86095742718", false},
+		{"steuer-id no repeat", detectors.ValidateSteuerID, "12345678901", false},
+		{"insee valid", detectors.ValidateINSEE, "2 85 05 78 006 048 77", true},
+		{"insee corsica", detectors.ValidateINSEE, "1 84 12 2A 451 089 33", true},
+		{"insee key", detectors.ValidateINSEE, "2 85 05 78 006 048 76", false},
+		{"insee month", detectors.ValidateINSEE, "2 85 15 78 006 048 77", false},
+		{"dni valid", detectors.ValidateDNI, "12345678Z", true},
+		{"dni letter", detectors.ValidateDNI, "12345678A", false},
+		{"nie x", detectors.ValidateDNI, "X-1234567-L", true},
+		{"nie y", detectors.ValidateDNI, "Y1234567X", true},
+		{"aadhaar valid", detectors.ValidateAadhaar, "2341 2341 2346", true},
+		{"aadhaar checksum", detectors.ValidateAadhaar, "234123412345", false},
+		{"aadhaar leading 1", detectors.ValidateAadhaar, "123412341234", false},
+		{"sin valid", detectors.ValidateSIN, "130 692 544", true},
+		{"sin checksum", detectors.ValidateSIN, "130-692-545", false},
+		{"sin leading 0", detectors.ValidateSIN, "046 454 286", false},
+		{"tfn valid", detectors.ValidateTFN, "123 456 782", true},
+		{"tfn checksum", detectors.ValidateTFN, "123456781", false},
+		{"nric s", detectors.ValidateNRIC, "S1234567D", true},
+		{"nric t", detectors.ValidateNRIC, "T1234567J", true},
+		{"fin f", detectors.ValidateNRIC, "F1234567N", true},
+		{"fin g", detectors.ValidateNRIC, "G1234567X", true},
+		{"nric letter", detectors.ValidateNRIC, "S1234567A", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.validator(tt.match); got != tt.want {
+				t.Errorf("validator(%q) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSafeHarborValidators tests the checks of the Safe Harbor pack
+func TestSafeHarborValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator detectors.Validator
+		match     string
+		want      bool
+	}{
+		{"npi valid", detectors.ValidateNPI, "1234567893", true},
+		{"npi checksum", detectors.ValidateNPI, "1234567890", false},
+		{"npi leading 3", detectors.ValidateNPI, "3234567893", false},
+		{"dea valid", detectors.ValidateDEA, "AB1234563", true},
+		{"dea mid-level", detectors.ValidateDEA, "MJ1234563", true},
+		{"dea checksum", detectors.ValidateDEA, "AB1234564", false},
+		{"dea registrant", detectors.ValidateDEA, "ZB1234563", false},
+		{"vin valid", detectors.ValidateVIN, "1M8GDM9AXKP042788", true},
+		{"vin check digit", detectors.ValidateVIN, "1M8GDM9AXKP042789", false},
+		{"vin digits only", detectors.ValidateVIN, "11111111111111111", false},
+		{"date us", detectors.ValidateDate, "03/15/1948", true},
+		{"date day first", detectors.ValidateDate, "15/03/1948", true},
+		{"date iso", detectors.ValidateDate, "2024-02-11", true},
+		{"date month name", detectors.ValidateDate, "Sept. 4, 2023", true},
+		{"date month", detectors.ValidateDate, "13/45/1948", false},
+		{"date iso month", detectors.ValidateDate, "2024-13-01", false},
+		{"date day", detectors.ValidateDate, "March 32, 1948", false},
+		{"ipv4 valid", detectors.ValidateIPv4, "192.168.10.42", true},
+		{"ipv4 octet", detectors.ValidateIPv4, "1.2.300.4", false},
+		{"ipv6 valid", detectors.ValidateIPv6, "2001:db8::1", true},
+		{"ipv6 unspecified", detectors.ValidateIPv6, "::", false},
+		{"ipv6 time", detectors.ValidateIPv6, "12:30:45", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.validator(tt.match); got != tt.want {
+				t.Errorf("validator(%q) = %v, want %v", tt.match, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRegexDetectorValidators tests that failed matches are dropped or kept
+// with lower confidence
+func TestRegexDetectorValidators(t *testing.T) {
+	detector, err := detectors.NewRegexDetector("card", "pci", "credit_card", `\b\d{16}\b`, 0.9, 10)
+	if err != nil {
+		t.Fatalf("Failed to create detector: %v", err)
+	}
+	detector.AddValidator(detectors.ValidateLuhn, 0)
+	detector.AddValidator(detectors.ValidateCardIIN, 0.5)
+
+	results, err := detector.Detect(context.Background(), "card 4111111111111111, order 2024061512304599, test 1234567812345670")
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected the order ID to be dropped, got %+v", results)
+	}
+	if results[0].Text != "4111111111111111" || results[0].Confidence != 0.9 {
+		t.Errorf("Unexpected card detection: %+v", results[0])
+	}
+	if results[1].Text != "1234567812345670" || results[1].Confidence != 0.5 {
+		t.Errorf("Expected an unassigned IIN to lower confidence, got %+v", results[1])
+	}
+}
@@ -0,0 +1,153 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OpenAI encodings that can be loaded from tiktoken files
+const (
+	EncodingO200k  = "o200k_base"
+	EncodingCl100k = "cl100k_base"
+	EncodingP50k   = "p50k_base"
+	EncodingR50k   = "r50k_base"
+)
+
+// encodingPatterns maps each known encoding to its pre-tokenizer
+var encodingPatterns = map[string]*regexp.Regexp{
+	EncodingO200k:  o200kPattern,
+	EncodingCl100k: cl100kPattern,
+	EncodingP50k:   gpt2Pattern,
+	EncodingR50k:   gpt2Pattern,
+}
+
+// Encoding is a byte-level BPE encoding such as cl100k_base
+type Encoding struct {
+	name    string
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// LoadEncoding reads an encoding in tiktoken format: one base64 token and
+// its rank per line. name must be one of the known OpenAI encodings, as it
+// selects the pre-tokenizer.
+func LoadEncoding(name string, r io.Reader) (*Encoding, error) {
+	pattern, ok := encodingPatterns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding: %s", name)
+	}
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		token, rank, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("%s line %d: expected a token and a rank", name, line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid token: %w", name, line, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid rank: %w", name, line, err)
+		}
+		ranks[string(decoded)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	return &Encoding{name: name, ranks: ranks, pattern: pattern}, nil
+}
+
+// LoadEncodingFile reads an encoding from a tiktoken file
+func LoadEncodingFile(name, path string) (*Encoding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadEncoding(name, file)
+}
+
+// loadEncodings loads every known encoding with a <name>.tiktoken file in dir
+func loadEncodings(dir string) (map[string]*Encoding, error) {
+	encodings := make(map[string]*Encoding)
+	for name := range encodingPatterns {
+		encoding, err := LoadEncodingFile(name, filepath.Join(dir, name+".tiktoken"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		encodings[name] = encoding
+	}
+	return encodings, nil
+}
+
+// Name returns the encoding's name
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode returns the token IDs for text
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range split(e.pattern, text) {
+		tokens = e.encodePiece(piece, tokens)
+	}
+	return tokens
+}
+
+// Count returns the number of tokens in text
+func (e *Encoding) Count(text string) int {
+	return len(e.Encode(text))
+}
+
+// encodePiece appends the tokens of one pre-tokenized piece, repeatedly
+// merging the adjacent pair with the lowest rank as tiktoken does
+func (e *Encoding) encodePiece(piece string, tokens []int) []int {
+	if rank, ok := e.ranks[piece]; ok {
+		return append(tokens, rank)
+	}
+
+	// bounds holds the start of each part, and the end of the last
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := e.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	for i := 0; i+1 < len(bounds); i++ {
+		tokens = append(tokens, e.ranks[piece[bounds[i]:bounds[i+1]]])
+	}
+	return tokens
+}
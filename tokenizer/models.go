@@ -0,0 +1,62 @@
+package tokenizer
+
+import "strings"
+
+// builtinModels lists the encoding and published limits of common models
+// by name prefix. A zero limit is not enforced.
+var builtinModels = []struct {
+	prefix string
+	ModelSettings
+}{
+	{"gpt-3.5-turbo", ModelSettings{EncodingCl100k, 16385, 4096}},
+	{"gpt-4", ModelSettings{EncodingCl100k, 8192, 8192}},
+	{"gpt-4-32k", ModelSettings{EncodingCl100k, 32768, 32768}},
+	{"gpt-4-turbo", ModelSettings{EncodingCl100k, 128000, 4096}},
+	{"gpt-4-1106", ModelSettings{EncodingCl100k, 128000, 4096}},
+	{"gpt-4-0125", ModelSettings{EncodingCl100k, 128000, 4096}},
+	{"gpt-4-vision", ModelSettings{EncodingCl100k, 128000, 4096}},
+	{"gpt-4.5", ModelSettings{EncodingO200k, 128000, 16384}},
+	{"gpt-4o", ModelSettings{EncodingO200k, 128000, 16384}},
+	{"gpt-4o-mini", ModelSettings{EncodingO200k, 128000, 16384}},
+	{"gpt-4.1", ModelSettings{EncodingO200k, 1047576, 32768}},
+	{"o1", ModelSettings{EncodingO200k, 200000, 100000}},
+	{"o3", ModelSettings{EncodingO200k, 200000, 100000}},
+	{"o4-mini", ModelSettings{EncodingO200k, 200000, 100000}},
+	{"text-embedding-3", ModelSettings{EncodingCl100k, 8191, 0}},
+	{"text-embedding-ada-002", ModelSettings{EncodingCl100k, 8191, 0}},
+	{"claude", ModelSettings{FamilyAnthropic, 200000, 0}},
+	{"claude-3-haiku", ModelSettings{FamilyAnthropic, 200000, 4096}},
+	{"claude-3-opus", ModelSettings{FamilyAnthropic, 200000, 4096}},
+	{"claude-3-5-sonnet", ModelSettings{FamilyAnthropic, 200000, 8192}},
+	{"claude-3-5-haiku", ModelSettings{FamilyAnthropic, 200000, 8192}},
+	{"gemini", ModelSettings{FamilyGemini, 0, 8192}},
+	{"gemini-1.5-pro", ModelSettings{FamilyGemini, 2097152, 8192}},
+	{"gemini-1.5-flash", ModelSettings{FamilyGemini, 1048576, 8192}},
+	{"gemini-2.0-flash", ModelSettings{FamilyGemini, 1048576, 8192}},
+	{"text-embedding-004", ModelSettings{FamilyGemini, 2048, 0}},
+	{"llama", ModelSettings{FamilyLlama, 0, 0}},
+	{"llama3", ModelSettings{FamilyLlama, 8192, 0}},
+	{"llama3.1", ModelSettings{FamilyLlama, 131072, 0}},
+	{"mistral", ModelSettings{FamilyMistral, 32768, 0}},
+	{"mixtral", ModelSettings{FamilyMistral, 32768, 0}},
+}
+
+// builtinModel returns the built-in settings with the longest prefix
+// matching model. Provider-qualified IDs such as Bedrock's
+// anthropic.claude-3-haiku-20240307-v1:0 and meta.llama3-8b-instruct-v1:0
+// are matched without their provider prefix.
+func builtinModel(model string) ModelSettings {
+	model = strings.ToLower(model)
+	if provider, name, ok := strings.Cut(model, "."); ok && !strings.ContainsAny(provider, "-0123456789") {
+		model = name
+	}
+
+	settings := ModelSettings{Encoding: FamilyGeneric}
+	best := 0
+	for _, builtin := range builtinModels {
+		if strings.HasPrefix(model, builtin.prefix) && len(builtin.prefix) > best {
+			settings, best = builtin.ModelSettings, len(builtin.prefix)
+		}
+	}
+	return settings
+}
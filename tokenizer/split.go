@@ -0,0 +1,58 @@
+package tokenizer
+
+import (
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pre-tokenizer patterns of the OpenAI encodings. The originals end with
+// \s+(?!\S)|\s+, whose lookahead RE2 cannot express, so they end with \s+
+// here and split applies the lookahead by hand.
+var (
+	gpt2Pattern = regexp.MustCompile(`^(?:'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+)`)
+
+	cl100kPattern = regexp.MustCompile(`^(?:(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+)`)
+
+	o200kPattern = regexp.MustCompile(`^(?:[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+)`)
+)
+
+// split breaks text into the pieces that BPE merges within
+func split(pattern *regexp.Regexp, text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		end := len(text)
+		if loc := pattern.FindStringIndex(text); loc != nil && loc[1] > 0 {
+			end = loc[1]
+		} else {
+			// Unmatched input is a piece of its own, one rune at a time
+			_, end = utf8.DecodeRuneInString(text)
+		}
+
+		// \s+(?!\S): a run of spaces before a word leaves its last space
+		// to the word
+		if end < len(text) && isSpaceRun(text[:end]) {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			last, size := utf8.DecodeLastRuneInString(text[:end])
+			if !unicode.IsSpace(next) && last != '\n' && last != '\r' && size < end {
+				end -= size
+			}
+		}
+
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	return pieces
+}
+
+// isSpaceRun reports whether text is entirely whitespace
+func isSpaceRun(text string) bool {
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
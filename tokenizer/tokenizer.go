@@ -0,0 +1,295 @@
+// Package tokenizer counts tokens offline, so the gateway can estimate
+// prompt size before forwarding a request, fill in usage that providers do
+// not report and enforce per-model limits. OpenAI models are counted exactly
+// when their BPE tables are loaded; other models use approximate counters.
+package tokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sentinel-platform/sentinel/adapters"
+)
+
+// Approximate counter families for models without loaded BPE tables
+const (
+	FamilyGeneric   = "generic"
+	FamilyAnthropic = "anthropic"
+	FamilyGemini    = "gemini"
+	FamilyLlama     = "llama"
+	FamilyMistral   = "mistral"
+)
+
+// Chat formatting overhead, as documented for OpenAI chat models
+const (
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	tokensPerReply   = 3
+	tokensPerImage   = 765
+)
+
+// Counter counts the tokens in text
+type Counter interface {
+	Count(text string) int
+}
+
+// Settings configures token counting and limits
+type Settings struct {
+	// EncodingsDir holds tiktoken BPE files named after their encoding,
+	// e.g. cl100k_base.tiktoken and o200k_base.tiktoken
+	EncodingsDir string `mapstructure:"encodingsDir"`
+	// EnforceLimits rejects requests that exceed their model's limits
+	EnforceLimits bool `mapstructure:"enforceLimits"`
+	// Models overrides the built-in model table. Keys are model names; a
+	// trailing * matches by prefix.
+	Models map[string]ModelSettings `mapstructure:"models"`
+}
+
+// ModelSettings describes how to count tokens for a model and its limits.
+// Zero values fall back to the built-in table.
+type ModelSettings struct {
+	// Encoding is an OpenAI encoding or an approximate counter family
+	Encoding        string `mapstructure:"encoding"`
+	ContextWindow   int    `mapstructure:"contextWindow"`
+	MaxOutputTokens int    `mapstructure:"maxOutputTokens"`
+}
+
+// LimitError is returned when a request exceeds its model's limits
+type LimitError struct {
+	Model string
+	// Limit is context_window or max_output_tokens
+	Limit        string
+	Allowed      int
+	PromptTokens int
+	MaxTokens    int
+}
+
+// Error implements the error interface
+func (e *LimitError) Error() string {
+	if e.Limit == "max_output_tokens" {
+		return fmt.Sprintf("max_tokens is too large: %d. %s supports at most %d completion tokens", e.MaxTokens, e.Model, e.Allowed)
+	}
+	return fmt.Sprintf("%s's maximum context length is %d tokens. However, you requested about %d tokens (%d in the messages, %d in the completion)",
+		e.Model, e.Allowed, e.PromptTokens+e.MaxTokens, e.PromptTokens, e.MaxTokens)
+}
+
+// Tokenizer counts tokens and checks limits by model
+type Tokenizer struct {
+	encodings     map[string]*Encoding
+	models        map[string]ModelSettings
+	enforceLimits bool
+}
+
+// New creates a tokenizer, loading any BPE tables found in the settings'
+// encodings directory
+func New(settings Settings) (*Tokenizer, error) {
+	encodings := make(map[string]*Encoding)
+	if settings.EncodingsDir != "" {
+		loaded, err := loadEncodings(settings.EncodingsDir)
+		if err != nil {
+			return nil, err
+		}
+		encodings = loaded
+	}
+
+	for model, config := range settings.Models {
+		if config.Encoding != "" && encodingPatterns[config.Encoding] == nil && approximations[config.Encoding] == 0 {
+			return nil, fmt.Errorf("model %s: unknown encoding %s", model, config.Encoding)
+		}
+	}
+
+	return &Tokenizer{
+		encodings:     encodings,
+		models:        settings.Models,
+		enforceLimits: settings.EnforceLimits,
+	}, nil
+}
+
+// AddEncoding makes a loaded encoding available to the models that use it
+func (t *Tokenizer) AddEncoding(encoding *Encoding) {
+	t.encodings[encoding.name] = encoding
+}
+
+// Model returns the encoding and limits for a model
+func (t *Tokenizer) Model(model string) ModelSettings {
+	settings := builtinModel(model)
+
+	bestScore := -1
+	var override ModelSettings
+	for pattern, config := range t.models {
+		if score := matchModel(pattern, model); score > bestScore {
+			bestScore, override = score, config
+		}
+	}
+	if bestScore >= 0 {
+		if override.Encoding != "" {
+			settings.Encoding = override.Encoding
+		}
+		if override.ContextWindow > 0 {
+			settings.ContextWindow = override.ContextWindow
+		}
+		if override.MaxOutputTokens > 0 {
+			settings.MaxOutputTokens = override.MaxOutputTokens
+		}
+	}
+	return settings
+}
+
+// Counter returns the token counter for a model: its BPE encoding if it is
+// loaded, and an approximate counter otherwise
+func (t *Tokenizer) Counter(model string) Counter {
+	encoding := t.Model(model).Encoding
+	if loaded, ok := t.encodings[encoding]; ok {
+		return loaded
+	}
+	return approximateCounterFor(encoding)
+}
+
+// CountText returns the number of tokens in text for a model
+func (t *Tokenizer) CountText(model, text string) int {
+	return t.Counter(model).Count(text)
+}
+
+// CountRequest estimates the prompt tokens of a chat completion request,
+// including message formatting, images, tool definitions and the response
+// format schema
+func (t *Tokenizer) CountRequest(req *adapters.ChatCompletionRequest) int {
+	counter := t.Counter(req.Model)
+
+	total := tokensPerReply
+	for _, message := range req.Messages {
+		total += tokensPerMessage + counter.Count(message.Role) + counter.Count(message.Text())
+		if message.Name != "" {
+			total += tokensPerName + counter.Count(message.Name)
+		}
+		for _, part := range message.Parts {
+			if part.Type == "image_url" {
+				total += tokensPerImage
+			}
+		}
+		for _, call := range message.ToolCalls {
+			total += tokensPerMessage + counter.Count(call.Function.Name) + counter.Count(call.Function.Arguments)
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		tools, _ := json.Marshal(req.Tools)
+		total += counter.Count(string(tools))
+	}
+	if req.ResponseFormat != nil && req.ResponseFormat.JSONSchema != nil {
+		total += counter.Count(string(req.ResponseFormat.JSONSchema.Schema))
+	}
+	return total
+}
+
+// CountCompletion counts the tokens generated in a response's choices
+func (t *Tokenizer) CountCompletion(resp *adapters.ChatCompletionResponse) int {
+	counter := t.Counter(resp.Model)
+
+	total := 0
+	for _, choice := range resp.Choices {
+		total += counter.Count(choice.Message.Text())
+		for _, call := range choice.Message.ToolCalls {
+			total += counter.Count(call.Function.Name) + counter.Count(call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// Check estimates a request's prompt tokens and, when limits are enforced,
+// returns a *LimitError if max_tokens exceeds the model's output limit or
+// the prompt and max_tokens together exceed its context window. The context
+// window is only checked when the model's encoding is loaded, so valid
+// prompts are not rejected on an approximate count.
+func (t *Tokenizer) Check(req *adapters.ChatCompletionRequest) (int, error) {
+	promptTokens := t.CountRequest(req)
+	if !t.enforceLimits {
+		return promptTokens, nil
+	}
+
+	limits := t.Model(req.Model)
+	if limits.MaxOutputTokens > 0 && req.MaxTokens > limits.MaxOutputTokens {
+		return promptTokens, &LimitError{
+			Model:        req.Model,
+			Limit:        "max_output_tokens",
+			Allowed:      limits.MaxOutputTokens,
+			PromptTokens: promptTokens,
+			MaxTokens:    req.MaxTokens,
+		}
+	}
+	exact := t.encodings[limits.Encoding] != nil
+	if exact && limits.ContextWindow > 0 && promptTokens+req.MaxTokens > limits.ContextWindow {
+		return promptTokens, &LimitError{
+			Model:        req.Model,
+			Limit:        "context_window",
+			Allowed:      limits.ContextWindow,
+			PromptTokens: promptTokens,
+			MaxTokens:    req.MaxTokens,
+		}
+	}
+	return promptTokens, nil
+}
+
+// approximations is the typical number of bytes of a single token in a
+// word, by counter family
+var approximations = map[string]int{
+	FamilyGeneric:   8,
+	FamilyAnthropic: 7,
+	FamilyGemini:    8,
+	FamilyLlama:     8,
+	FamilyMistral:   7,
+	// OpenAI encodings whose tables are not loaded
+	EncodingO200k:  8,
+	EncodingCl100k: 8,
+	EncodingP50k:   7,
+	EncodingR50k:   7,
+}
+
+// approximateCounter estimates tokens by splitting text as cl100k_base does
+// and charging each piece by length. Letters outside ASCII are charged a
+// token each, which overestimates for most non-Latin scripts.
+type approximateCounter int
+
+// approximateCounterFor returns the approximate counter for a family or encoding
+func approximateCounterFor(family string) approximateCounter {
+	if bytes, ok := approximations[family]; ok {
+		return approximateCounter(bytes)
+	}
+	return approximateCounter(approximations[FamilyGeneric])
+}
+
+// Count estimates the number of tokens in text
+func (c approximateCounter) Count(text string) int {
+	total := 0
+	for _, piece := range split(cl100kPattern, text) {
+		ascii, other := 0, 0
+		for _, r := range piece {
+			if r < utf8.RuneSelf {
+				ascii++
+			} else {
+				other++
+			}
+		}
+		total += other + (ascii+int(c)-1)/int(c)
+	}
+	return total
+}
+
+// matchModel scores how well a pattern matches a model: exact names score
+// above prefix patterns, longer prefixes above shorter ones, and -1 means
+// no match. Matching ignores case.
+func matchModel(pattern, model string) int {
+	pattern, model = strings.ToLower(pattern), strings.ToLower(model)
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		if strings.HasPrefix(model, prefix) {
+			return len(prefix)
+		}
+		return -1
+	}
+	if pattern == model {
+		return len(pattern) + 1
+	}
+	return -1
+}